@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the agent's configuration file (-config). It is reloaded on SIGHUP.
+// If processes or ports are omitted, the agent watches a built-in list of
+// common ones and reports only those it finds; configured ones are always
+// reported, as stopped or closed if they are missing.
+type Config struct {
+	Processes  []ProcessConfig `yaml:"processes"`
+	Ports      []PortConfig    `yaml:"ports"`
+	Mounts     FilterConfig    `yaml:"mounts"`     // Patterns matched against mount points
+	Interfaces FilterConfig    `yaml:"interfaces"` // Patterns matched against interface names
+}
+
+// ProcessConfig is a process to watch. It is matched by the process name
+// (comm) unless a cmdline pattern or pidfile is given.
+type ProcessConfig struct {
+	Name    string `yaml:"name"`    // Name the process is reported as
+	Cmdline string `yaml:"cmdline"` // Regular expression matched against the full command line
+	Pidfile string `yaml:"pidfile"` // File holding the process ID
+
+	cmdline *regexp.Regexp
+}
+
+// PortConfig is a port that should have a listening socket
+type PortConfig struct {
+	Port     int    `yaml:"port"`
+	Protocol string `yaml:"protocol"` // tcp (default) or udp
+	Family   string `yaml:"family"`   // ipv4, ipv6, or empty for either
+}
+
+// FilterConfig selects names with glob patterns (path.Match syntax). If any
+// include patterns are given, only matching names are kept; excludes always
+// win. A name matching an include pattern is kept even if the agent would skip
+// it by default (e.g. tmpfs mounts or the loopback interface).
+type FilterConfig struct {
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// currentConfig holds the active configuration; nil means the built-in defaults
+var currentConfig atomic.Pointer[Config]
+
+// activeConfig returns the active configuration, or an empty one
+func activeConfig() *Config {
+	if config := currentConfig.Load(); config != nil {
+		return config
+	}
+	return &Config{}
+}
+
+// loadConfig reads and validates a configuration file
+func loadConfig(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) { // An empty file is valid
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return config, nil
+}
+
+func (c *Config) validate() error {
+	for i := range c.Processes {
+		process := &c.Processes[i]
+		if process.Name == "" {
+			return fmt.Errorf("processes[%d]: name is required", i)
+		}
+		if process.Cmdline != "" && process.Pidfile != "" {
+			return fmt.Errorf("process %s: set either cmdline or pidfile, not both", process.Name)
+		}
+		if process.Cmdline != "" {
+			pattern, err := regexp.Compile(process.Cmdline)
+			if err != nil {
+				return fmt.Errorf("process %s: invalid cmdline pattern: %w", process.Name, err)
+			}
+			process.cmdline = pattern
+		}
+	}
+
+	for i := range c.Ports {
+		port := &c.Ports[i]
+		if port.Port < 1 || port.Port > 65535 {
+			return fmt.Errorf("ports[%d]: port must be 1-65535", i)
+		}
+		port.Protocol = strings.ToLower(port.Protocol)
+		if port.Protocol == "" {
+			port.Protocol = "tcp"
+		}
+		if port.Protocol != "tcp" && port.Protocol != "udp" {
+			return fmt.Errorf("port %d: protocol must be tcp or udp", port.Port)
+		}
+		port.Family = strings.ToLower(port.Family)
+		if port.Family != "" && port.Family != "ipv4" && port.Family != "ipv6" {
+			return fmt.Errorf("port %d: family must be ipv4 or ipv6", port.Port)
+		}
+	}
+
+	for name, filter := range map[string]FilterConfig{"mounts": c.Mounts, "interfaces": c.Interfaces} {
+		for _, pattern := range slices.Concat(filter.Include, filter.Exclude) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%s: invalid pattern %q", name, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// match reports whether a name passes the filter, and whether an include
+// pattern selected it explicitly
+func (f FilterConfig) match(name string) (allowed, explicit bool) {
+	for _, pattern := range f.Exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false, false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true, false
+	}
+	for _, pattern := range f.Include {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// watchConfig reloads the configuration file on SIGHUP. An invalid file is
+// logged and the previous configuration stays active.
+func watchConfig(filename string) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		config, err := loadConfig(filename)
+		if err != nil {
+			log.Printf("Reloading configuration failed, keeping the previous one: %v", err)
+			continue
+		}
+		currentConfig.Store(config)
+		log.Printf("Reloaded configuration from %s (%d processes, %d ports)", filename, len(config.Processes), len(config.Ports))
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// containerInfo is what the Docker API tells about a container
+type containerInfo struct {
+	name  string
+	image string
+}
+
+// dockerRetryInterval is how long to wait before asking an unreachable Docker
+// API again, or asking again about containers it didn't list
+const dockerRetryInterval = time.Minute
+
+// dockerNames caches container names from the Docker Engine API; it is
+// refreshed when a container it doesn't know appears
+var dockerNames = struct {
+	sync.Mutex
+	byID        map[string]containerInfo
+	notDocker   map[string]time.Time // When Docker last didn't list a container, e.g. one run by containerd or podman
+	client      *http.Client
+	failing     bool      // whether the last request failed, so errors are logged once
+	lastAttempt time.Time // when the last request was made
+}{byID: make(map[string]containerInfo), notDocker: make(map[string]time.Time)}
+
+// resolveContainerNames returns the names of the given containers known to
+// Docker. Containers run by other runtimes, or any container if Docker isn't
+// reachable, are missing from the result. Docker is asked again when a new
+// container appears, but at most every dockerRetryInterval about containers it
+// didn't list (one may be listed late, while it starts) or while it is
+// unreachable.
+func resolveContainerNames(containers map[string]cgroupStats) map[string]containerInfo {
+	dockerNames.Lock()
+	defer dockerNames.Unlock()
+
+	if *dockerSocket == "" {
+		return dockerNames.byID
+	}
+
+	// Forget containers that are gone, so their IDs don't pile up
+	for id := range dockerNames.notDocker {
+		if _, exists := containers[id]; !exists {
+			delete(dockerNames.notDocker, id)
+		}
+	}
+
+	unknown := false
+	for id := range containers {
+		_, known := dockerNames.byID[id]
+		listedAt, notDocker := dockerNames.notDocker[id]
+		if !known && (!notDocker || time.Since(listedAt) >= dockerRetryInterval) {
+			unknown = true
+			break
+		}
+	}
+	if !unknown || (dockerNames.failing && time.Since(dockerNames.lastAttempt) < dockerRetryInterval) {
+		return dockerNames.byID
+	}
+
+	dockerNames.lastAttempt = time.Now()
+	byID, err := listDockerContainers()
+	switch {
+	case err != nil && !dockerNames.failing:
+		log.Printf("Naming containers through the Docker API failed: %v", err)
+	case err == nil && dockerNames.failing:
+		log.Printf("Naming containers through the Docker API recovered")
+	}
+	dockerNames.failing = err != nil
+	if err != nil {
+		return dockerNames.byID
+	}
+
+	dockerNames.byID = byID
+	for id := range containers {
+		if _, known := byID[id]; !known {
+			dockerNames.notDocker[id] = dockerNames.lastAttempt
+		}
+	}
+	return dockerNames.byID
+}
+
+// listDockerContainers lists the running containers over the Docker socket
+func listDockerContainers() (map[string]containerInfo, error) {
+	if dockerNames.client == nil {
+		dockerNames.client = &http.Client{
+			Timeout: 2 * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", *dockerSocket)
+				},
+			},
+		}
+	}
+
+	// The host is ignored; requests go to the socket
+	resp, err := dockerNames.client.Get("http://docker/containers/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP status code: %d", resp.StatusCode)
+	}
+
+	var containers []struct {
+		ID    string   `json:"Id"`
+		Names []string `json:"Names"`
+		Image string   `json:"Image"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&containers); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+
+	byID := make(map[string]containerInfo, len(containers))
+	for _, container := range containers {
+		info := containerInfo{name: container.ID[:min(12, len(container.ID))], image: container.Image}
+		if len(container.Names) > 0 {
+			info.name = strings.TrimPrefix(container.Names[0], "/")
+		}
+		byID[container.ID] = info
+	}
+	return byID, nil
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Content types of the text exposition formats
+const (
+	prometheusContentType  = "text/plain; version=0.0.4; charset=utf-8"
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// metricPrefix is the namespace of every metric the agent exposes
+const metricPrefix = "monitoring_agent_"
+
+// agentVersion is reported by monitoring_agent_info
+const agentVersion = "1.0.0"
+
+// metricFamily is one metric with its metadata and samples
+type metricFamily struct {
+	name       string // without _total for counters
+	help       string
+	metricType string // gauge or counter
+	unit       string // bytes, seconds, ... (OpenMetrics only)
+	samples    []metricSample
+}
+
+type metricSample struct {
+	labels [][2]string
+	value  float64
+}
+
+func (f *metricFamily) add(value float64, labels ...string) {
+	sample := metricSample{value: value}
+	for i := 0; i+1 < len(labels); i += 2 {
+		sample.labels = append(sample.labels, [2]string{labels[i], labels[i+1]})
+	}
+	f.samples = append(f.samples, sample)
+}
+
+// metricsFormat picks the response format for a /metrics request. The format
+// query parameter (json, prometheus or openmetrics) takes precedence over the
+// Accept header; JSON stays the default so existing clients keep working.
+func metricsFormat(r *http.Request) string {
+	switch format := r.URL.Query().Get("format"); format {
+	case "json", "prometheus", "openmetrics":
+		return format
+	}
+
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "application/openmetrics-text"):
+		return "openmetrics"
+	case strings.Contains(accept, "text/plain"):
+		return "prometheus"
+	default:
+		return "json"
+	}
+}
+
+// writeExposition renders metrics in the Prometheus text format, or in the
+// OpenMetrics format if openMetrics is set
+func writeExposition(w io.Writer, metrics *MetricsResponse, openMetrics bool) {
+	for _, family := range buildMetricFamilies(metrics) {
+		if len(family.samples) == 0 {
+			continue
+		}
+
+		name := metricPrefix + family.name
+		sampleName := name
+		if family.metricType == "counter" {
+			if openMetrics {
+				sampleName = name + "_total"
+			} else {
+				name += "_total"
+				sampleName = name
+			}
+		}
+
+		fmt.Fprintf(w, "# HELP %s %s\n", name, escapeHelp(family.help))
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, family.metricType)
+		if openMetrics && family.unit != "" {
+			fmt.Fprintf(w, "# UNIT %s %s\n", name, family.unit)
+		}
+		for _, sample := range family.samples {
+			fmt.Fprintf(w, "%s%s %s\n", sampleName, formatLabels(sample.labels), formatValue(sample.value))
+		}
+	}
+
+	if openMetrics {
+		fmt.Fprint(w, "# EOF\n")
+	}
+}
+
+// buildMetricFamilies converts a metrics snapshot into metric families
+func buildMetricFamilies(metrics *MetricsResponse) []*metricFamily {
+	info := &metricFamily{name: "info", help: "Agent information; always 1.", metricType: "gauge"}
+	info.add(1, "hostname", metrics.Hostname, "version", agentVersion)
+
+	collected := &metricFamily{name: "last_collection_timestamp_seconds", help: "Unix time the metrics were collected.", metricType: "gauge", unit: "seconds"}
+	if timestamp, err := time.Parse(time.RFC3339, metrics.Timestamp); err == nil {
+		collected.add(float64(timestamp.Unix()))
+	}
+
+	// CPU
+	cpuUsage := &metricFamily{name: "cpu_usage_percent", help: "CPU usage since the previous collection.", metricType: "gauge", unit: "percent"}
+	cpuUsage.add(metrics.CPU.UsagePercent)
+	cpuCores := &metricFamily{name: "cpu_cores", help: "Number of logical CPU cores.", metricType: "gauge"}
+	cpuCores.add(float64(metrics.CPU.Cores))
+	cpuMode := &metricFamily{name: "cpu_mode_percent", help: "Share of CPU time spent in each mode since the previous collection.", metricType: "gauge", unit: "percent"}
+	addCPUModes(cpuMode, metrics.CPU.CPUTimes)
+	coreUsage := &metricFamily{name: "cpu_core_usage_percent", help: "Usage of each logical core since the previous collection.", metricType: "gauge", unit: "percent"}
+	coreMode := &metricFamily{name: "cpu_core_mode_percent", help: "Share of each logical core's time spent in each mode since the previous collection.", metricType: "gauge", unit: "percent"}
+	for _, core := range metrics.CPU.PerCore {
+		label := strconv.Itoa(core.Core)
+		coreUsage.add(core.UsagePercent, "core", label)
+		addCPUModes(coreMode, core.CPUTimes, "core", label)
+	}
+	load1 := &metricFamily{name: "load1", help: "1-minute load average.", metricType: "gauge"}
+	load5 := &metricFamily{name: "load5", help: "5-minute load average.", metricType: "gauge"}
+	load15 := &metricFamily{name: "load15", help: "15-minute load average.", metricType: "gauge"}
+	if load := metrics.CPU.LoadAverage; load != nil {
+		load1.add(load.Load1)
+		load5.add(load.Load5)
+		load15.add(load.Load15)
+	}
+
+	// Pressure stall information
+	pressure10 := &metricFamily{name: "pressure_avg10_percent", help: "Share of time tasks were stalled on a resource over the last 10 seconds.", metricType: "gauge", unit: "percent"}
+	pressure60 := &metricFamily{name: "pressure_avg60_percent", help: "Share of time tasks were stalled on a resource over the last 60 seconds.", metricType: "gauge", unit: "percent"}
+	pressure300 := &metricFamily{name: "pressure_avg300_percent", help: "Share of time tasks were stalled on a resource over the last 300 seconds.", metricType: "gauge", unit: "percent"}
+	pressureStalled := &metricFamily{name: "pressure_stalled_seconds", help: "Time tasks were stalled on a resource since boot.", metricType: "counter", unit: "seconds"}
+	if metrics.Pressure != nil {
+		for _, resource := range []struct {
+			name     string
+			pressure *Pressure
+		}{{"cpu", metrics.Pressure.CPU}, {"memory", metrics.Pressure.Memory}, {"io", metrics.Pressure.IO}} {
+			if resource.pressure == nil {
+				continue
+			}
+			kinds := []string{"some"}
+			averages := []*PressureAverages{&resource.pressure.Some}
+			if resource.pressure.Full != nil {
+				kinds = append(kinds, "full")
+				averages = append(averages, resource.pressure.Full)
+			}
+			for i, kind := range kinds {
+				pressure10.add(averages[i].Avg10, "resource", resource.name, "kind", kind)
+				pressure60.add(averages[i].Avg60, "resource", resource.name, "kind", kind)
+				pressure300.add(averages[i].Avg300, "resource", resource.name, "kind", kind)
+				pressureStalled.add(averages[i].TotalSeconds, "resource", resource.name, "kind", kind)
+			}
+		}
+	}
+
+	// Memory
+	memory := metrics.Memory
+	memoryTotal := &metricFamily{name: "memory_total_bytes", help: "Total memory.", metricType: "gauge", unit: "bytes"}
+	memoryUsed := &metricFamily{name: "memory_used_bytes", help: "Memory in use (total minus available).", metricType: "gauge", unit: "bytes"}
+	memoryAvailable := &metricFamily{name: "memory_available_bytes", help: "Memory available for new processes.", metricType: "gauge", unit: "bytes"}
+	memoryUsage := &metricFamily{name: "memory_usage_percent", help: "Memory usage.", metricType: "gauge", unit: "percent"}
+	swapTotal := &metricFamily{name: "swap_total_bytes", help: "Total swap space.", metricType: "gauge", unit: "bytes"}
+	swapUsed := &metricFamily{name: "swap_used_bytes", help: "Swap space in use.", metricType: "gauge", unit: "bytes"}
+	if memory.TotalBytes > 0 {
+		memoryTotal.add(float64(memory.TotalBytes))
+		memoryUsed.add(float64(memory.UsedBytes))
+		memoryAvailable.add(float64(memory.AvailableBytes))
+		memoryUsage.add(memory.UsagePercent)
+		swapTotal.add(float64(memory.SwapTotalBytes))
+		swapUsed.add(float64(memory.SwapUsedBytes))
+	}
+
+	// Disks
+	diskTotal := &metricFamily{name: "filesystem_size_bytes", help: "Filesystem size.", metricType: "gauge", unit: "bytes"}
+	diskUsed := &metricFamily{name: "filesystem_used_bytes", help: "Filesystem space in use.", metricType: "gauge", unit: "bytes"}
+	diskAvailable := &metricFamily{name: "filesystem_available_bytes", help: "Filesystem space available to unprivileged users.", metricType: "gauge", unit: "bytes"}
+	diskUsage := &metricFamily{name: "filesystem_usage_percent", help: "Filesystem usage.", metricType: "gauge", unit: "percent"}
+	diskReadOnly := &metricFamily{name: "filesystem_read_only", help: "Whether the filesystem is mounted read-only (1) or not (0).", metricType: "gauge"}
+	inodesTotal := &metricFamily{name: "filesystem_inodes", help: "Filesystem inodes.", metricType: "gauge"}
+	inodesFree := &metricFamily{name: "filesystem_inodes_free", help: "Filesystem inodes free.", metricType: "gauge"}
+	inodesUsage := &metricFamily{name: "filesystem_inodes_usage_percent", help: "Filesystem inode usage.", metricType: "gauge", unit: "percent"}
+	for _, disk := range metrics.Disk {
+		labels := []string{"mount", disk.Mount, "device", disk.Filesystem, "fstype", disk.FSType}
+		diskTotal.add(float64(disk.TotalBytes), labels...)
+		diskUsed.add(float64(disk.UsedBytes), labels...)
+		diskAvailable.add(float64(disk.AvailableBytes), labels...)
+		diskUsage.add(disk.UsagePercent, labels...)
+		diskReadOnly.add(boolValue(disk.ReadOnly), labels...)
+		if disk.InodesTotal > 0 {
+			inodesTotal.add(float64(disk.InodesTotal), labels...)
+			inodesFree.add(float64(disk.InodesFree), labels...)
+			inodesUsage.add(disk.InodesUsagePercent, labels...)
+		}
+	}
+
+	// Network
+	networkReceived := &metricFamily{name: "network_receive_bytes", help: "Bytes received per interface since boot.", metricType: "counter", unit: "bytes"}
+	networkTransmitted := &metricFamily{name: "network_transmit_bytes", help: "Bytes transmitted per interface since boot.", metricType: "counter", unit: "bytes"}
+	networkReceivedPackets := &metricFamily{name: "network_receive_packets", help: "Packets received per interface since boot.", metricType: "counter"}
+	networkTransmittedPackets := &metricFamily{name: "network_transmit_packets", help: "Packets transmitted per interface since boot.", metricType: "counter"}
+	networkReceiveErrors := &metricFamily{name: "network_receive_errors", help: "Receive errors per interface since boot.", metricType: "counter"}
+	networkTransmitErrors := &metricFamily{name: "network_transmit_errors", help: "Transmit errors per interface since boot.", metricType: "counter"}
+	networkReceiveDropped := &metricFamily{name: "network_receive_drop", help: "Received packets dropped per interface since boot.", metricType: "counter"}
+	networkTransmitDropped := &metricFamily{name: "network_transmit_drop", help: "Transmitted packets dropped per interface since boot.", metricType: "counter"}
+	for _, iface := range metrics.Network.Interfaces {
+		networkReceived.add(float64(iface.RxBytes), "interface", iface.Name)
+		networkTransmitted.add(float64(iface.TxBytes), "interface", iface.Name)
+		networkReceivedPackets.add(float64(iface.RxPackets), "interface", iface.Name)
+		networkTransmittedPackets.add(float64(iface.TxPackets), "interface", iface.Name)
+		networkReceiveErrors.add(float64(iface.RxErrors), "interface", iface.Name)
+		networkTransmitErrors.add(float64(iface.TxErrors), "interface", iface.Name)
+		networkReceiveDropped.add(float64(iface.RxDropped), "interface", iface.Name)
+		networkTransmitDropped.add(float64(iface.TxDropped), "interface", iface.Name)
+	}
+
+	// Software RAID
+	raidDegraded := &metricFamily{name: "raid_degraded", help: "Whether an md array is missing or has failed members (1) or not (0).", metricType: "gauge"}
+	raidDevices := &metricFamily{name: "raid_devices", help: "Members an md array should have.", metricType: "gauge"}
+	raidActive := &metricFamily{name: "raid_active_devices", help: "Members of an md array that are in sync.", metricType: "gauge"}
+	raidFailed := &metricFamily{name: "raid_failed_devices", help: "Members of an md array marked faulty.", metricType: "gauge"}
+	raidSync := &metricFamily{name: "raid_sync_percent", help: "Progress of an md array's recovery, reshape, resync or check.", metricType: "gauge", unit: "percent"}
+	for _, array := range metrics.RAID {
+		labels := []string{"device", array.Device, "level", array.Level}
+		raidDegraded.add(boolValue(array.Degraded), labels...)
+		raidDevices.add(float64(array.Devices), labels...)
+		raidActive.add(float64(array.ActiveDevices), labels...)
+		raidFailed.add(float64(array.FailedDevices), labels...)
+		if array.SyncAction != "" {
+			raidSync.add(array.SyncPercent, "device", array.Device, "level", array.Level, "action", array.SyncAction)
+		}
+	}
+
+	// Disk I/O
+	diskReads := &metricFamily{name: "disk_reads_completed", help: "Reads completed per block device since boot.", metricType: "counter"}
+	diskWrites := &metricFamily{name: "disk_writes_completed", help: "Writes completed per block device since boot.", metricType: "counter"}
+	diskReadBytes := &metricFamily{name: "disk_read_bytes", help: "Bytes read per block device since boot.", metricType: "counter", unit: "bytes"}
+	diskWrittenBytes := &metricFamily{name: "disk_written_bytes", help: "Bytes written per block device since boot.", metricType: "counter", unit: "bytes"}
+	diskIOTime := &metricFamily{name: "disk_io_time_seconds", help: "Time each block device was busy since boot.", metricType: "counter", unit: "seconds"}
+	for _, disk := range metrics.DiskIO {
+		diskReads.add(float64(disk.Reads), "device", disk.Device)
+		diskWrites.add(float64(disk.Writes), "device", disk.Device)
+		diskReadBytes.add(float64(disk.ReadBytes), "device", disk.Device)
+		diskWrittenBytes.add(float64(disk.WriteBytes), "device", disk.Device)
+		diskIOTime.add(float64(disk.IOTimeMillis)/1000, "device", disk.Device)
+	}
+
+	// Processes and ports
+	processRunning := &metricFamily{name: "process_running", help: "Whether a watched process is running.", metricType: "gauge"}
+	for _, service := range metrics.Services {
+		processRunning.add(boolValue(service.Status == "running"), "process", service.Name)
+	}
+	processCount := &metricFamily{name: "process_count", help: "Number of processes matching a watched process.", metricType: "gauge"}
+	processCPU := &metricFamily{name: "process_cpu_usage_percent", help: "CPU usage of a watched process since the previous collection, of one core.", metricType: "gauge", unit: "percent"}
+	processRSS := &metricFamily{name: "process_resident_memory_bytes", help: "Resident memory of a watched process.", metricType: "gauge", unit: "bytes"}
+	processThreads := &metricFamily{name: "process_threads", help: "Threads of a watched process.", metricType: "gauge"}
+	processFDs := &metricFamily{name: "process_open_fds", help: "Open file descriptors of a watched process.", metricType: "gauge"}
+	processRead := &metricFamily{name: "process_read_bytes", help: "Bytes a watched process read from storage.", metricType: "counter", unit: "bytes"}
+	processWritten := &metricFamily{name: "process_written_bytes", help: "Bytes a watched process wrote to storage.", metricType: "counter", unit: "bytes"}
+	processUptime := &metricFamily{name: "process_uptime_seconds", help: "Time since the oldest process of a watched process started.", metricType: "gauge", unit: "seconds"}
+	for _, service := range metrics.Services {
+		if service.Status != "running" {
+			continue
+		}
+		processCount.add(float64(service.ProcessCount), "process", service.Name)
+		processCPU.add(service.CPUPercent, "process", service.Name)
+		processRSS.add(float64(service.RSSBytes), "process", service.Name)
+		processThreads.add(float64(service.Threads), "process", service.Name)
+		processFDs.add(float64(service.OpenFDs), "process", service.Name)
+		processRead.add(float64(service.ReadBytes), "process", service.Name)
+		processWritten.add(float64(service.WriteBytes), "process", service.Name)
+		processUptime.add(float64(service.UptimeSeconds), "process", service.Name)
+	}
+	portListening := &metricFamily{name: "port_listening", help: "Whether a watched port has a listening socket.", metricType: "gauge"}
+	for _, port := range metrics.Ports {
+		labels := []string{"port", strconv.Itoa(port.Port), "protocol", port.Protocol}
+		if port.Family != "" {
+			labels = append(labels, "family", port.Family)
+		}
+		portListening.add(boolValue(port.Status == "listening"), labels...)
+	}
+
+	// Containers
+	containerCPU := &metricFamily{name: "container_cpu_usage_percent", help: "CPU usage of a container since the previous collection, of one core.", metricType: "gauge", unit: "percent"}
+	containerCPUSeconds := &metricFamily{name: "container_cpu_seconds", help: "CPU time used by a container since it started.", metricType: "counter", unit: "seconds"}
+	containerMemory := &metricFamily{name: "container_memory_usage_bytes", help: "Memory used by a container, excluding reclaimable page cache.", metricType: "gauge", unit: "bytes"}
+	containerLimit := &metricFamily{name: "container_memory_limit_bytes", help: "Memory limit of a container; absent if unlimited.", metricType: "gauge", unit: "bytes"}
+	containerOOMKills := &metricFamily{name: "container_oom_kills", help: "Processes in a container killed for exceeding its memory limit.", metricType: "counter"}
+	containerRead := &metricFamily{name: "container_read_bytes", help: "Bytes read from block devices by a container.", metricType: "counter", unit: "bytes"}
+	containerWritten := &metricFamily{name: "container_written_bytes", help: "Bytes written to block devices by a container.", metricType: "counter", unit: "bytes"}
+	for _, container := range metrics.Containers {
+		labels := []string{"container", container.Name, "id", container.ID, "image", container.Image}
+		containerCPU.add(container.CPUPercent, labels...)
+		containerCPUSeconds.add(container.CPUSeconds, labels...)
+		containerMemory.add(float64(container.MemoryBytes), labels...)
+		if container.MemoryLimitBytes > 0 {
+			containerLimit.add(float64(container.MemoryLimitBytes), labels...)
+		}
+		containerOOMKills.add(float64(container.OOMKills), labels...)
+		containerRead.add(float64(container.ReadBytes), labels...)
+		containerWritten.add(float64(container.WriteBytes), labels...)
+	}
+
+	return []*metricFamily{
+		info, collected,
+		cpuUsage, cpuCores, cpuMode, coreUsage, coreMode, load1, load5, load15,
+		pressure10, pressure60, pressure300, pressureStalled,
+		memoryTotal, memoryUsed, memoryAvailable, memoryUsage, swapTotal, swapUsed,
+		diskTotal, diskUsed, diskAvailable, diskUsage, diskReadOnly, inodesTotal, inodesFree, inodesUsage,
+		raidDegraded, raidDevices, raidActive, raidFailed, raidSync,
+		networkReceived, networkTransmitted, networkReceivedPackets, networkTransmittedPackets,
+		networkReceiveErrors, networkTransmitErrors, networkReceiveDropped, networkTransmitDropped,
+		diskReads, diskWrites, diskReadBytes, diskWrittenBytes, diskIOTime,
+		processRunning, processCount, processCPU, processRSS, processThreads, processFDs, processRead, processWritten, processUptime,
+		portListening,
+		containerCPU, containerCPUSeconds, containerMemory, containerLimit, containerOOMKills, containerRead, containerWritten,
+	}
+}
+
+// addCPUModes adds a sample per CPU mode, with the given labels and a mode label
+func addCPUModes(family *metricFamily, times CPUTimes, labels ...string) {
+	for _, mode := range []struct {
+		name  string
+		value float64
+	}{
+		{"user", times.User}, {"nice", times.Nice}, {"system", times.System}, {"idle", times.Idle},
+		{"iowait", times.IOWait}, {"irq", times.IRQ}, {"softirq", times.SoftIRQ}, {"steal", times.Steal},
+	} {
+		family.add(mode.value, append(labels, "mode", mode.name)...)
+	}
+}
+
+// formatLabels renders a label set like {a="1",b="2"}, sorted by name
+func formatLabels(labels [][2]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	sorted := make([][2]string, len(labels))
+	copy(sorted, labels)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i][0] < sorted[j][0] })
+
+	parts := make([]string, len(sorted))
+	for i, label := range sorted {
+		parts[i] = label[0] + `="` + escapeLabelValue(label[1]) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
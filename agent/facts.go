@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// HostFacts describes the machine the agent runs on. It is sent to the
+// monitoring server when registering in push mode.
+type HostFacts struct {
+	Hostname         string   `json:"hostname"`
+	OS               string   `json:"os"`       // e.g. "Ubuntu 22.04.4 LTS"
+	Platform         string   `json:"platform"` // linux, darwin, ...
+	Kernel           string   `json:"kernel,omitempty"`
+	Arch             string   `json:"arch"`
+	CPUCount         int      `json:"cpu_count"`
+	MemoryTotalBytes uint64   `json:"memory_total_bytes"`
+	AgentVersion     string   `json:"agent_version"`
+	IPAddresses      []string `json:"ip_addresses"`
+}
+
+// CollectHostFacts gathers the host's static facts
+func CollectHostFacts(hostname string) HostFacts {
+	facts := HostFacts{
+		Hostname:     hostname,
+		OS:           readOSName(),
+		Platform:     runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		CPUCount:     runtime.NumCPU(),
+		AgentVersion: agentVersion,
+		IPAddresses:  collectIPAddresses(),
+	}
+
+	// Read the kernel release (Linux)
+	if data, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		facts.Kernel = strings.TrimSpace(string(data))
+	}
+
+	// Read the total memory (Linux)
+	if data, err := os.ReadFile("/proc/meminfo"); err == nil {
+		facts.MemoryTotalBytes = parseMemInfo(string(data))["MemTotal"] * 1024
+	}
+
+	return facts
+}
+
+// readOSName returns the distribution name from /etc/os-release, or the platform
+func readOSName() string {
+	data, err := os.ReadFile("/etc/os-release")
+	if err != nil {
+		return runtime.GOOS
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "PRETTY_NAME="); ok {
+			return strings.Trim(value, `"'`)
+		}
+	}
+	return runtime.GOOS
+}
+
+// collectIPAddresses returns the addresses of the interfaces that are up,
+// without loopback and IPv6 link-local addresses
+func collectIPAddresses() []string {
+	addresses := []string{}
+
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return addresses
+	}
+
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			addresses = append(addresses, ipNet.IP.String())
+		}
+	}
+
+	return addresses
+}
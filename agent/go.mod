@@ -1,3 +1,5 @@
 module monitoring-agent
 
 go 1.25.1
+
+require gopkg.in/yaml.v3 v3.0.1
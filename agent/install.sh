@@ -6,6 +6,7 @@ set -e
 # Configuration
 AGENT_PORT="${AGENT_PORT:-9100}"
 AUTH_TOKEN="${AUTH_TOKEN:-}"
+CONFIG_FILE="${CONFIG_FILE:-}"
 INSTALL_DIR="/usr/local/bin"
 SERVICE_FILE="/etc/systemd/system/monitoring-agent.service"
 
@@ -78,7 +79,8 @@ After=network.target
 [Service]
 Type=simple
 User=root
-ExecStart=$INSTALL_DIR/monitoring-agent -port $AGENT_PORT ${AUTH_TOKEN:+-token $AUTH_TOKEN}
+ExecStart=$INSTALL_DIR/monitoring-agent -port $AGENT_PORT ${AUTH_TOKEN:+-token $AUTH_TOKEN} ${CONFIG_FILE:+-config $CONFIG_FILE}
+ExecReload=/bin/kill -HUP \$MAINPID
 Restart=always
 RestartSec=5
 StandardOutput=journal
@@ -11,22 +11,34 @@ import (
 )
 
 var (
-	port      = flag.Int("port", 9100, "Port to listen on")
-	authToken = flag.String("token", "", "Authentication token (optional)")
-	interval  = flag.Int("interval", 10, "Metrics collection interval in seconds")
+	port         = flag.Int("port", 9100, "Port to listen on")
+	authToken    = flag.String("token", "", "Authentication token (optional)")
+	interval     = flag.Int("interval", 10, "Metrics collection interval in seconds")
+	pushURL      = flag.String("push-url", "", "Monitoring server URL to push metrics to (optional)")
+	pushToken    = flag.String("push-token", "", "Agent token for push mode, from the server's Hosts section")
+	topCount     = flag.Int("top", 5, "Number of processes in the top CPU and memory lists (0 disables them)")
+	configFile   = flag.String("config", "", "YAML file with processes, ports, mounts and interfaces to watch (optional, reloaded on SIGHUP)")
+	cgroupRoot   = flag.String("cgroup-root", "/sys/fs/cgroup", "cgroup filesystem to read container metrics from (empty disables them)")
+	dockerSocket = flag.String("docker-socket", "/var/run/docker.sock", "Docker Engine API socket, to name containers (empty disables it)")
 )
 
 // MetricsResponse represents the JSON response structure
 type MetricsResponse struct {
-	Hostname  string           `json:"hostname"`
-	Timestamp string           `json:"timestamp"`
-	Status    string           `json:"status"`
-	CPU       CPUMetrics       `json:"cpu"`
-	Memory    MemoryMetrics    `json:"memory"`
-	Disk      []DiskMetrics    `json:"disk"`
-	Network   NetworkMetrics   `json:"network"`
-	Services  []ServiceMetrics `json:"services,omitempty"`
-	Ports     []PortMetrics    `json:"ports,omitempty"`
+	Hostname   string             `json:"hostname"`
+	Timestamp  string             `json:"timestamp"`
+	Status     string             `json:"status"`
+	CPU        CPUMetrics         `json:"cpu"`
+	Memory     MemoryMetrics      `json:"memory"`
+	Disk       []DiskMetrics      `json:"disk"`
+	DiskIO     []DiskIOMetrics    `json:"disk_io,omitempty"`
+	RAID       []RAIDMetrics      `json:"raid,omitempty"`
+	Network    NetworkMetrics     `json:"network"`
+	Pressure   *PressureMetrics   `json:"pressure,omitempty"`
+	Services   []ServiceMetrics   `json:"services,omitempty"`
+	Ports      []PortMetrics      `json:"ports,omitempty"`
+	TopCPU     []ProcessMetrics   `json:"top_cpu,omitempty"`    // Processes using the most CPU
+	TopMemory  []ProcessMetrics   `json:"top_memory,omitempty"` // Processes using the most memory
+	Containers []ContainerMetrics `json:"containers,omitempty"`
 }
 
 // Global metrics cache
@@ -36,20 +48,47 @@ var lastUpdate time.Time
 func main() {
 	flag.Parse()
 
+	if *pushURL != "" && *pushToken == "" {
+		log.Fatal("-push-url requires -push-token")
+	}
+	if *port == 0 && *pushURL == "" {
+		log.Fatal("-port 0 disables the HTTP server, so -push-url is required")
+	}
+
 	// Get hostname
 	hostname, err := os.Hostname()
 	if err != nil {
 		hostname = "unknown"
 	}
 
-	log.Printf("Starting Monitoring Agent on port %d", *port)
+	log.Printf("Starting Monitoring Agent")
 	log.Printf("Hostname: %s", hostname)
 	if *authToken != "" {
 		log.Printf("Authentication enabled")
 	}
+	if *configFile != "" {
+		config, err := loadConfig(*configFile)
+		if err != nil {
+			log.Fatalf("Loading configuration failed: %v", err)
+		}
+		currentConfig.Store(config)
+		log.Printf("Loaded configuration from %s (%d processes, %d ports)", *configFile, len(config.Processes), len(config.Ports))
+		go watchConfig(*configFile)
+	}
+
+	var pusher *pushClient
+	if *pushURL != "" {
+		pusher = newPushClient(*pushURL, *pushToken)
+		log.Printf("Pushing metrics to %s every %ds", pusher.serverURL, *interval)
+	}
 
 	// Start metrics collector
-	go metricsCollector(hostname)
+	go metricsCollector(hostname, pusher)
+
+	if *port == 0 {
+		// Push-only mode
+		select {}
+	}
 
 	// HTTP handlers
 	http.HandleFunc("/metrics", metricsHandler)
@@ -64,26 +103,37 @@ func main() {
 	}
 }
 
-func metricsCollector(hostname string) {
+func metricsCollector(hostname string, pusher *pushClient) {
 	for {
 		metrics := collectAllMetrics(hostname)
 		cachedMetrics = &metrics
 		lastUpdate = time.Now()
+		if pusher != nil {
+			pusher.push(&metrics)
+		}
 		time.Sleep(time.Duration(*interval) * time.Second)
 	}
 }
 
 func collectAllMetrics(hostname string) MetricsResponse {
+	processes := scanProcesses()
+
 	return MetricsResponse{
-		Hostname:  hostname,
-		Timestamp: time.Now().Format(time.RFC3339),
-		Status:    "healthy",
-		CPU:       CollectCPUMetrics(),
-		Memory:    CollectMemoryMetrics(),
-		Disk:      CollectDiskMetrics(),
-		Network:   CollectNetworkMetrics(),
-		Services:  CollectServiceMetrics(),
-		Ports:     CollectPortMetrics(),
+		Hostname:   hostname,
+		Timestamp:  time.Now().Format(time.RFC3339),
+		Status:     "healthy",
+		CPU:        CollectCPUMetrics(),
+		Memory:     CollectMemoryMetrics(),
+		Disk:       CollectDiskMetrics(),
+		DiskIO:     CollectDiskIOMetrics(),
+		RAID:       CollectRAIDMetrics(),
+		Network:    CollectNetworkMetrics(),
+		Pressure:   CollectPressureMetrics(),
+		Services:   CollectServiceMetrics(processes),
+		Ports:      CollectPortMetrics(),
+		TopCPU:     topProcesses(processes, *topCount, false),
+		TopMemory:  topProcesses(processes, *topCount, true),
+		Containers: CollectContainerMetrics(),
 	}
 }
 
@@ -97,17 +147,26 @@ func metricsHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-
-	if cachedMetrics == nil {
+	metrics := cachedMetrics
+	if metrics == nil {
 		// First request before cache is ready
 		hostname, _ := os.Hostname()
-		metrics := collectAllMetrics(hostname)
-		json.NewEncoder(w).Encode(metrics)
-		return
+		collected := collectAllMetrics(hostname)
+		metrics = &collected
 	}
 
-	json.NewEncoder(w).Encode(cachedMetrics)
+	// Prometheus scrapes ask for the text formats in their Accept header
+	switch metricsFormat(r) {
+	case "prometheus":
+		w.Header().Set("Content-Type", prometheusContentType)
+		writeExposition(w, metrics, false)
+	case "openmetrics":
+		w.Header().Set("Content-Type", openMetricsContentType)
+		writeExposition(w, metrics, true)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(metrics)
+	}
 }
 
 func healthHandler(w http.ResponseWriter, r *http.Request) {
@@ -144,6 +203,7 @@ func rootHandler(w http.ResponseWriter, r *http.Request) {
     <h2>Endpoints</h2>
     <ul>
         <li><a href="/metrics">/metrics</a> - Get all system metrics (JSON)</li>
+        <li><a href="/metrics?format=prometheus">/metrics?format=prometheus</a> - The same metrics in Prometheus text format</li>
         <li><a href="/health">/health</a> - Health check endpoint</li>
     </ul>
     <h2>Usage</h2>
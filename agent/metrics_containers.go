@@ -0,0 +1,261 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContainerMetrics is the resource usage of one container, from its cgroup
+type ContainerMetrics struct {
+	ID    string `json:"id"`   // Short (12 character) container ID
+	Name  string `json:"name"` // From the Docker API, or the short ID
+	Image string `json:"image,omitempty"`
+
+	CPUPercent       float64 `json:"cpu_percent"`        // Of one core, since the previous collection
+	CPUSeconds       float64 `json:"cpu_seconds"`        // Since the container started
+	MemoryBytes      uint64  `json:"memory_bytes"`       // Excluding page cache the kernel can reclaim
+	MemoryLimitBytes uint64  `json:"memory_limit_bytes"` // 0 if unlimited
+	MemoryPercent    float64 `json:"memory_percent"`     // Of the limit
+	OOMKills         uint64  `json:"oom_kills"`          // Processes killed for exceeding the limit
+	ReadBytes        uint64  `json:"read_bytes"`
+	WriteBytes       uint64  `json:"write_bytes"`
+	ReadRate         float64 `json:"read_bytes_per_sec"`
+	WriteRate        float64 `json:"write_bytes_per_sec"`
+}
+
+// cgroupStats holds the counters read from one container's cgroup
+type cgroupStats struct {
+	cpuNanos    uint64
+	memoryBytes uint64
+	limitBytes  uint64
+	oomKills    uint64
+	readBytes   uint64
+	writeBytes  uint64
+}
+
+// containerIDPattern matches the cgroup directory of a container, named by the
+// Docker (cgroupfs or systemd driver), containerd, CRI-O or Podman conventions,
+// e.g. "<id>", "docker-<id>.scope" or "cri-containerd-<id>.scope"
+var containerIDPattern = regexp.MustCompile(`^(?:[a-z0-9]+-)*([0-9a-f]{64})(?:\.scope)?$`)
+
+// unlimitedMemory is the smallest limit cgroup v1 reports for "no limit"
+// (the largest page counter, rounded to the page size)
+const unlimitedMemory = 1 << 62
+
+// maxCgroupDepth bounds the search for container cgroups
+const maxCgroupDepth = 8
+
+// previousContainers keeps each container's counters from the previous
+// collection, so CPU usage and I/O rates can be computed
+var previousContainers = struct {
+	sync.Mutex
+	stats       map[string]cgroupStats
+	collectedAt time.Time
+}{stats: make(map[string]cgroupStats)}
+
+// CollectContainerMetrics reads the cgroups of running containers under
+// -cgroup-root (Linux), with cgroup v2 or v1, and names them through the
+// Docker API if it is available. It returns nil if there are none.
+func CollectContainerMetrics() []ContainerMetrics {
+	if *cgroupRoot == "" {
+		return nil
+	}
+
+	var found map[string]cgroupStats
+	if _, err := os.Stat(filepath.Join(*cgroupRoot, "cgroup.controllers")); err == nil {
+		found = findContainerCgroups(*cgroupRoot, readCgroupV2)
+	} else {
+		// The memory hierarchy decides which cgroups are containers; the
+		// other controllers use the same relative paths
+		found = findContainerCgroups(filepath.Join(*cgroupRoot, "memory"), func(dir string) cgroupStats {
+			relative, _ := filepath.Rel(filepath.Join(*cgroupRoot, "memory"), dir)
+			return readCgroupV1(*cgroupRoot, relative)
+		})
+	}
+	if len(found) == 0 {
+		return nil
+	}
+
+	names := resolveContainerNames(found)
+
+	previousContainers.Lock()
+	defer previousContainers.Unlock()
+	now := time.Now()
+	elapsed := now.Sub(previousContainers.collectedAt).Seconds()
+
+	containers := make([]ContainerMetrics, 0, len(found))
+	for id, stats := range found {
+		container := ContainerMetrics{
+			ID:               id[:12],
+			Name:             id[:12],
+			CPUSeconds:       roundFloat(float64(stats.cpuNanos)/1e9, 2),
+			MemoryBytes:      stats.memoryBytes,
+			MemoryLimitBytes: stats.limitBytes,
+			OOMKills:         stats.oomKills,
+			ReadBytes:        stats.readBytes,
+			WriteBytes:       stats.writeBytes,
+		}
+		if info, ok := names[id]; ok {
+			container.Name = info.name
+			container.Image = info.image
+		}
+		if stats.limitBytes > 0 {
+			container.MemoryPercent = roundFloat(float64(stats.memoryBytes)/float64(stats.limitBytes)*100, 2)
+		}
+
+		if previous, ok := previousContainers.stats[id]; ok && elapsed > 0 {
+			container.CPUPercent = roundFloat(perSecond(stats.cpuNanos, previous.cpuNanos, elapsed)/1e9*100, 2)
+			container.ReadRate = perSecond(stats.readBytes, previous.readBytes, elapsed)
+			container.WriteRate = perSecond(stats.writeBytes, previous.writeBytes, elapsed)
+		}
+
+		containers = append(containers, container)
+	}
+
+	previousContainers.stats = found
+	previousContainers.collectedAt = now
+
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Name < containers[j].Name
+	})
+	return containers
+}
+
+// findContainerCgroups searches a cgroup hierarchy for container cgroups and
+// reads each with read. Cgroups nested inside a container are counted in it.
+func findContainerCgroups(base string, read func(dir string) cgroupStats) map[string]cgroupStats {
+	found := make(map[string]cgroupStats)
+	filepath.WalkDir(base, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		if strings.Count(strings.TrimPrefix(path, base), string(filepath.Separator)) > maxCgroupDepth {
+			return filepath.SkipDir
+		}
+
+		// Podman's conmon monitors run next to the containers they watch
+		match := containerIDPattern.FindStringSubmatch(entry.Name())
+		if match == nil || strings.Contains(entry.Name(), "conmon") {
+			return nil
+		}
+		found[match[1]] = read(path)
+		return filepath.SkipDir
+	})
+	return found
+}
+
+// readCgroupV2 reads a container's counters from a cgroup v2 directory
+func readCgroupV2(dir string) cgroupStats {
+	var stats cgroupStats
+
+	cpu := readKeyValues(filepath.Join(dir, "cpu.stat"))
+	stats.cpuNanos = cpu["usage_usec"] * 1000
+
+	stats.memoryBytes = readUint(filepath.Join(dir, "memory.current"))
+	if inactive := readKeyValues(filepath.Join(dir, "memory.stat"))["inactive_file"]; inactive < stats.memoryBytes {
+		stats.memoryBytes -= inactive
+	}
+	stats.limitBytes = readUint(filepath.Join(dir, "memory.max")) // "max" reads as 0
+	stats.oomKills = readKeyValues(filepath.Join(dir, "memory.events"))["oom_kill"]
+
+	// io.stat has a line per device, like "8:0 rbytes=1024 wbytes=0 rios=1 ..."
+	if data, err := os.ReadFile(filepath.Join(dir, "io.stat")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			for _, field := range strings.Fields(line) {
+				key, value, _ := strings.Cut(field, "=")
+				number, _ := strconv.ParseUint(value, 10, 64)
+				switch key {
+				case "rbytes":
+					stats.readBytes += number
+				case "wbytes":
+					stats.writeBytes += number
+				}
+			}
+		}
+	}
+	return stats
+}
+
+// readCgroupV1 reads a container's counters from the cpuacct, memory and
+// blkio hierarchies of cgroup v1
+func readCgroupV1(root, relative string) cgroupStats {
+	var stats cgroupStats
+
+	stats.cpuNanos = readUint(filepath.Join(root, "cpuacct", relative, "cpuacct.usage"))
+	if stats.cpuNanos == 0 {
+		stats.cpuNanos = readUint(filepath.Join(root, "cpu,cpuacct", relative, "cpuacct.usage"))
+	}
+
+	memory := filepath.Join(root, "memory", relative)
+	stats.memoryBytes = readUint(filepath.Join(memory, "memory.usage_in_bytes"))
+	if inactive := readKeyValues(filepath.Join(memory, "memory.stat"))["total_inactive_file"]; inactive < stats.memoryBytes {
+		stats.memoryBytes -= inactive
+	}
+	if limit := readUint(filepath.Join(memory, "memory.limit_in_bytes")); limit < unlimitedMemory {
+		stats.limitBytes = limit
+	}
+	stats.oomKills = readKeyValues(filepath.Join(memory, "memory.oom_control"))["oom_kill"] // Linux 4.13+
+
+	// Lines like "8:0 Read 1024", plus a "Total" line
+	blkio := filepath.Join(root, "blkio", relative)
+	for _, name := range []string{"blkio.throttle.io_service_bytes", "blkio.io_service_bytes_recursive"} {
+		data, err := os.ReadFile(filepath.Join(blkio, name))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 3 {
+				continue
+			}
+			number, _ := strconv.ParseUint(fields[2], 10, 64)
+			switch fields[1] {
+			case "Read":
+				stats.readBytes += number
+			case "Write":
+				stats.writeBytes += number
+			}
+		}
+		if stats.readBytes > 0 || stats.writeBytes > 0 {
+			break
+		}
+	}
+	return stats
+}
+
+// readUint reads a file holding a single number; it returns 0 if the file is
+// missing or holds something else (e.g. "max")
+func readUint(filename string) uint64 {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return 0
+	}
+	value, _ := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	return value
+}
+
+// readKeyValues reads a file of "key value" lines, like cpu.stat or memory.stat
+func readKeyValues(filename string) map[string]uint64 {
+	values := make(map[string]uint64)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return values
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if value, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			values[fields[0]] = value
+		}
+	}
+	return values
+}
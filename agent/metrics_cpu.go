@@ -3,122 +3,200 @@ package main
 import (
 	"os"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type CPUMetrics struct {
-	UsagePercent float64 `json:"usage_percent"`
+	UsagePercent float64 `json:"usage_percent"` // Time not idle or waiting for I/O
 	Cores        int     `json:"cores"`
-	LoadAverage  string  `json:"load_average,omitempty"`
+	CPUTimes
+	LoadAverage *LoadAverage  `json:"load_average,omitempty"`
+	PerCore     []CoreMetrics `json:"per_core,omitempty"`
+}
+
+// CPUTimes splits CPU time since the previous collection by what it was spent
+// on, in percent. Steal is time a virtual machine was ready to run but the
+// hypervisor ran something else; high steal means a noisy neighbour rather
+// than load on this host.
+type CPUTimes struct {
+	User    float64 `json:"user_percent"`
+	Nice    float64 `json:"nice_percent"`
+	System  float64 `json:"system_percent"`
+	Idle    float64 `json:"idle_percent"`
+	IOWait  float64 `json:"iowait_percent"`
+	IRQ     float64 `json:"irq_percent"`
+	SoftIRQ float64 `json:"softirq_percent"`
+	Steal   float64 `json:"steal_percent"`
+}
+
+// CoreMetrics is the usage of one logical core
+type CoreMetrics struct {
+	Core         int     `json:"core"`
+	UsagePercent float64 `json:"usage_percent"`
+	CPUTimes
 }
 
-var lastCPUStats cpuStats
+// LoadAverage is the number of runnable and uninterruptible tasks, averaged
+// over 1, 5 and 15 minutes
+type LoadAverage struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+}
 
-type cpuStats struct {
-	user   uint64
-	system uint64
-	idle   uint64
-	total  uint64
+// cpuTicks holds the /proc/stat counters of one CPU line, in clock ticks
+type cpuTicks struct {
+	user, nice, system, idle, iowait, irq, softirq, steal uint64
 }
 
+// total excludes guest time, which /proc/stat already counts in user and nice
+func (t cpuTicks) total() uint64 {
+	return t.user + t.nice + t.system + t.idle + t.iowait + t.irq + t.softirq + t.steal
+}
+
+// previousCPU keeps the counters of every CPU line from the previous
+// collection; "cpu" is the aggregate of all cores
+var previousCPU = struct {
+	sync.Mutex
+	ticks map[string]cpuTicks
+}{}
+
 func CollectCPUMetrics() CPUMetrics {
 	metrics := CPUMetrics{
-		Cores:        runtime.NumCPU(),
-		UsagePercent: getCPUUsage(),
+		Cores:       runtime.NumCPU(),
+		LoadAverage: getLoadAverage(),
 	}
 
-	// Try to get load average (Linux/Unix only)
-	if loadavg := getLoadAverage(); loadavg != "" {
-		metrics.LoadAverage = loadavg
+	current := readCPUStats()
+	if current == nil {
+		return metrics
 	}
 
-	return metrics
-}
+	previousCPU.Lock()
+	defer previousCPU.Unlock()
 
-func getCPUUsage() float64 {
-	stats := readCPUStats()
-	if stats == nil {
-		return 0.0
-	}
-
-	if lastCPUStats.total == 0 {
-		lastCPUStats = *stats
+	// Without a previous collection, measure over a short interval instead
+	if previousCPU.ticks == nil {
 		time.Sleep(100 * time.Millisecond)
-		stats = readCPUStats()
-		if stats == nil {
-			return 0.0
+		previousCPU.ticks, current = current, readCPUStats()
+		if current == nil {
+			return metrics
 		}
 	}
 
-	totalDelta := stats.total - lastCPUStats.total
-	idleDelta := stats.idle - lastCPUStats.idle
-
-	lastCPUStats = *stats
+	if ticks, ok := current["cpu"]; ok {
+		metrics.UsagePercent, metrics.CPUTimes = cpuUsage(ticks, previousCPU.ticks["cpu"])
+	}
 
-	if totalDelta == 0 {
-		return 0.0
+	for name, ticks := range current {
+		core, err := strconv.Atoi(strings.TrimPrefix(name, "cpu"))
+		if err != nil {
+			continue // The aggregate line
+		}
+		usage, times := cpuUsage(ticks, previousCPU.ticks[name])
+		metrics.PerCore = append(metrics.PerCore, CoreMetrics{Core: core, UsagePercent: usage, CPUTimes: times})
 	}
+	sort.Slice(metrics.PerCore, func(i, j int) bool {
+		return metrics.PerCore[i].Core < metrics.PerCore[j].Core
+	})
 
-	usage := 100.0 * (float64(totalDelta-idleDelta) / float64(totalDelta))
-	return roundFloat(usage, 2)
+	previousCPU.ticks = current
+	return metrics
 }
 
-func readCPUStats() *cpuStats {
-	// Read /proc/stat (Linux)
-	data, err := os.ReadFile("/proc/stat")
-	if err != nil {
-		// Fallback for non-Linux systems
-		return &cpuStats{total: 1, idle: 0}
+// cpuUsage returns the busy percentage and the breakdown of the time between
+// two samples of a CPU line. A core that went offline and came back may have
+// reset counters; it is reported idle until the next collection.
+func cpuUsage(current, previous cpuTicks) (float64, CPUTimes) {
+	if current.total() <= previous.total() {
+		return 0, CPUTimes{}
 	}
 
-	lines := strings.Split(string(data), "\n")
-	if len(lines) == 0 {
-		return nil
+	total := float64(current.total() - previous.total())
+	percent := func(now, before uint64) float64 {
+		if now < before {
+			return 0
+		}
+		return roundFloat(float64(now-before)/total*100, 2)
 	}
 
-	// First line contains aggregate CPU stats
-	fields := strings.Fields(lines[0])
-	if len(fields) < 5 || fields[0] != "cpu" {
+	times := CPUTimes{
+		User:    percent(current.user, previous.user),
+		Nice:    percent(current.nice, previous.nice),
+		System:  percent(current.system, previous.system),
+		Idle:    percent(current.idle, previous.idle),
+		IOWait:  percent(current.iowait, previous.iowait),
+		IRQ:     percent(current.irq, previous.irq),
+		SoftIRQ: percent(current.softirq, previous.softirq),
+		Steal:   percent(current.steal, previous.steal),
+	}
+	usage := roundFloat(max(100-times.Idle-times.IOWait, 0), 2)
+	return usage, times
+}
+
+// readCPUStats reads the counters of the aggregate and per-core CPU lines of
+// /proc/stat (Linux)
+func readCPUStats() map[string]cpuTicks {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
 		return nil
 	}
 
-	user, _ := strconv.ParseUint(fields[1], 10, 64)
-	nice, _ := strconv.ParseUint(fields[2], 10, 64)
-	system, _ := strconv.ParseUint(fields[3], 10, 64)
-	idle, _ := strconv.ParseUint(fields[4], 10, 64)
-	iowait, _ := strconv.ParseUint(fields[5], 10, 64)
+	stats := make(map[string]cpuTicks)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
 
-	total := user + nice + system + idle + iowait
-	if len(fields) > 6 {
-		irq, _ := strconv.ParseUint(fields[6], 10, 64)
-		total += irq
-	}
-	if len(fields) > 7 {
-		softirq, _ := strconv.ParseUint(fields[7], 10, 64)
-		total += softirq
+		// Older kernels have fewer columns; missing ones count as zero
+		counter := func(index int) uint64 {
+			if index >= len(fields) {
+				return 0
+			}
+			value, _ := strconv.ParseUint(fields[index], 10, 64)
+			return value
+		}
+		stats[fields[0]] = cpuTicks{
+			user:    counter(1),
+			nice:    counter(2),
+			system:  counter(3),
+			idle:    counter(4),
+			iowait:  counter(5),
+			irq:     counter(6),
+			softirq: counter(7),
+			steal:   counter(8),
+		}
 	}
-
-	return &cpuStats{
-		user:   user,
-		system: system,
-		idle:   idle,
-		total:  total,
+	if len(stats) == 0 {
+		return nil
 	}
+	return stats
 }
 
-func getLoadAverage() string {
+func getLoadAverage() *LoadAverage {
 	// Read /proc/loadavg (Linux)
 	data, err := os.ReadFile("/proc/loadavg")
 	if err != nil {
-		return ""
+		return nil
 	}
 
 	fields := strings.Fields(string(data))
-	if len(fields) >= 3 {
-		return fields[0] + " " + fields[1] + " " + fields[2]
+	if len(fields) < 3 {
+		return nil
 	}
 
-	return ""
+	var values [3]float64
+	for i := range values {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil
+		}
+		values[i] = value
+	}
+	return &LoadAverage{Load1: values[0], Load5: values[1], Load15: values[2]}
 }
@@ -6,17 +6,36 @@ import (
 	"syscall"
 )
 
+// stReadOnly is the read-only flag in Statfs_t.Flags (ST_RDONLY on Linux,
+// MNT_RDONLY on macOS)
+const stReadOnly = 0x1
+
 type DiskMetrics struct {
 	Mount        string  `json:"mount"`
 	TotalGB      float64 `json:"total_gb"`
 	UsedGB       float64 `json:"used_gb"`
 	AvailableGB  float64 `json:"available_gb"`
 	UsagePercent float64 `json:"usage_percent"`
-	Filesystem   string  `json:"filesystem,omitempty"`
+	Filesystem   string  `json:"filesystem,omitempty"` // Device
+	FSType       string  `json:"fs_type,omitempty"`    // ext4, xfs, ...
+	ReadOnly     bool    `json:"read_only"`            // Set when e.g. errors made the kernel remount it read-only
+
+	// Inodes; a filesystem can run out of them while it still has free space.
+	// Filesystems that allocate inodes dynamically (btrfs, vfat) report none.
+	InodesTotal        uint64  `json:"inodes_total"`
+	InodesUsed         uint64  `json:"inodes_used"`
+	InodesFree         uint64  `json:"inodes_free"`
+	InodesUsagePercent float64 `json:"inodes_usage_percent"`
+
+	// Exact sizes in bytes, for the Prometheus output
+	TotalBytes     uint64 `json:"total_bytes"`
+	UsedBytes      uint64 `json:"used_bytes"`
+	AvailableBytes uint64 `json:"available_bytes"`
 }
 
 func CollectDiskMetrics() []DiskMetrics {
 	var disks []DiskMetrics
+	filter := activeConfig().Mounts
 
 	// Read /proc/mounts to get mounted filesystems (Linux)
 	data, err := os.ReadFile("/proc/mounts")
@@ -41,8 +60,13 @@ func CollectDiskMetrics() []DiskMetrics {
 		mountPoint := fields[1]
 		fsType := fields[2]
 
-		// Skip non-disk filesystems
-		if strings.HasPrefix(device, "/dev/loop") ||
+		allowed, explicit := filter.match(mountPoint)
+		if !allowed {
+			continue
+		}
+
+		// Skip non-disk filesystems, unless included explicitly
+		if !explicit && (strings.HasPrefix(device, "/dev/loop") ||
 			strings.HasPrefix(device, "tmpfs") ||
 			strings.HasPrefix(device, "devtmpfs") ||
 			strings.HasPrefix(device, "sysfs") ||
@@ -50,7 +74,7 @@ func CollectDiskMetrics() []DiskMetrics {
 			strings.HasPrefix(device, "cgroup") ||
 			strings.HasPrefix(device, "securityfs") ||
 			fsType == "squashfs" ||
-			fsType == "overlay" {
+			fsType == "overlay") {
 			continue
 		}
 
@@ -63,12 +87,13 @@ func CollectDiskMetrics() []DiskMetrics {
 		metrics := getDiskUsage(mountPoint)
 		if metrics != nil {
 			metrics.Filesystem = device
+			metrics.FSType = fsType
 			disks = append(disks, *metrics)
 		}
 	}
 
 	// If no disks found, add root as fallback
-	if len(disks) == 0 {
+	if allowed, _ := filter.match("/"); len(disks) == 0 && allowed {
 		if metrics := getDiskUsage("/"); metrics != nil {
 			disks = append(disks, *metrics)
 		}
@@ -85,20 +110,40 @@ func getDiskUsage(path string) *DiskMetrics {
 	}
 
 	// Calculate sizes
-	total := float64(stat.Blocks * uint64(stat.Bsize))
-	available := float64(stat.Bavail * uint64(stat.Bsize))
-	used := total - float64(stat.Bfree*uint64(stat.Bsize))
+	totalBytes := stat.Blocks * uint64(stat.Bsize)
+	availableBytes := stat.Bavail * uint64(stat.Bsize)
+	usedBytes := totalBytes - stat.Bfree*uint64(stat.Bsize)
+
+	total := float64(totalBytes)
+	available := float64(availableBytes)
+	used := float64(usedBytes)
 
 	usagePercent := 0.0
 	if total > 0 {
 		usagePercent = 100.0 * used / total
 	}
 
+	inodesUsed := stat.Files - min(stat.Ffree, stat.Files)
+	inodesPercent := 0.0
+	if stat.Files > 0 {
+		inodesPercent = 100.0 * float64(inodesUsed) / float64(stat.Files)
+	}
+
 	return &DiskMetrics{
 		Mount:        path,
 		TotalGB:      roundFloat(total/1024/1024/1024, 2),
 		UsedGB:       roundFloat(used/1024/1024/1024, 2),
 		AvailableGB:  roundFloat(available/1024/1024/1024, 2),
 		UsagePercent: roundFloat(usagePercent, 2),
+		ReadOnly:     stat.Flags&stReadOnly != 0,
+
+		InodesTotal:        stat.Files,
+		InodesUsed:         inodesUsed,
+		InodesFree:         stat.Ffree,
+		InodesUsagePercent: roundFloat(inodesPercent, 2),
+
+		TotalBytes:     totalBytes,
+		UsedBytes:      usedBytes,
+		AvailableBytes: availableBytes,
 	}
 }
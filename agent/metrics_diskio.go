@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// diskSectorSize is the unit of the sector counts in /proc/diskstats,
+// regardless of the device's actual sector size
+const diskSectorSize = 512
+
+// DiskIOMetrics is a block device's I/O activity
+type DiskIOMetrics struct {
+	Device string `json:"device"`
+
+	// Counters since boot
+	Reads        uint64 `json:"reads"`
+	Writes       uint64 `json:"writes"`
+	ReadBytes    uint64 `json:"read_bytes"`
+	WriteBytes   uint64 `json:"write_bytes"`
+	IOTimeMillis uint64 `json:"io_time_ms"` // Time the device was busy
+
+	// Since the previous collection
+	ReadIOPS       float64 `json:"read_iops"`
+	WriteIOPS      float64 `json:"write_iops"`
+	ReadRate       float64 `json:"read_bytes_per_sec"`
+	WriteRate      float64 `json:"write_bytes_per_sec"`
+	AwaitMillis    float64 `json:"await_ms"`     // Average time per request, including queueing
+	UtilizationPct float64 `json:"util_percent"` // Share of time the device was busy
+}
+
+// diskCounters holds the /proc/diskstats fields the rates are computed from
+type diskCounters struct {
+	reads, writes               uint64
+	sectorsRead, sectorsWritten uint64
+	readMillis, writeMillis     uint64
+	ioMillis                    uint64
+}
+
+// previousDiskIO keeps each device's counters from the previous collection
+var previousDiskIO = struct {
+	sync.Mutex
+	devices     map[string]diskCounters
+	collectedAt time.Time
+}{devices: make(map[string]diskCounters)}
+
+// CollectDiskIOMetrics reads /proc/diskstats (Linux). Virtual devices
+// (loop, ram, zram) and devices that have never done any I/O are skipped.
+func CollectDiskIOMetrics() []DiskIOMetrics {
+	data, err := os.ReadFile("/proc/diskstats")
+	if err != nil {
+		return nil
+	}
+
+	previousDiskIO.Lock()
+	defer previousDiskIO.Unlock()
+	now := time.Now()
+	elapsed := now.Sub(previousDiskIO.collectedAt).Seconds()
+	seen := make(map[string]diskCounters)
+
+	var devices []DiskIOMetrics
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 14 {
+			continue
+		}
+
+		device := fields[2]
+		if strings.HasPrefix(device, "loop") || strings.HasPrefix(device, "ram") || strings.HasPrefix(device, "zram") {
+			continue
+		}
+
+		counter := func(index int) uint64 {
+			value, _ := strconv.ParseUint(fields[index], 10, 64)
+			return value
+		}
+		current := diskCounters{
+			reads:          counter(3),
+			sectorsRead:    counter(5),
+			readMillis:     counter(6),
+			writes:         counter(7),
+			sectorsWritten: counter(9),
+			writeMillis:    counter(10),
+			ioMillis:       counter(12),
+		}
+		if current.reads == 0 && current.writes == 0 {
+			continue
+		}
+		seen[device] = current
+
+		metrics := DiskIOMetrics{
+			Device:       device,
+			Reads:        current.reads,
+			Writes:       current.writes,
+			ReadBytes:    current.sectorsRead * diskSectorSize,
+			WriteBytes:   current.sectorsWritten * diskSectorSize,
+			IOTimeMillis: current.ioMillis,
+		}
+
+		if previous, ok := previousDiskIO.devices[device]; ok && elapsed > 0 {
+			metrics.ReadIOPS = perSecond(current.reads, previous.reads, elapsed)
+			metrics.WriteIOPS = perSecond(current.writes, previous.writes, elapsed)
+			metrics.ReadRate = perSecond(current.sectorsRead*diskSectorSize, previous.sectorsRead*diskSectorSize, elapsed)
+			metrics.WriteRate = perSecond(current.sectorsWritten*diskSectorSize, previous.sectorsWritten*diskSectorSize, elapsed)
+
+			requests, previousRequests := current.reads+current.writes, previous.reads+previous.writes
+			waited, previousWaited := current.readMillis+current.writeMillis, previous.readMillis+previous.writeMillis
+			if requests > previousRequests && waited >= previousWaited {
+				metrics.AwaitMillis = roundFloat(float64(waited-previousWaited)/float64(requests-previousRequests), 2)
+			}
+
+			if current.ioMillis >= previous.ioMillis {
+				busy := float64(current.ioMillis-previous.ioMillis) / (elapsed * 1000) * 100
+				metrics.UtilizationPct = roundFloat(min(busy, 100), 2)
+			}
+		}
+
+		devices = append(devices, metrics)
+	}
+
+	previousDiskIO.devices = seen
+	previousDiskIO.collectedAt = now
+	return devices
+}
@@ -14,6 +14,13 @@ type MemoryMetrics struct {
 	SwapTotalMB   uint64  `json:"swap_total_mb,omitempty"`
 	SwapUsedMB    uint64  `json:"swap_used_mb,omitempty"`
 	SwapPercent   float64 `json:"swap_percent,omitempty"`
+
+	// Exact sizes in bytes, for the Prometheus output
+	TotalBytes     uint64 `json:"total_bytes"`
+	UsedBytes      uint64 `json:"used_bytes"`
+	AvailableBytes uint64 `json:"available_bytes"`
+	SwapTotalBytes uint64 `json:"swap_total_bytes,omitempty"`
+	SwapUsedBytes  uint64 `json:"swap_used_bytes,omitempty"`
 }
 
 func CollectMemoryMetrics() MemoryMetrics {
@@ -57,6 +64,13 @@ func CollectMemoryMetrics() MemoryMetrics {
 		SwapTotalMB:  swapTotal / 1024,
 		SwapUsedMB:   swapUsed / 1024,
 		SwapPercent:  roundFloat(swapPercent, 2),
+
+		// /proc/meminfo reports kB
+		TotalBytes:     total * 1024,
+		UsedBytes:      used * 1024,
+		AvailableBytes: available * 1024,
+		SwapTotalBytes: swapTotal * 1024,
+		SwapUsedBytes:  swapUsed * 1024,
 	}
 }
 
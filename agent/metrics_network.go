@@ -4,13 +4,17 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 type NetworkMetrics struct {
-	RxBytes uint64                `json:"rx_bytes"`
-	TxBytes uint64                `json:"tx_bytes"`
-	RxMB    float64               `json:"rx_mb"`
-	TxMB    float64               `json:"tx_mb"`
+	RxBytes    uint64             `json:"rx_bytes"`
+	TxBytes    uint64             `json:"tx_bytes"`
+	RxMB       float64            `json:"rx_mb"`
+	TxMB       float64            `json:"tx_mb"`
+	RxRate     float64            `json:"rx_bytes_per_sec"` // Since the previous collection
+	TxRate     float64            `json:"tx_bytes_per_sec"`
 	Interfaces []NetworkInterface `json:"interfaces,omitempty"`
 }
 
@@ -20,8 +24,34 @@ type NetworkInterface struct {
 	TxBytes uint64  `json:"tx_bytes"`
 	RxMB    float64 `json:"rx_mb"`
 	TxMB    float64 `json:"tx_mb"`
+
+	// Counters since boot
+	RxPackets uint64 `json:"rx_packets"`
+	TxPackets uint64 `json:"tx_packets"`
+	RxErrors  uint64 `json:"rx_errors"`
+	TxErrors  uint64 `json:"tx_errors"`
+	RxDropped uint64 `json:"rx_dropped"`
+	TxDropped uint64 `json:"tx_dropped"`
+
+	// Rates since the previous collection
+	RxRate        float64 `json:"rx_bytes_per_sec"`
+	TxRate        float64 `json:"tx_bytes_per_sec"`
+	RxPacketRate  float64 `json:"rx_packets_per_sec"`
+	TxPacketRate  float64 `json:"tx_packets_per_sec"`
+	RxErrorRate   float64 `json:"rx_errors_per_sec"`
+	TxErrorRate   float64 `json:"tx_errors_per_sec"`
+	RxDroppedRate float64 `json:"rx_dropped_per_sec"`
+	TxDroppedRate float64 `json:"tx_dropped_per_sec"`
 }
 
+// previousNetwork keeps each interface's counters from the previous
+// collection, so rates can be computed
+var previousNetwork = struct {
+	sync.Mutex
+	interfaces  map[string]NetworkInterface
+	collectedAt time.Time
+}{interfaces: make(map[string]NetworkInterface)}
+
 func CollectNetworkMetrics() NetworkMetrics {
 	// Read /proc/net/dev (Linux)
 	data, err := os.ReadFile("/proc/net/dev")
@@ -31,7 +61,15 @@ func CollectNetworkMetrics() NetworkMetrics {
 
 	var totalRx, totalTx uint64
 	var interfaces []NetworkInterface
+	filter := activeConfig().Interfaces
+
+	previousNetwork.Lock()
+	defer previousNetwork.Unlock()
+	now := time.Now()
+	elapsed := now.Sub(previousNetwork.collectedAt).Seconds()
+	seen := make(map[string]NetworkInterface)
 
+	var totalRxRate, totalTxRate float64
 	lines := strings.Split(string(data), "\n")
 	for i, line := range lines {
 		// Skip header lines
@@ -39,43 +77,81 @@ func CollectNetworkMetrics() NetworkMetrics {
 			continue
 		}
 
-		fields := strings.Fields(line)
-		if len(fields) < 10 {
+		// Large counters can follow the colon without a space
+		name, counters, ok := strings.Cut(line, ":")
+		if !ok {
 			continue
 		}
+		fields := strings.Fields(counters)
+		if len(fields) < 12 {
+			continue
+		}
+
+		ifaceName := strings.TrimSpace(name)
 
-		ifaceName := strings.TrimSuffix(fields[0], ":")
+		allowed, explicit := filter.match(ifaceName)
+		if !allowed {
+			continue
+		}
 
-		// Skip loopback
-		if ifaceName == "lo" {
+		// Skip loopback, unless included explicitly
+		if ifaceName == "lo" && !explicit {
 			continue
 		}
 
-		rxBytes, _ := strconv.ParseUint(fields[1], 10, 64)
-		txBytes, _ := strconv.ParseUint(fields[9], 10, 64)
+		counter := func(index int) uint64 {
+			value, _ := strconv.ParseUint(fields[index], 10, 64)
+			return value
+		}
+		iface := NetworkInterface{
+			Name:      ifaceName,
+			RxBytes:   counter(0),
+			RxPackets: counter(1),
+			RxErrors:  counter(2),
+			RxDropped: counter(3),
+			TxBytes:   counter(8),
+			TxPackets: counter(9),
+			TxErrors:  counter(10),
+			TxDropped: counter(11),
+		}
+		iface.RxMB = roundFloat(float64(iface.RxBytes)/1024/1024, 2)
+		iface.TxMB = roundFloat(float64(iface.TxBytes)/1024/1024, 2)
 
-		totalRx += rxBytes
-		totalTx += txBytes
+		if previous, ok := previousNetwork.interfaces[ifaceName]; ok {
+			iface.RxRate = perSecond(iface.RxBytes, previous.RxBytes, elapsed)
+			iface.TxRate = perSecond(iface.TxBytes, previous.TxBytes, elapsed)
+			iface.RxPacketRate = perSecond(iface.RxPackets, previous.RxPackets, elapsed)
+			iface.TxPacketRate = perSecond(iface.TxPackets, previous.TxPackets, elapsed)
+			iface.RxErrorRate = perSecond(iface.RxErrors, previous.RxErrors, elapsed)
+			iface.TxErrorRate = perSecond(iface.TxErrors, previous.TxErrors, elapsed)
+			iface.RxDroppedRate = perSecond(iface.RxDropped, previous.RxDropped, elapsed)
+			iface.TxDroppedRate = perSecond(iface.TxDropped, previous.TxDropped, elapsed)
+		}
+		seen[ifaceName] = iface
+
+		totalRx += iface.RxBytes
+		totalTx += iface.TxBytes
+		totalRxRate += iface.RxRate
+		totalTxRate += iface.TxRate
 
 		// Skip virtual interfaces with no traffic
-		if rxBytes == 0 && txBytes == 0 {
+		if iface.RxBytes == 0 && iface.TxBytes == 0 && !explicit {
 			continue
 		}
 
-		interfaces = append(interfaces, NetworkInterface{
-			Name:    ifaceName,
-			RxBytes: rxBytes,
-			TxBytes: txBytes,
-			RxMB:    roundFloat(float64(rxBytes)/1024/1024, 2),
-			TxMB:    roundFloat(float64(txBytes)/1024/1024, 2),
-		})
+		interfaces = append(interfaces, iface)
 	}
 
+	previousNetwork.interfaces = seen
+	previousNetwork.collectedAt = now
+
 	return NetworkMetrics{
 		RxBytes:    totalRx,
 		TxBytes:    totalTx,
 		RxMB:       roundFloat(float64(totalRx)/1024/1024, 2),
 		TxMB:       roundFloat(float64(totalTx)/1024/1024, 2),
+		RxRate:     roundFloat(totalRxRate, 2),
+		TxRate:     roundFloat(totalTxRate, 2),
 		Interfaces: interfaces,
 	}
 }
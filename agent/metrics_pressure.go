@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PressureMetrics is Linux pressure stall information (PSI, kernel 4.20+):
+// the share of time tasks were stalled waiting for CPU, memory or I/O. Unlike
+// usage, it shows whether work is actually being delayed.
+type PressureMetrics struct {
+	CPU    *Pressure `json:"cpu,omitempty"`
+	Memory *Pressure `json:"memory,omitempty"`
+	IO     *Pressure `json:"io,omitempty"`
+}
+
+// Pressure holds the stall averages of one resource. Some is time at least one
+// task was stalled; full is time all non-idle tasks were stalled at once.
+type Pressure struct {
+	Some PressureAverages  `json:"some"`
+	Full *PressureAverages `json:"full,omitempty"` // Not reported for CPU by older kernels
+}
+
+// PressureAverages are stall percentages over 10, 60 and 300 seconds, and the
+// total stall time since boot
+type PressureAverages struct {
+	Avg10        float64 `json:"avg10"`
+	Avg60        float64 `json:"avg60"`
+	Avg300       float64 `json:"avg300"`
+	TotalSeconds float64 `json:"total_seconds"`
+}
+
+// CollectPressureMetrics reads /proc/pressure. It returns nil if the kernel
+// doesn't support PSI or it is disabled (psi=0).
+func CollectPressureMetrics() *PressureMetrics {
+	metrics := &PressureMetrics{
+		CPU:    readPressure("/proc/pressure/cpu"),
+		Memory: readPressure("/proc/pressure/memory"),
+		IO:     readPressure("/proc/pressure/io"),
+	}
+	if metrics.CPU == nil && metrics.Memory == nil && metrics.IO == nil {
+		return nil
+	}
+	return metrics
+}
+
+// readPressure parses a PSI file, with lines like
+// "some avg10=1.23 avg60=0.50 avg300=0.10 total=123456" (total in microseconds)
+func readPressure(filename string) *Pressure {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil
+	}
+
+	var pressure Pressure
+	found := false
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var averages PressureAverages
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			number, _ := strconv.ParseFloat(value, 64)
+			switch key {
+			case "avg10":
+				averages.Avg10 = number
+			case "avg60":
+				averages.Avg60 = number
+			case "avg300":
+				averages.Avg300 = number
+			case "total":
+				averages.TotalSeconds = number / 1e6
+			}
+		}
+
+		switch fields[0] {
+		case "some":
+			pressure.Some = averages
+			found = true
+		case "full":
+			pressure.Full = &averages
+		}
+	}
+	if !found {
+		return nil
+	}
+	return &pressure
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clockTicks is the kernel's USER_HZ, the unit of CPU times in /proc. It is
+// 100 on every mainstream Linux platform and can't be read without cgo.
+const clockTicks = 100
+
+// ProcessMetrics is the resource usage of a single process, for the top lists
+type ProcessMetrics struct {
+	PID        int     `json:"pid"`
+	Name       string  `json:"name"`
+	Command    string  `json:"command,omitempty"` // Command line, shortened
+	CPUPercent float64 `json:"cpu_percent"`       // Of one core, so it may exceed 100
+	RSSBytes   uint64  `json:"rss_bytes"`
+	Threads    int     `json:"threads"`
+}
+
+// processInfo is a process as seen in /proc/[pid]/stat during one scan
+type processInfo struct {
+	pid        int
+	comm       string
+	cmdline    string // Arguments separated by spaces
+	cpuTicks   uint64 // User plus system time
+	cpuPercent float64
+	rssBytes   uint64
+	threads    int
+	startedAt  time.Time
+}
+
+// processCPU remembers each process's CPU time from the previous scan, so
+// usage can be reported over the collection interval
+var processCPU = struct {
+	sync.Mutex
+	ticks     map[int]uint64
+	scannedAt time.Time
+}{ticks: make(map[int]uint64)}
+
+// scanProcesses reads every process's name, command line and resource usage
+// (Linux). A process's CPU usage is measured since the previous scan, or since
+// it started if it is new.
+func scanProcesses() []processInfo {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+
+	bootTime := readBootTime()
+	pageSize := uint64(os.Getpagesize())
+	now := time.Now()
+
+	processCPU.Lock()
+	defer processCPU.Unlock()
+	elapsed := now.Sub(processCPU.scannedAt).Seconds()
+	ticks := make(map[int]uint64, len(processCPU.ticks))
+
+	var processes []processInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		process, ok := readProcessStat(pid, bootTime, pageSize)
+		if !ok {
+			continue // Exited meanwhile
+		}
+		cmdline, _ := os.ReadFile("/proc/" + entry.Name() + "/cmdline")
+		process.cmdline = strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+
+		if previous, seen := processCPU.ticks[pid]; seen && elapsed > 0 && process.cpuTicks >= previous {
+			process.cpuPercent = float64(process.cpuTicks-previous) / clockTicks / elapsed * 100
+		} else if lifetime := now.Sub(process.startedAt).Seconds(); lifetime > 0 {
+			process.cpuPercent = float64(process.cpuTicks) / clockTicks / lifetime * 100
+		}
+		process.cpuPercent = roundFloat(process.cpuPercent, 2)
+		ticks[pid] = process.cpuTicks
+
+		processes = append(processes, process)
+	}
+
+	processCPU.ticks = ticks
+	processCPU.scannedAt = now
+	return processes
+}
+
+// readProcessStat parses /proc/[pid]/stat
+func readProcessStat(pid int, bootTime time.Time, pageSize uint64) (processInfo, bool) {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return processInfo{}, false
+	}
+
+	// The name is in parentheses and may itself contain spaces or parentheses
+	stat := string(data)
+	open, end := strings.IndexByte(stat, '('), strings.LastIndexByte(stat, ')')
+	if open < 0 || end < open {
+		return processInfo{}, false
+	}
+	// Fields after the name start with field 3 (state)
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 22 {
+		return processInfo{}, false
+	}
+
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+	threads, _ := strconv.Atoi(fields[17])
+	startTicks, _ := strconv.ParseUint(fields[19], 10, 64)
+	rssPages, _ := strconv.ParseUint(fields[21], 10, 64)
+
+	return processInfo{
+		pid:       pid,
+		comm:      stat[open+1 : end],
+		cpuTicks:  utime + stime,
+		rssBytes:  rssPages * pageSize,
+		threads:   threads,
+		startedAt: bootTime.Add(time.Duration(startTicks) * time.Second / clockTicks),
+	}, true
+}
+
+// readBootTime returns when the system booted, from the btime line of /proc/stat
+func readBootTime() time.Time {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "btime "); ok {
+			seconds, _ := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			return time.Unix(seconds, 0)
+		}
+	}
+	return time.Time{}
+}
+
+// processDetails holds what /proc/[pid]/status, io and fd add to a process's
+// stat. io and fd are only readable for other users' processes as root.
+type processDetails struct {
+	rssBytes   uint64
+	threads    int
+	openFDs    int
+	readBytes  uint64
+	writeBytes uint64
+}
+
+func readProcessDetails(pid int) processDetails {
+	dir := "/proc/" + strconv.Itoa(pid)
+	var details processDetails
+
+	if data, err := os.ReadFile(dir + "/status"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			fields := strings.Fields(value)
+			if len(fields) == 0 {
+				continue
+			}
+			switch key {
+			case "VmRSS":
+				kb, _ := strconv.ParseUint(fields[0], 10, 64)
+				details.rssBytes = kb * 1024
+			case "Threads":
+				details.threads, _ = strconv.Atoi(fields[0])
+			}
+		}
+	}
+
+	if data, err := os.ReadFile(dir + "/io"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			switch key {
+			case "read_bytes":
+				details.readBytes, _ = strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+			case "write_bytes":
+				details.writeBytes, _ = strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+			}
+		}
+	}
+
+	if entries, err := os.ReadDir(dir + "/fd"); err == nil {
+		details.openFDs = len(entries)
+	}
+
+	return details
+}
+
+// topProcesses returns the n processes using the most CPU or memory
+func topProcesses(processes []processInfo, n int, byMemory bool) []ProcessMetrics {
+	if n <= 0 {
+		return nil
+	}
+
+	sorted := make([]processInfo, len(processes))
+	copy(sorted, processes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if byMemory {
+			return sorted[i].rssBytes > sorted[j].rssBytes
+		}
+		return sorted[i].cpuPercent > sorted[j].cpuPercent
+	})
+
+	top := make([]ProcessMetrics, 0, n)
+	for _, process := range sorted[:min(n, len(sorted))] {
+		command := strings.Join(strings.Fields(process.cmdline), " ")
+		if runes := []rune(command); len(runes) > 120 {
+			command = string(runes[:117]) + "..."
+		}
+		top = append(top, ProcessMetrics{
+			PID:        process.pid,
+			Name:       process.comm,
+			Command:    command,
+			CPUPercent: process.cpuPercent,
+			RSSBytes:   process.rssBytes,
+			Threads:    process.threads,
+		})
+	}
+	return top
+}
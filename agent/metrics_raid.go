@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RAIDMetrics is the state of a Linux software RAID (md) array
+type RAIDMetrics struct {
+	Device        string   `json:"device"`         // e.g. md0
+	Level         string   `json:"level"`          // raid1, raid5, ...
+	Status        string   `json:"status"`         // clean, degraded, rebuilding, resyncing, checking or inactive
+	Degraded      bool     `json:"degraded"`       // Members are missing or failed
+	Devices       int      `json:"devices"`        // Members the array should have
+	ActiveDevices int      `json:"active_devices"` // Members in sync
+	FailedDevices int      `json:"failed_devices"` // Members marked faulty
+	Members       []string `json:"members"`
+
+	// Sync in progress: recovery or reshape (rebuilding), resync, check or repair
+	SyncAction  string  `json:"sync_action,omitempty"`
+	SyncPercent float64 `json:"sync_percent,omitempty"`
+}
+
+var (
+	// raidDevicesPattern matches the "[2/1]" member counts of an array
+	raidDevicesPattern = regexp.MustCompile(`\[(\d+)/(\d+)\]`)
+
+	// raidSyncPattern matches a progress line like "recovery =  8.5% (...)"
+	raidSyncPattern = regexp.MustCompile(`(recovery|reshape|resync|check|repair)\s*=\s*([\d.]+)%`)
+)
+
+// CollectRAIDMetrics parses /proc/mdstat (Linux). It returns nil if there is
+// no md driver or no arrays.
+func CollectRAIDMetrics() []RAIDMetrics {
+	data, err := os.ReadFile("/proc/mdstat")
+	if err != nil {
+		return nil
+	}
+	return parseMDStat(string(data))
+}
+
+// parseMDStat parses the contents of /proc/mdstat
+func parseMDStat(data string) []RAIDMetrics {
+	var arrays []RAIDMetrics
+	var current *RAIDMetrics
+	for _, line := range strings.Split(data, "\n") {
+		// An array starts with "md0 : active raid1 sdb1[1] sda1[0](F)";
+		// its status lines are indented
+		if name, description, ok := strings.Cut(line, " : "); ok && strings.HasPrefix(name, "md") {
+			arrays = append(arrays, parseRAIDArray(name, description))
+			current = &arrays[len(arrays)-1]
+			continue
+		}
+		if current == nil || !strings.HasPrefix(line, " ") {
+			current = nil
+			continue
+		}
+
+		if match := raidDevicesPattern.FindStringSubmatch(line); match != nil {
+			current.Devices, _ = strconv.Atoi(match[1])
+			current.ActiveDevices, _ = strconv.Atoi(match[2])
+		}
+		if match := raidSyncPattern.FindStringSubmatch(line); match != nil {
+			current.SyncAction = match[1]
+			current.SyncPercent, _ = strconv.ParseFloat(match[2], 64)
+		}
+	}
+
+	for i := range arrays {
+		array := &arrays[i]
+		array.Degraded = array.ActiveDevices < array.Devices || array.FailedDevices > 0
+		switch {
+		case array.Status == "inactive":
+		case array.SyncAction == "recovery" || array.SyncAction == "reshape":
+			array.Status = "rebuilding"
+		case array.Degraded:
+			array.Status = "degraded"
+		case array.SyncAction == "resync":
+			array.Status = "resyncing"
+		case array.SyncAction == "check" || array.SyncAction == "repair":
+			array.Status = "checking"
+		default:
+			array.Status = "clean"
+		}
+	}
+	return arrays
+}
+
+// parseRAIDArray parses the first line of an array, after "md0 : "
+func parseRAIDArray(name, description string) RAIDMetrics {
+	array := RAIDMetrics{Device: strings.TrimSpace(name), Members: []string{}}
+
+	fields := strings.Fields(description)
+	if len(fields) > 0 && fields[0] == "inactive" {
+		array.Status = "inactive"
+	}
+	for _, field := range fields[min(1, len(fields)):] {
+		// Members look like "sda1[0]", with (F) for faulty, (S) for spare,
+		// (W) for write-mostly or (R) for replacement
+		member, _, isMember := strings.Cut(field, "[")
+		switch {
+		case strings.HasPrefix(field, "("):
+			continue // e.g. "(auto-read-only)"
+		case !isMember && array.Level == "":
+			array.Level = field
+		case isMember:
+			array.Members = append(array.Members, member)
+			if strings.HasSuffix(field, "(F)") {
+				array.FailedDevices++
+			}
+		}
+	}
+
+	// Arrays without redundancy (raid0, linear) have no member counts
+	array.Devices = len(array.Members)
+	array.ActiveDevices = len(array.Members) - array.FailedDevices
+	return array
+}
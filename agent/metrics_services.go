@@ -2,96 +2,158 @@ package main
 
 import (
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 )
 
 type ServiceMetrics struct {
 	Name   string `json:"name"`
 	Status string `json:"status"` // "running", "stopped", "unknown"
 	PID    int    `json:"pid,omitempty"`
+
+	// Resource usage, summed over all matching processes
+	ProcessCount  int     `json:"process_count,omitempty"`
+	CPUPercent    float64 `json:"cpu_percent"` // Of one core, so it may exceed 100
+	RSSBytes      uint64  `json:"rss_bytes"`
+	Threads       int     `json:"threads"`
+	OpenFDs       int     `json:"open_fds"`
+	ReadBytes     uint64  `json:"read_bytes"`     // Read from storage since start
+	WriteBytes    uint64  `json:"write_bytes"`    // Written to storage since start
+	UptimeSeconds int64   `json:"uptime_seconds"` // Of the oldest process
 }
 
 type PortMetrics struct {
-	Port   int    `json:"port"`
-	Status string `json:"status"` // "listening", "closed"
-	Process string `json:"process,omitempty"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol,omitempty"` // "tcp" or "udp"
+	Family   string `json:"family,omitempty"`   // "ipv4" or "ipv6", if only one is checked
+	Status   string `json:"status"`             // "listening", "closed"
+	Process  string `json:"process,omitempty"`
 }
 
-func CollectServiceMetrics() []ServiceMetrics {
-	var services []ServiceMetrics
-
-	// Common services to check
-	serviceNames := []string{
-		"nginx", "apache2", "httpd",
-		"mysql", "mysqld", "mariadb",
-		"postgresql", "postgres",
-		"redis", "redis-server",
-		"mongodb", "mongod",
-		"docker", "dockerd",
-	}
+// defaultProcesses are watched when the configuration doesn't list any, and
+// reported only if they are running
+var defaultProcesses = []string{
+	"nginx", "apache2", "httpd",
+	"mysql", "mysqld", "mariadb",
+	"postgresql", "postgres",
+	"redis", "redis-server",
+	"mongodb", "mongod",
+	"docker", "dockerd",
+}
 
-	for _, name := range serviceNames {
-		if status := checkProcess(name); status != nil {
-			services = append(services, *status)
+func CollectServiceMetrics(processes []processInfo) []ServiceMetrics {
+	watched := activeConfig().Processes
+	if watched == nil {
+		var services []ServiceMetrics
+		for _, name := range defaultProcesses {
+			if matches := findProcesses(ProcessConfig{Name: name}, processes); len(matches) > 0 {
+				services = append(services, summarizeProcesses(name, matches))
+			}
 		}
+		return services
 	}
 
+	// Configured processes are always reported, as stopped if they aren't found
+	services := make([]ServiceMetrics, 0, len(watched))
+	for _, process := range watched {
+		services = append(services, summarizeProcesses(process.Name, findProcesses(process, processes)))
+	}
 	return services
 }
 
-func checkProcess(name string) *ServiceMetrics {
-	// Try pgrep first (most reliable)
-	cmd := exec.Command("pgrep", "-x", name)
-	output, err := cmd.Output()
-	if err == nil && len(output) > 0 {
-		pidStr := strings.TrimSpace(string(output))
-		pids := strings.Split(pidStr, "\n")
-		pid, _ := strconv.Atoi(pids[0])
-		return &ServiceMetrics{
-			Name:   name,
-			Status: "running",
-			PID:    pid,
+// summarizeProcesses sums the resource usage of the processes matching a watch
+func summarizeProcesses(name string, matches []processInfo) ServiceMetrics {
+	metric := ServiceMetrics{Name: name, Status: "stopped"}
+	if len(matches) == 0 {
+		return metric
+	}
+
+	metric.Status = "running"
+	metric.PID = matches[0].pid
+	metric.ProcessCount = len(matches)
+	oldest := matches[0].startedAt
+	for _, process := range matches {
+		details := readProcessDetails(process.pid)
+		metric.CPUPercent += process.cpuPercent
+		metric.RSSBytes += details.rssBytes
+		metric.Threads += details.threads
+		metric.OpenFDs += details.openFDs
+		metric.ReadBytes += details.readBytes
+		metric.WriteBytes += details.writeBytes
+		if process.startedAt.Before(oldest) {
+			oldest = process.startedAt
+			metric.PID = process.pid
 		}
 	}
+	metric.CPUPercent = roundFloat(metric.CPUPercent, 2)
+	metric.UptimeSeconds = int64(time.Since(oldest).Seconds())
+	return metric
+}
 
-	// Fallback: check if process name exists in /proc
-	entries, err := os.ReadDir("/proc")
-	if err == nil {
-		for _, entry := range entries {
-			if !entry.IsDir() {
-				continue
-			}
+// findProcesses returns the processes a watch matches
+func findProcesses(watch ProcessConfig, processes []processInfo) []processInfo {
+	var matches []processInfo
 
-			// Check if directory name is a number (PID)
-			pid, err := strconv.Atoi(entry.Name())
-			if err != nil {
-				continue
+	switch {
+	case watch.Pidfile != "":
+		data, err := os.ReadFile(watch.Pidfile)
+		if err != nil {
+			return nil
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil || pid <= 0 {
+			return nil
+		}
+		for _, process := range processes {
+			if process.pid == pid {
+				return []processInfo{process}
 			}
+		}
+		// Not in the scan (e.g. /proc is hidden); signal 0 only checks that
+		// the process exists, and EPERM means it belongs to another user
+		if err := syscall.Kill(pid, 0); err == nil || err == syscall.EPERM {
+			return []processInfo{{pid: pid, startedAt: time.Now()}}
+		}
 
-			// Read process name from /proc/[pid]/comm
-			commPath := "/proc/" + entry.Name() + "/comm"
-			commData, err := os.ReadFile(commPath)
-			if err != nil {
-				continue
+	case watch.cmdline != nil:
+		// The agent's own command line may contain the pattern (e.g. in -config)
+		self := os.Getpid()
+		for _, process := range processes {
+			if process.pid != self && watch.cmdline.MatchString(process.cmdline) {
+				matches = append(matches, process)
 			}
+		}
 
-			processName := strings.TrimSpace(string(commData))
-			if processName == name {
-				return &ServiceMetrics{
-					Name:   name,
-					Status: "running",
-					PID:    pid,
-				}
+	default:
+		// The kernel truncates process names to 15 characters
+		name := watch.Name
+		if len(name) > 15 {
+			name = name[:15]
+		}
+		for _, process := range processes {
+			if process.comm == name {
+				matches = append(matches, process)
 			}
 		}
 	}
-
-	return nil
+	return matches
 }
 
 func CollectPortMetrics() []PortMetrics {
+	if watched := activeConfig().Ports; watched != nil {
+		ports := make([]PortMetrics, 0, len(watched))
+		for _, port := range watched {
+			metric := PortMetrics{Port: port.Port, Protocol: port.Protocol, Family: port.Family, Status: "closed"}
+			if portListening(port.Port, port.Protocol, port.Family) {
+				metric.Status = "listening"
+			}
+			ports = append(ports, metric)
+		}
+		return ports
+	}
+
 	var ports []PortMetrics
 
 	// Common ports to check
@@ -117,30 +179,34 @@ func CollectPortMetrics() []PortMetrics {
 }
 
 func checkPort(port int) *PortMetrics {
-	// Read /proc/net/tcp and /proc/net/tcp6
-	listening := false
-
-	// Check IPv4
-	if checkPortInFile("/proc/net/tcp", port) {
-		listening = true
-	}
-
-	// Check IPv6
-	if !listening && checkPortInFile("/proc/net/tcp6", port) {
-		listening = true
-	}
-
-	if listening {
+	if portListening(port, "tcp", "") {
 		return &PortMetrics{
-			Port:   port,
-			Status: "listening",
+			Port:     port,
+			Protocol: "tcp",
+			Status:   "listening",
 		}
 	}
 
 	return nil
 }
 
-func checkPortInFile(filename string, port int) bool {
+// portListening reports whether a socket is listening on the port, reading
+// /proc/net/{tcp,udp}[6]. UDP sockets have no listen state, so a bound UDP
+// socket counts as listening. An empty family checks IPv4 and IPv6.
+func portListening(port int, protocol, family string) bool {
+	// TCP_LISTEN is 0A, and unconnected UDP sockets are 07 (TCP_CLOSE)
+	state := "0A"
+	if protocol == "udp" {
+		state = "07"
+	}
+
+	if family != "ipv6" && checkPortInFile("/proc/net/"+protocol, port, state) {
+		return true
+	}
+	return family != "ipv4" && checkPortInFile("/proc/net/"+protocol+"6", port, state)
+}
+
+func checkPortInFile(filename string, port int, listenState string) bool {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return false
@@ -172,9 +238,9 @@ func checkPortInFile(filename string, port int) bool {
 		}
 
 		if parts[1] == portHex {
-			// Check if it's in LISTEN state (0A in hex)
+			// Check if it's in the listening state
 			state := fields[3]
-			if state == "0A" {
+			if state == listenState {
 				return true
 			}
 		}
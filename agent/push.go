@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// pushPath and registerPath are the ingestion endpoints on the monitoring server
+	pushPath     = "/api/agent/push"
+	registerPath = "/api/agent/register"
+
+	// reregisterInterval is how often host facts are sent again, so changed
+	// IP addresses or upgrades show up on the server
+	reregisterInterval = time.Hour
+)
+
+// pushClient sends metrics to the monitoring server, for hosts the server
+// cannot reach (e.g. behind NAT)
+type pushClient struct {
+	serverURL    string
+	token        string
+	client       *http.Client
+	registeredAt time.Time
+	failing      bool // whether the last push failed, so errors are logged once
+}
+
+func newPushClient(serverURL, token string) *pushClient {
+	return &pushClient{
+		serverURL: strings.TrimRight(serverURL, "/"),
+		token:     token,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// push posts a metrics snapshot to the server, registering the host's facts
+// first if they haven't been sent recently. Failures are logged when they
+// start and when pushing recovers, not on every interval.
+func (p *pushClient) push(metrics *MetricsResponse) {
+	err := p.registerIfDue(metrics.Hostname)
+	if err == nil {
+		err = p.send(pushPath, metrics)
+	}
+
+	switch {
+	case err != nil && !p.failing:
+		log.Printf("Pushing metrics to %s failed: %v (retrying every %ds)", p.serverURL, err, *interval)
+	case err == nil && p.failing:
+		log.Printf("Pushing metrics to %s recovered", p.serverURL)
+	}
+	p.failing = err != nil
+}
+
+func (p *pushClient) registerIfDue(hostname string) error {
+	if time.Since(p.registeredAt) < reregisterInterval {
+		return nil
+	}
+	if err := p.send(registerPath, CollectHostFacts(hostname)); err != nil {
+		return fmt.Errorf("registering: %w", err)
+	}
+	p.registeredAt = time.Now()
+	return nil
+}
+
+func (p *pushClient) send(path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.serverURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	req.Header.Set("X-Agent-Interval", strconv.Itoa(*interval))
+	req.Header.Set("User-Agent", "monitoring-agent/"+agentVersion)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("server returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
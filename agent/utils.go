@@ -7,3 +7,12 @@ func roundFloat(val float64, precision int) float64 {
 	ratio := math.Pow(10, float64(precision))
 	return math.Round(val*ratio) / ratio
 }
+
+// perSecond returns the rate at which a counter grew over the given number of
+// seconds, or 0 if there is no interval or the counter was reset
+func perSecond(current, previous uint64, seconds float64) float64 {
+	if seconds <= 0 || current < previous {
+		return 0
+	}
+	return roundFloat(float64(current-previous)/seconds, 2)
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"errors"
+	"monitoring/models"
+	"monitoring/services"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// SessionCookieName is the cookie holding the dashboard session
+	SessionCookieName = "monitoring_session"
+
+	// userContextKey is where the authenticated user is stored on the gin context
+	userContextKey = "user"
+)
+
+// AuthHandler handles login, logout, accounts and API tokens
+type AuthHandler struct {
+	auth  *services.AuthService
+	users *models.UserStore
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(auth *services.AuthService, users *models.UserStore) *AuthHandler {
+	return &AuthHandler{
+		auth:  auth,
+		users: users,
+	}
+}
+
+// RequireAuth rejects API requests without a valid session cookie or
+// "Authorization: Bearer <token>" header
+func (h *AuthHandler) RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := h.authenticate(c)
+		if user == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+
+		c.Set(userContextKey, user)
+		c.Next()
+	}
+}
+
+// RequireLogin redirects dashboard requests without a valid session to the login page
+func (h *AuthHandler) RequireLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := h.authenticate(c)
+		if user == nil {
+			c.Redirect(http.StatusFound, "/login")
+			c.Abort()
+			return
+		}
+
+		c.Set(userContextKey, user)
+		c.Next()
+	}
+}
+
+// authenticate returns the user behind the request's API token or session, or nil
+func (h *AuthHandler) authenticate(c *gin.Context) *models.User {
+	if header := c.GetHeader("Authorization"); header != "" {
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			return nil
+		}
+		user, err := h.auth.ValidateAPIToken(strings.TrimSpace(token))
+		if err != nil {
+			return nil
+		}
+		return user
+	}
+
+	value, err := c.Cookie(SessionCookieName)
+	if err != nil {
+		return nil
+	}
+	user, err := h.auth.ValidateSession(value)
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
+// currentUser returns the user set by RequireAuth or RequireLogin
+func currentUser(c *gin.Context) *models.User {
+	user, _ := c.Get(userContextKey)
+	current, _ := user.(*models.User)
+	return current
+}
+
+// LoginPage handles GET /login
+func (h *AuthHandler) LoginPage(c *gin.Context) {
+	if h.authenticate(c) != nil {
+		c.Redirect(http.StatusFound, "/")
+		return
+	}
+	c.HTML(http.StatusOK, "login.html", nil)
+}
+
+// Login handles POST /api/auth/login
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req struct {
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, session, err := h.auth.Login(req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.setSessionCookie(c, session, int(services.SessionTTL.Seconds()))
+	c.JSON(http.StatusOK, user.Public())
+}
+
+// Logout handles POST /api/auth/logout
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if value, err := c.Cookie(SessionCookieName); err == nil {
+		h.auth.Logout(value)
+	}
+
+	h.setSessionCookie(c, "", -1)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// setSessionCookie writes the session cookie. It is HttpOnly, SameSite=Lax
+// and Secure whenever the request arrived over HTTPS.
+func (h *AuthHandler) setSessionCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(SessionCookieName, value, maxAge, "/", "", secure, true)
+}
+
+// GetCurrentUser handles GET /api/auth/me
+func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
+	c.JSON(http.StatusOK, currentUser(c).Public())
+}
+
+// ChangePassword handles PUT /api/auth/password
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := currentUser(c)
+	if !h.auth.CheckPassword(user, req.CurrentPassword) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+
+	session, _ := c.Cookie(SessionCookieName)
+	if err := h.auth.SetPassword(user.ID, req.NewPassword, session); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+}
+
+// GetTokens handles GET /api/auth/tokens
+func (h *AuthHandler) GetTokens(c *gin.Context) {
+	c.JSON(http.StatusOK, h.auth.GetAPITokens(currentUser(c).ID))
+}
+
+// CreateToken handles POST /api/auth/tokens. The token is only returned by this call.
+func (h *AuthHandler) CreateToken(c *gin.Context) {
+	var req struct {
+		Name          string `json:"name" binding:"required"`
+		ExpiresInDays int    `json:"expires_in_days"` // 0 = never expires
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		expiry := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &expiry
+	}
+
+	token, value, err := h.auth.CreateAPIToken(currentUser(c).ID, req.Name, expiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token":   value,
+		"details": token.Public(),
+	})
+}
+
+// DeleteToken handles DELETE /api/auth/tokens/:id
+func (h *AuthHandler) DeleteToken(c *gin.Context) {
+	if err := h.auth.DeleteAPIToken(currentUser(c).ID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API token not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API token deleted successfully"})
+}
+
+// GetAllUsers handles GET /api/users
+func (h *AuthHandler) GetAllUsers(c *gin.Context) {
+	users := h.users.GetAll()
+	public := make([]*models.User, len(users))
+	for i, user := range users {
+		public[i] = user.Public()
+	}
+	c.JSON(http.StatusOK, public)
+}
+
+// CreateUser handles POST /api/users. New users are viewers unless a role is given.
+func (h *AuthHandler) CreateUser(c *gin.Context) {
+	var req struct {
+		Username string      `json:"username" binding:"required"`
+		Password string      `json:"password" binding:"required"`
+		Role     models.Role `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.RoleViewer
+	}
+
+	user, err := h.auth.CreateUser(req.Username, req.Password, req.Role)
+	if err != nil {
+		if errors.Is(err, models.ErrUsernameTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, user.Public())
+}
+
+// SetUserRole handles PUT /api/users/:id/role
+func (h *AuthHandler) SetUserRole(c *gin.Context) {
+	var req struct {
+		Role models.Role `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id := c.Param("id")
+	if id == currentUser(c).ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+
+	user, err := h.auth.SetRole(id, req.Role)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user.Public())
+}
+
+// ResetPassword handles PUT /api/users/:id/password, setting another user's password
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.auth.SetPassword(c.Param("id"), req.Password, ""); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+}
+
+// DeleteUser handles DELETE /api/users/:id
+func (h *AuthHandler) DeleteUser(c *gin.Context) {
+	id := c.Param("id")
+	if id == currentUser(c).ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot delete your own account"})
+		return
+	}
+
+	if err := h.auth.DeleteUser(id); err != nil {
+		if errors.Is(err, services.ErrLastAdmin) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+}
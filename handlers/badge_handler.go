@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"monitoring/models"
+	"monitoring/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// badgeCacheControl lets CDNs and image proxies (like GitHub's camo) cache
+// badges briefly and serve a stale copy while revalidating
+const badgeCacheControl = "public, max-age=60, s-maxage=60, stale-while-revalidate=300"
+
+// BadgeHandler serves public SVG badges and manages badge tokens
+type BadgeHandler struct {
+	badges *services.BadgeService
+}
+
+// NewBadgeHandler creates a new badge handler
+func NewBadgeHandler(badges *services.BadgeService) *BadgeHandler {
+	return &BadgeHandler{
+		badges: badges,
+	}
+}
+
+// EnableBadges handles POST /api/services/:id/badge, creating or rotating the badge token
+func (h *BadgeHandler) EnableBadges(c *gin.Context) {
+	service, err := h.badges.EnableBadges(c.Param("id"))
+	if err != nil {
+		h.tokenError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"badge_token": service.BadgeToken,
+		"status":      "/badge/" + service.BadgeToken + "/status.svg",
+		"uptime":      "/badge/" + service.BadgeToken + "/uptime.svg",
+		"response":    "/badge/" + service.BadgeToken + "/response-time.svg",
+	})
+}
+
+// DisableBadges handles DELETE /api/services/:id/badge
+func (h *BadgeHandler) DisableBadges(c *gin.Context) {
+	if _, err := h.badges.DisableBadges(c.Param("id")); err != nil {
+		h.tokenError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Badges disabled successfully"})
+}
+
+func (h *BadgeHandler) tokenError(c *gin.Context, err error) {
+	if errors.Is(err, models.ErrServiceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// StatusBadge handles GET /badge/:token/status.svg
+func (h *BadgeHandler) StatusBadge(c *gin.Context) {
+	service, ok := h.service(c)
+	if !ok {
+		return
+	}
+	h.render(c, http.StatusOK, h.badges.StatusBadge(service))
+}
+
+// UptimeBadge handles GET /badge/:token/uptime.svg?window=30d
+func (h *BadgeHandler) UptimeBadge(c *gin.Context) {
+	service, ok := h.service(c)
+	if !ok {
+		return
+	}
+
+	window, err := services.ParseBadgeWindow(c.Query("window"))
+	if err != nil {
+		h.render(c, http.StatusBadRequest, services.ErrorBadge("uptime", "invalid window"))
+		return
+	}
+
+	badge, err := h.badges.UptimeBadge(service, window)
+	if err != nil {
+		h.render(c, http.StatusInternalServerError, services.ErrorBadge("uptime", "error"))
+		return
+	}
+	h.render(c, http.StatusOK, badge)
+}
+
+// ResponseTimeBadge handles GET /badge/:token/response-time.svg
+func (h *BadgeHandler) ResponseTimeBadge(c *gin.Context) {
+	service, ok := h.service(c)
+	if !ok {
+		return
+	}
+	h.render(c, http.StatusOK, h.badges.ResponseTimeBadge(service))
+}
+
+// service looks up the service behind the badge token, rendering a
+// "not found" badge if there is none
+func (h *BadgeHandler) service(c *gin.Context) (*models.MonitoredService, bool) {
+	service, err := h.badges.GetService(c.Param("token"))
+	if err != nil {
+		h.render(c, http.StatusNotFound, services.NotFoundBadge())
+		return nil, false
+	}
+	return service, true
+}
+
+// render writes a badge with CDN-friendly caching headers. Successful
+// badges carry an ETag so unchanged badges are revalidated with a 304.
+func (h *BadgeHandler) render(c *gin.Context, status int, badge services.Badge) {
+	svg := badge.WithLabel(c.Query("label")).RenderSVG()
+
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	if status != http.StatusOK {
+		c.Header("Cache-Control", "no-cache")
+		c.Data(status, "image/svg+xml; charset=utf-8", svg)
+		return
+	}
+
+	sum := sha256.Sum256(svg)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	c.Header("Cache-Control", badgeCacheControl)
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", svg)
+}
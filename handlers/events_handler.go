@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"fmt"
+	"monitoring/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// eventsKeepAlive is how often a comment is sent on idle streams, so proxies
+// do not close them
+const eventsKeepAlive = 25 * time.Second
+
+// EventsHandler streams live updates to the dashboard over Server-Sent Events
+type EventsHandler struct {
+	events *services.EventBroker
+}
+
+// NewEventsHandler creates a new events handler
+func NewEventsHandler(events *services.EventBroker) *EventsHandler {
+	return &EventsHandler{
+		events: events,
+	}
+}
+
+// Stream handles GET /api/events. Clients resume after a reconnect by sending
+// the Last-Event-ID header (or the lastEventId query parameter); if events
+// were missed, a "resync" event tells them to reload.
+func (h *EventsHandler) Stream(c *gin.Context) {
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("lastEventId")
+	}
+
+	events, replay, resync, cancel := h.events.Subscribe(lastEventID)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable nginx response buffering
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if resync {
+		fmt.Fprintf(w, "event: %s\ndata: {}\n\n", services.EventResync)
+	}
+	for _, event := range replay {
+		writeEvent(w, event)
+	}
+	w.Flush()
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				// Dropped for falling behind; the client reconnects and resumes
+				return
+			}
+			writeEvent(w, event)
+			w.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			w.Flush()
+		}
+	}
+}
+
+// writeEvent writes one event in the text/event-stream format
+func writeEvent(w gin.ResponseWriter, event services.Event) {
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
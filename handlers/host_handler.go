@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+	"monitoring/models"
+	"monitoring/services"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxPushBodySize limits the size of metrics pushed by agents
+	maxPushBodySize = 1 << 20
+
+	// defaultSampleWindow is how far back samples are returned by default
+	defaultSampleWindow = time.Hour
+)
+
+// HostHandler handles hosts running the monitoring agent and the metrics they push
+type HostHandler struct {
+	hosts *services.HostService
+}
+
+// NewHostHandler creates a new host handler
+func NewHostHandler(hosts *services.HostService) *HostHandler {
+	return &HostHandler{
+		hosts: hosts,
+	}
+}
+
+// Push handles POST /api/agent/push. Agents authenticate with their own
+// "Authorization: Bearer agt_..." token rather than a user session, and may
+// report their push interval in the X-Agent-Interval header (seconds).
+func (h *HostHandler) Push(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Agent token required"})
+		return
+	}
+
+	var metrics models.AgentMetrics
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPushBodySize)
+	if err := c.ShouldBindJSON(&metrics); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	interval, _ := strconv.Atoi(c.GetHeader("X-Agent-Interval"))
+	if _, err := h.hosts.Push(strings.TrimSpace(token), interval, &metrics); err != nil {
+		if errors.Is(err, services.ErrInvalidAgentToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid agent token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Register handles POST /api/agent/register, where agents report their host
+// facts. It authenticates like Push.
+func (h *HostHandler) Register(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Agent token required"})
+		return
+	}
+
+	var facts models.HostFacts
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPushBodySize)
+	if err := c.ShouldBindJSON(&facts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.hosts.Register(strings.TrimSpace(token), &facts); err != nil {
+		if errors.Is(err, services.ErrInvalidAgentToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid agent token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetAllHosts handles GET /api/hosts?status=<status>. Hosts are listed with
+// their facts and a summary of their latest metrics.
+func (h *HostHandler) GetAllHosts(c *gin.Context) {
+	status := models.HostStatus(c.Query("status"))
+	switch status {
+	case "", models.HostPending, models.HostUp, models.HostStale, models.HostDown:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, up, stale or down"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.hosts.GetAll(status))
+}
+
+// GetHost handles GET /api/hosts/:id, including the host's latest metrics
+func (h *HostHandler) GetHost(c *gin.Context) {
+	host, err := h.hosts.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Host not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, host)
+}
+
+// DeleteHost handles DELETE /api/hosts/:id
+func (h *HostHandler) DeleteHost(c *gin.Context) {
+	if err := h.hosts.Delete(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Host not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Host deleted successfully"})
+}
+
+// CreateHost handles POST /api/hosts. The agent token is only returned here.
+func (h *HostHandler) CreateHost(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	host, token, err := h.hosts.CreateHost(req.Name)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidHostName):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, models.ErrHostNameTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token": token,
+		"host":  host,
+	})
+}
+
+// GetHostSamples handles GET /api/hosts/:id/samples?since=<RFC 3339 time>,
+// returning the last hour by default
+func (h *HostHandler) GetHostSamples(c *gin.Context) {
+	since := time.Now().Add(-defaultSampleWindow)
+	if value := c.Query("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be an RFC 3339 time"})
+			return
+		}
+		since = parsed
+	}
+
+	samples, err := h.hosts.GetSamples(c.Param("id"), since)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Host not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, samples)
+}
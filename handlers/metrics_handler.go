@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"monitoring/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsHandler serves metrics in the Prometheus exposition format
+type MetricsHandler struct {
+	handler http.Handler
+}
+
+// NewMetricsHandler creates a metrics handler exposing the collector along
+// with the Go runtime and process metrics of the server
+func NewMetricsHandler(collector *services.MetricsCollector) *MetricsHandler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collector,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return &MetricsHandler{
+		handler: promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+			ErrorHandling: promhttp.ContinueOnError,
+		}),
+	}
+}
+
+// Metrics handles GET /metrics
+func (h *MetricsHandler) Metrics(c *gin.Context) {
+	h.handler.ServeHTTP(c.Writer, c.Request)
+}
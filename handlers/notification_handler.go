@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"monitoring/models"
+	"monitoring/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler handles HTTP requests for notification channels
+type NotificationHandler struct {
+	notifications *services.NotificationService
+}
+
+// NewNotificationHandler creates a new notification channel handler
+func NewNotificationHandler(notifications *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notifications: notifications,
+	}
+}
+
+// GetAllChannels handles GET /api/notifications/channels
+func (h *NotificationHandler) GetAllChannels(c *gin.Context) {
+	channels := h.notifications.GetAll()
+	if !canManageChannels(c) {
+		for _, channel := range channels {
+			hideChannelConfig(channel)
+		}
+	}
+	c.JSON(http.StatusOK, channels)
+}
+
+// GetChannel handles GET /api/notifications/channels/:id
+func (h *NotificationHandler) GetChannel(c *gin.Context) {
+	channel, err := h.notifications.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification channel not found"})
+		return
+	}
+	if !canManageChannels(c) {
+		hideChannelConfig(channel)
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+// canManageChannels reports whether the user may see channel settings
+func canManageChannels(c *gin.Context) bool {
+	user := currentUser(c)
+	return user != nil && user.Role.Allows(models.RoleAdmin)
+}
+
+// hideChannelConfig removes the destination settings of a (masked copy of a)
+// channel. URLs, headers, chat IDs and recipients may all be secrets; other
+// users only need a channel's name and type to pick it for a service.
+func hideChannelConfig(channel *models.NotificationChannel) {
+	channel.Telegram = nil
+	channel.Webhook = nil
+	channel.Email = nil
+}
+
+// CreateChannel handles POST /api/notifications/channels
+func (h *NotificationHandler) CreateChannel(c *gin.Context) {
+	var req models.NotificationChannel
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// IDs are always generated by the server
+	req.ID = ""
+
+	if err := h.notifications.Add(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, _ := h.notifications.Get(req.ID)
+	c.JSON(http.StatusCreated, channel)
+}
+
+// UpdateChannel handles PUT /api/notifications/channels/:id
+func (h *NotificationHandler) UpdateChannel(c *gin.Context) {
+	var req models.NotificationChannel
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.ID = c.Param("id")
+
+	if err := h.notifications.Update(&req); err != nil {
+		if errors.Is(err, services.ErrChannelNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification channel not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, _ := h.notifications.Get(req.ID)
+	c.JSON(http.StatusOK, channel)
+}
+
+// DeleteChannel handles DELETE /api/notifications/channels/:id
+func (h *NotificationHandler) DeleteChannel(c *gin.Context) {
+	if err := h.notifications.Delete(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification channel not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification channel deleted successfully"})
+}
+
+// TestChannel handles POST /api/notifications/channels/:id/test
+func (h *NotificationHandler) TestChannel(c *gin.Context) {
+	if err := h.notifications.Test(c.Param("id")); err != nil {
+		if errors.Is(err, services.ErrChannelNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification channel not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to send test message",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Test notification sent successfully",
+	})
+}
+
+// GetDeliveries handles GET /api/notifications/channels/:id/deliveries
+func (h *NotificationHandler) GetDeliveries(c *gin.Context) {
+	deliveries, err := h.notifications.GetDeliveries(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification channel not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// TestChannelConfig handles POST /api/notifications/test, sending a test message
+// through a channel configuration without saving it
+func (h *NotificationHandler) TestChannelConfig(c *gin.Context) {
+	var req models.NotificationChannel
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.notifications.TestConfig(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to send test message",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Test notification sent successfully",
+	})
+}
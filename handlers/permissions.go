@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"monitoring/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeRoles is the minimum role needed for each API route, keyed by
+// "METHOD /full/path". Routes missing from the table require an admin, so a
+// new endpoint is never accidentally open to everyone.
+var routeRoles = map[string]models.Role{
+	// Own account
+	"POST /api/auth/logout":       models.RoleViewer,
+	"GET /api/auth/me":            models.RoleViewer,
+	"PUT /api/auth/password":      models.RoleViewer,
+	"GET /api/auth/tokens":        models.RoleViewer,
+	"POST /api/auth/tokens":       models.RoleViewer,
+	"DELETE /api/auth/tokens/:id": models.RoleViewer,
+
+	// Users
+	"GET /api/users":              models.RoleAdmin,
+	"POST /api/users":             models.RoleAdmin,
+	"PUT /api/users/:id/role":     models.RoleAdmin,
+	"PUT /api/users/:id/password": models.RoleAdmin,
+	"DELETE /api/users/:id":       models.RoleAdmin,
+
+	// Services. Viewers get them without request headers and body.
+	"GET /api/services":                models.RoleViewer,
+	"GET /api/services/:id":            models.RoleViewer,
+	"GET /api/services/:id/statistics": models.RoleViewer,
+	"GET /api/services/:id/history":    models.RoleViewer,
+	"POST /api/services":               models.RoleOperator,
+	"PUT /api/services/:id":            models.RoleOperator,
+	"POST /api/services/:id/check":     models.RoleOperator,
+	"DELETE /api/services/:id":         models.RoleAdmin,
+	"POST /api/services/:id/badge":     models.RoleOperator,
+	"DELETE /api/services/:id/badge":   models.RoleOperator,
+
+	// Notifications. Non-admins only get channel names and types, to pick
+	// channels for services.
+	"GET /api/notifications/channels":                models.RoleViewer,
+	"GET /api/notifications/channels/:id":            models.RoleViewer,
+	"POST /api/notifications/channels":               models.RoleAdmin,
+	"PUT /api/notifications/channels/:id":            models.RoleAdmin,
+	"DELETE /api/notifications/channels/:id":         models.RoleAdmin,
+	"POST /api/notifications/channels/:id/test":      models.RoleAdmin,
+	"GET /api/notifications/channels/:id/deliveries": models.RoleAdmin,
+	"POST /api/notifications/test":                   models.RoleAdmin,
+	"GET /api/telegram/config":                       models.RoleAdmin,
+	"PUT /api/telegram/config":                       models.RoleAdmin,
+	"POST /api/telegram/test":                        models.RoleAdmin,
+
+	// Hosts
+	"GET /api/hosts":             models.RoleViewer,
+	"GET /api/hosts/:id":         models.RoleViewer,
+	"GET /api/hosts/:id/samples": models.RoleViewer,
+	"POST /api/hosts":            models.RoleAdmin,
+	"DELETE /api/hosts/:id":      models.RoleAdmin,
+
+	// Live updates
+	"GET /api/events": models.RoleViewer,
+
+	// Status page
+	"GET /api/status-page": models.RoleAdmin,
+	"PUT /api/status-page": models.RoleAdmin,
+
+	// System
+	"GET /api/system/info":         models.RoleViewer,
+	"GET /api/system/alert-config": models.RoleAdmin,
+	"PUT /api/system/alert-config": models.RoleAdmin,
+
+	// Prometheus metrics (outside /api, so scrapers can use the default path)
+	"GET /metrics": models.RoleViewer,
+}
+
+// RequiredRole returns the minimum role for a route, defaulting to admin
+func RequiredRole(method, path string) models.Role {
+	if role, ok := routeRoles[method+" "+path]; ok {
+		return role
+	}
+	return models.RoleAdmin
+}
+
+// Authorize rejects requests from users whose role is below the route's
+// required role. It must run after RequireAuth.
+func Authorize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		required := RequiredRole(c.Request.Method, c.FullPath())
+
+		user := currentUser(c)
+		if user == nil || !user.Role.Allows(required) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":         "Insufficient permissions",
+				"required_role": required,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
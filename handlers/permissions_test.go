@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"monitoring/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequiredRole(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   models.Role
+	}{
+		{http.MethodGet, "/api/auth/me", models.RoleViewer},
+		{http.MethodGet, "/api/services", models.RoleViewer},
+		{http.MethodPost, "/api/services", models.RoleOperator},
+		{http.MethodPut, "/api/services/:id", models.RoleOperator},
+		{http.MethodDelete, "/api/services/:id", models.RoleAdmin},
+		{http.MethodGet, "/api/notifications/channels", models.RoleViewer},
+		{http.MethodPost, "/api/notifications/channels", models.RoleAdmin},
+		{http.MethodGet, "/api/system/info", models.RoleViewer},
+		{http.MethodGet, "/api/system/alert-config", models.RoleAdmin},
+		{http.MethodPut, "/api/system/alert-config", models.RoleAdmin},
+		{http.MethodGet, "/metrics", models.RoleViewer},
+		{http.MethodGet, "/api/users", models.RoleAdmin},
+		// Unlisted routes and methods require an admin
+		{http.MethodGet, "/api/new-endpoint", models.RoleAdmin},
+		{http.MethodPatch, "/api/services/:id", models.RoleAdmin},
+		{http.MethodGet, "/api/services/123", models.RoleAdmin},
+	}
+
+	for _, test := range tests {
+		if got := RequiredRole(test.method, test.path); got != test.want {
+			t.Errorf("RequiredRole(%s, %s) = %s, want %s", test.method, test.path, got, test.want)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		user   *models.User
+		method string
+		path   string
+		want   int
+	}{
+		{name: "viewer reads services", user: &models.User{Role: models.RoleViewer}, method: http.MethodGet, path: "/api/services/abc", want: http.StatusOK},
+		{name: "viewer cannot edit services", user: &models.User{Role: models.RoleViewer}, method: http.MethodPut, path: "/api/services/abc", want: http.StatusForbidden},
+		{name: "operator edits services", user: &models.User{Role: models.RoleOperator}, method: http.MethodPut, path: "/api/services/abc", want: http.StatusOK},
+		{name: "operator cannot delete services", user: &models.User{Role: models.RoleOperator}, method: http.MethodDelete, path: "/api/services/abc", want: http.StatusForbidden},
+		{name: "admin deletes services", user: &models.User{Role: models.RoleAdmin}, method: http.MethodDelete, path: "/api/services/abc", want: http.StatusOK},
+		{name: "operator cannot change alert thresholds", user: &models.User{Role: models.RoleOperator}, method: http.MethodPut, path: "/api/system/alert-config", want: http.StatusForbidden},
+		{name: "admin changes alert thresholds", user: &models.User{Role: models.RoleAdmin}, method: http.MethodPut, path: "/api/system/alert-config", want: http.StatusOK},
+		{name: "operator cannot use an unlisted route", user: &models.User{Role: models.RoleOperator}, method: http.MethodGet, path: "/api/unlisted", want: http.StatusForbidden},
+		{name: "no user", method: http.MethodGet, path: "/api/services/abc", want: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if test.user != nil {
+					c.Set(userContextKey, test.user)
+				}
+			}, Authorize())
+			ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+			router.GET("/api/services/:id", ok)
+			router.PUT("/api/services/:id", ok)
+			router.DELETE("/api/services/:id", ok)
+			router.PUT("/api/system/alert-config", ok)
+			router.GET("/api/unlisted", ok)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, nil))
+			if recorder.Code != test.want {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, test.want, recorder.Body)
+			}
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"monitoring/models"
 	"monitoring/services"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -38,8 +41,18 @@ func (h *ServiceHandler) CreateService(c *gin.Context) {
 	// Set defaults
 	req.ID = uuid.New().String()
 	req.Status = models.StatusUnknown
+	req.ConfirmedStatus = models.StatusUnknown
+	req.ConsecutiveFailures = 0
+	req.ConsecutiveSuccesses = 0
+	req.BadgeToken = ""
+	req.Tags = models.NormalizeTags(req.Tags)
 	req.CreatedAt = time.Now()
 
+	if err := services.ValidateService(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	if req.CheckInterval == 0 {
 		req.CheckInterval = 60 // default to 60 seconds
 	}
@@ -54,7 +67,7 @@ func (h *ServiceHandler) CreateService(c *gin.Context) {
 	}
 
 	// Perform initial health check
-	result := h.monitor.CheckService(&req)
+	result := h.monitor.RunCheck(&req)
 	if err := h.monitor.UpdateServiceStatus(result); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -69,6 +82,11 @@ func (h *ServiceHandler) CreateService(c *gin.Context) {
 // GetAllServices handles GET /api/services
 func (h *ServiceHandler) GetAllServices(c *gin.Context) {
 	services := h.store.GetAll()
+	if !canEditServices(c) {
+		for i, service := range services {
+			services[i] = service.Redacted()
+		}
+	}
 	c.JSON(http.StatusOK, services)
 }
 
@@ -81,16 +99,25 @@ func (h *ServiceHandler) GetService(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
 		return
 	}
+	if !canEditServices(c) {
+		service = service.Redacted()
+	}
 
 	c.JSON(http.StatusOK, service)
 }
 
+// canEditServices reports whether the user may see a service's full
+// configuration, including request headers and body
+func canEditServices(c *gin.Context) bool {
+	user := currentUser(c)
+	return user != nil && user.Role.Allows(models.RoleOperator)
+}
+
 // UpdateService handles PUT /api/services/:id
 func (h *ServiceHandler) UpdateService(c *gin.Context) {
 	id := c.Param("id")
 
-	existing, err := h.store.Get(id)
-	if err != nil {
+	if _, err := h.store.Get(id); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
 		return
 	}
@@ -100,19 +127,32 @@ func (h *ServiceHandler) UpdateService(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	req.Tags = models.NormalizeTags(req.Tags)
 
-	// Preserve certain fields
-	req.ID = existing.ID
-	req.CreatedAt = existing.CreatedAt
-	req.Status = existing.Status
-	req.LastCheck = existing.LastCheck
+	if err := services.ValidateService(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	if err := h.store.Update(&req); err != nil {
+	// Preserve certain fields, as they are when the edit is stored, so a check
+	// finishing meanwhile isn't undone
+	updated, err := h.store.Modify(id, func(existing *models.MonitoredService) {
+		req.ID = existing.ID
+		req.CreatedAt = existing.CreatedAt
+		req.Status = existing.Status
+		req.LastCheck = existing.LastCheck
+		req.ConfirmedStatus = existing.ConfirmedStatus
+		req.ConsecutiveFailures = existing.ConsecutiveFailures
+		req.ConsecutiveSuccesses = existing.ConsecutiveSuccesses
+		req.BadgeToken = existing.BadgeToken
+		*existing = req
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, &req)
+	c.JSON(http.StatusOK, updated)
 }
 
 // DeleteService handles DELETE /api/services/:id
@@ -140,7 +180,7 @@ func (h *ServiceHandler) CheckServiceNow(c *gin.Context) {
 		return
 	}
 
-	result := h.monitor.CheckService(service)
+	result := h.monitor.RunCheck(service)
 	if err := h.monitor.UpdateServiceStatus(result); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -152,7 +192,7 @@ func (h *ServiceHandler) CheckServiceNow(c *gin.Context) {
 	c.JSON(http.StatusOK, service)
 }
 
-// GetServiceStatistics handles GET /api/services/:id/statistics
+// GetServiceStatistics handles GET /api/services/:id/statistics?from=&to=
 func (h *ServiceHandler) GetServiceStatistics(c *gin.Context) {
 	id := c.Param("id")
 
@@ -163,11 +203,21 @@ func (h *ServiceHandler) GetServiceStatistics(c *gin.Context) {
 		return
 	}
 
-	stats := h.history.GetStatistics(id)
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, err := h.history.GetStatistics(id, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, stats)
 }
 
-// GetServiceHistory handles GET /api/services/:id/history
+// GetServiceHistory handles GET /api/services/:id/history?from=&to=&resolution=
 func (h *ServiceHandler) GetServiceHistory(c *gin.Context) {
 	id := c.Param("id")
 
@@ -178,14 +228,67 @@ func (h *ServiceHandler) GetServiceHistory(c *gin.Context) {
 		return
 	}
 
-	history := h.history.GetHistory(id)
-	if history == nil {
-		c.JSON(http.StatusOK, &models.ServiceHistory{
-			ServiceID: id,
-			Checks:    []models.HealthCheckRecord{},
-		})
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	history := &models.ServiceHistory{
+		ServiceID:  id,
+		From:       from,
+		To:         to,
+		Resolution: c.DefaultQuery("resolution", "raw"),
+		Checks:     []models.HealthCheckRecord{},
+	}
+
+	if history.Resolution == "raw" {
+		history.Checks, err = h.history.GetHistory(id, from, to)
+	} else {
+		history.Rollups, err = h.history.GetRollups(id, history.Resolution, from, to)
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidResolution) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, history)
 }
+
+// parseTimeRange reads the from and to query parameters as RFC 3339 times or
+// Unix seconds. The range defaults to the 24 hours before to, which defaults to now.
+func parseTimeRange(c *gin.Context) (time.Time, time.Time, error) {
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		parsed, err := parseQueryTime(value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %v", err)
+		}
+		to = parsed
+	}
+
+	from := to.Add(-24 * time.Hour)
+	if value := c.Query("from"); value != "" {
+		parsed, err := parseQueryTime(value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %v", err)
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+	return from, to, nil
+}
+
+func parseQueryTime(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"monitoring/models"
+	"monitoring/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatusPageHandler serves the public status page and its configuration
+type StatusPageHandler struct {
+	statusPage *services.StatusPageService
+}
+
+// NewStatusPageHandler creates a new status page handler
+func NewStatusPageHandler(statusPage *services.StatusPageService) *StatusPageHandler {
+	return &StatusPageHandler{
+		statusPage: statusPage,
+	}
+}
+
+// Page handles GET /status. The page loads its data from the JSON feed.
+func (h *StatusPageHandler) Page(c *gin.Context) {
+	if !h.statusPage.GetConfig().Enabled {
+		c.String(http.StatusNotFound, "404 page not found")
+		return
+	}
+	c.HTML(http.StatusOK, "status.html", nil)
+}
+
+// Feed handles GET /status.json, the public status page data for embedding
+func (h *StatusPageHandler) Feed(c *gin.Context) {
+	page, err := h.statusPage.GetPage()
+	if err != nil {
+		if errors.Is(err, services.ErrStatusPageDisabled) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Status page is disabled"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build status page"})
+		return
+	}
+
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Header("Cache-Control", "public, max-age=30")
+	c.JSON(http.StatusOK, page)
+}
+
+// GetConfig handles GET /api/status-page
+func (h *StatusPageHandler) GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, h.statusPage.GetConfig())
+}
+
+// UpdateConfig handles PUT /api/status-page
+func (h *StatusPageHandler) UpdateConfig(c *gin.Context) {
+	var config models.StatusPageConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.statusPage.UpdateConfig(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.statusPage.GetConfig())
+}
@@ -1,13 +1,14 @@
 package handlers
 
 import (
+	"monitoring/models"
 	"monitoring/services"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-// SystemHandler handles HTTP requests for system information
+// SystemHandler handles HTTP requests for system information and alert thresholds
 type SystemHandler struct {
 	system *services.SystemService
 }
@@ -29,3 +30,24 @@ func (h *SystemHandler) GetSystemInfo(c *gin.Context) {
 
 	c.JSON(http.StatusOK, info)
 }
+
+// GetAlertConfig handles GET /api/system/alert-config
+func (h *SystemHandler) GetAlertConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, h.system.GetAlertConfig())
+}
+
+// UpdateAlertConfig handles PUT /api/system/alert-config
+func (h *SystemHandler) UpdateAlertConfig(c *gin.Context) {
+	var config models.SystemAlertConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.system.UpdateAlertConfig(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.system.GetAlertConfig())
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"monitoring/models"
 	"monitoring/services"
 	"net/http"
@@ -8,22 +9,22 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// TelegramHandler handles HTTP requests for Telegram configuration
+// TelegramHandler handles HTTP requests for Telegram configuration.
+// It manages the default Telegram notification channel for backward compatibility.
 type TelegramHandler struct {
-	telegram *services.TelegramService
+	notifications *services.NotificationService
 }
 
 // NewTelegramHandler creates a new Telegram handler
-func NewTelegramHandler(telegram *services.TelegramService) *TelegramHandler {
+func NewTelegramHandler(notifications *services.NotificationService) *TelegramHandler {
 	return &TelegramHandler{
-		telegram: telegram,
+		notifications: notifications,
 	}
 }
 
 // GetConfig handles GET /api/telegram/config
 func (h *TelegramHandler) GetConfig(c *gin.Context) {
-	config := h.telegram.GetConfig()
-	c.JSON(http.StatusOK, config)
+	c.JSON(http.StatusOK, h.currentConfig())
 }
 
 // UpdateConfig handles PUT /api/telegram/config
@@ -35,17 +36,33 @@ func (h *TelegramHandler) UpdateConfig(c *gin.Context) {
 		return
 	}
 
-	h.telegram.SetConfig(&req)
+	channel := &models.NotificationChannel{
+		ID:       services.LegacyTelegramChannelID,
+		Name:     "Telegram",
+		Type:     models.ChannelTelegram,
+		Enabled:  req.Enabled,
+		Default:  true,
+		Telegram: &models.TelegramConfig{BotToken: req.BotToken, ChatID: req.ChatID},
+	}
+
+	err := h.notifications.Update(channel)
+	if errors.Is(err, services.ErrChannelNotFound) {
+		err = h.notifications.Add(channel)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Telegram configuration updated successfully",
-		"config":  h.telegram.GetConfig(),
+		"config":  h.currentConfig(),
 	})
 }
 
 // TestNotification handles POST /api/telegram/test
 func (h *TelegramHandler) TestNotification(c *gin.Context) {
-	if err := h.telegram.SendTestMessage(); err != nil {
+	if err := h.notifications.Test(services.LegacyTelegramChannelID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to send test message",
 			"details": err.Error(),
@@ -57,3 +74,17 @@ func (h *TelegramHandler) TestNotification(c *gin.Context) {
 		"message": "Test notification sent successfully",
 	})
 }
+
+// currentConfig returns the default Telegram channel in the legacy config shape
+func (h *TelegramHandler) currentConfig() *models.TelegramConfig {
+	channel, err := h.notifications.Get(services.LegacyTelegramChannelID)
+	if err != nil || channel.Telegram == nil {
+		return &models.TelegramConfig{Enabled: false}
+	}
+
+	return &models.TelegramConfig{
+		BotToken: channel.Telegram.BotToken,
+		ChatID:   channel.Telegram.ChatID,
+		Enabled:  channel.Enabled,
+	}
+}
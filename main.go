@@ -7,7 +7,9 @@ import (
 	"monitoring/services"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,42 +19,82 @@ func main() {
 	dataFile := "monitoring_data.json"
 	persistence := models.NewPersistenceManager(dataFile)
 
-	// Load existing data
+	// Load existing data. An unreadable file must not be replaced by an empty
+	// one (with a new admin account), so refuse to start instead.
 	appData, err := persistence.Load()
 	if err != nil {
-		fmt.Printf("Warning: Could not load existing data: %v\n", err)
-		appData = &models.AppData{
-			Services:   make(map[string]*models.MonitoredService),
-			Histories:  make(map[string]*models.ServiceHistory),
-			TelegramConfig: &models.TelegramConfig{
-				Enabled: false,
-			},
-		}
+		fmt.Printf("Error loading %s: %v\n", dataFile, err)
+		fmt.Println("Fix or move the file away to start with empty data.")
+		os.Exit(1)
 	}
 
 	// Initialize store and load data
 	store := models.NewServiceStore()
 	store.LoadFromMap(appData.Services)
 
-	// Initialize history store and load data
-	historyStore := models.NewHistoryStore(100) // Keep last 100 checks per service
-	historyStore.LoadFromMap(appData.Histories)
+	// Initialize the on-disk history store (HISTORY_DIR, retention in days via
+	// HISTORY_RAW_RETENTION_DAYS, HISTORY_MINUTE_RETENTION_DAYS and HISTORY_HOUR_RETENTION_DAYS)
+	historyDir := os.Getenv("HISTORY_DIR")
+	if historyDir == "" {
+		historyDir = "history"
+	}
+	historyStore, err := models.NewHistoryStore(historyDir, models.HistoryRetention{
+		Raw:    retentionFromEnv("HISTORY_RAW_RETENTION_DAYS"),
+		Minute: retentionFromEnv("HISTORY_MINUTE_RETENTION_DAYS"),
+		Hour:   retentionFromEnv("HISTORY_HOUR_RETENTION_DAYS"),
+	})
+	if err != nil {
+		fmt.Printf("Error opening history store %s: %v\n", historyDir, err)
+		os.Exit(1)
+	}
+	importedHistories := historyStore.ImportLegacy(appData.Histories)
+
+	// Initialize notification channels and migrate legacy Telegram settings
+	notifications := services.NewNotificationService()
+	notifications.LoadChannels(appData.NotificationChannels)
+	migrated := notifications.MigrateLegacyTelegram(appData.TelegramConfig, store.GetAll())
+
+	// Initialize user accounts and create the first admin if there are none
+	// (ADMIN_USERNAME, ADMIN_PASSWORD; a random password is printed if unset)
+	userStore := models.NewUserStore()
+	userStore.LoadFromMap(appData.Users, appData.APITokens)
+	authService := services.NewAuthService(userStore)
+	adminUsername := os.Getenv("ADMIN_USERNAME")
+	if adminUsername == "" {
+		adminUsername = "admin"
+	}
+	bootstrapped, adminPassword, err := authService.Bootstrap(adminUsername, os.Getenv("ADMIN_PASSWORD"))
+	if err != nil {
+		fmt.Printf("Error creating admin account: %v\n", err)
+		os.Exit(1)
+	}
 
-	// Initialize Telegram service and load config
-	telegram := services.NewTelegramService()
-	telegram.LoadConfig(appData.TelegramConfig)
+	// Initialize incidents and the public status page
+	incidentStore := models.NewIncidentStore()
+	incidentStore.LoadFromMap(appData.Incidents)
+	statusPageService := services.NewStatusPageService(store, historyStore, incidentStore)
+	statusPageService.LoadConfig(appData.StatusPage)
+
+	// Initialize hosts that push metrics from the monitoring agent
+	hostStore := models.NewHostStore()
+	hostStore.LoadFromMap(appData.Hosts)
+	hostService := services.NewHostService(hostStore)
 
 	// Initialize system service early
-	systemService := services.NewSystemService(telegram)
+	systemService := services.NewSystemService(notifications)
 	systemService.LoadAlertConfig(appData.SystemAlertConfig)
 
 	// Setup auto-save callback
 	saveData := func() {
 		data := &models.AppData{
-			Services:          store.GetAllAsMap(),
-			Histories:         historyStore.GetAllAsMap(),
-			TelegramConfig:    telegram.GetRawConfig(),
-			SystemAlertConfig: systemService.GetAlertConfig(),
+			Services:             store.GetAllAsMap(),
+			NotificationChannels: notifications.GetAllAsMap(),
+			SystemAlertConfig:    systemService.GetAlertConfig(),
+			Users:                userStore.GetAllAsMap(),
+			APITokens:            userStore.GetTokensAsMap(),
+			StatusPage:           statusPageService.GetConfig(),
+			Incidents:            incidentStore.GetAllAsMap(),
+			Hosts:                hostStore.GetAllAsMap(),
 		}
 		if err := persistence.Save(data); err != nil {
 			fmt.Printf("Error saving data: %v\n", err)
@@ -61,22 +103,70 @@ func main() {
 
 	// Set persistence callbacks
 	store.SetPersistence(persistence, saveData)
-	telegram.SetOnSave(saveData)
+	notifications.SetOnSave(saveData)
+	userStore.SetOnSave(saveData)
+	incidentStore.SetOnSave(saveData)
+	statusPageService.SetOnSave(saveData)
+	hostStore.SetOnSave(saveData)
+	systemService.SetOnSave(saveData)
+	if migrated {
+		fmt.Println("📨 Migrated Telegram settings to notification channels")
+	}
+	if importedHistories > 0 {
+		fmt.Printf("📥 Imported history of %d service(s) into %s\n", importedHistories, historyDir)
+	}
+	if bootstrapped {
+		fmt.Printf("🔐 Created admin account %q\n", adminUsername)
+		if os.Getenv("ADMIN_PASSWORD") == "" {
+			fmt.Printf("🔑 Generated password: %s (change it after signing in)\n", adminPassword)
+		}
+	}
+	if migrated || bootstrapped || len(appData.Histories) > 0 {
+		saveData()
+	}
 
 	// Initialize monitor service
-	monitor := services.NewMonitorService(store, historyStore, telegram)
+	monitor := services.NewMonitorService(store, historyStore, incidentStore, notifications)
 
-	// Initialize scheduler
-	scheduler := services.NewScheduler(monitor)
+	// Initialize scheduler (MAX_CONCURRENT_CHECKS bounds parallel checks)
+	maxWorkers, _ := strconv.Atoi(os.Getenv("MAX_CONCURRENT_CHECKS"))
+	scheduler := services.NewScheduler(monitor, store, maxWorkers)
 	scheduler.Start()
 	defer scheduler.Stop()
 
+	// Stream service changes, check results and system alerts to dashboards
+	events := services.NewEventBroker()
+	store.Subscribe(events.PublishServiceEvent)
+	monitor.Subscribe(func(result *models.HealthCheckResult) {
+		events.Publish(services.EventServiceCheck, result)
+	})
+	systemService.SubscribeAlerts(func(alert models.SystemAlert) {
+		events.Publish(services.EventSystemAlert, alert)
+	})
+	hostService.Subscribe(func(host *models.Host) {
+		events.Publish(services.EventHostUpdated, host)
+	})
+	hostService.SubscribeDeleted(func(id string) {
+		events.Publish(services.EventHostDeleted, map[string]string{"id": id})
+	})
+	hostService.Start()
+	go streamSystemInfo(systemService, events)
+	go systemService.WatchAlerts(30 * time.Second)
+
 	// Initialize handlers
 	serviceHandler := handlers.NewServiceHandler(store, historyStore, monitor)
-	telegramHandler := handlers.NewTelegramHandler(telegram)
+	telegramHandler := handlers.NewTelegramHandler(notifications)
+	notificationHandler := handlers.NewNotificationHandler(notifications)
 	systemHandler := handlers.NewSystemHandler(systemService)
+	authHandler := handlers.NewAuthHandler(authService, userStore)
+	statusPageHandler := handlers.NewStatusPageHandler(statusPageService)
+	badgeHandler := handlers.NewBadgeHandler(services.NewBadgeService(store, historyStore))
+	eventsHandler := handlers.NewEventsHandler(events)
+	hostHandler := handlers.NewHostHandler(hostService)
+	metricsHandler := handlers.NewMetricsHandler(services.NewMetricsCollector(store, monitor, scheduler, notifications, systemService))
 
 	fmt.Printf("💾 Data will be saved to: %s\n", dataFile)
+	fmt.Printf("📈 Check history will be saved to: %s\n", historyDir)
 	if len(appData.Services) > 0 {
 		fmt.Printf("📥 Loaded %d service(s) from disk\n", len(appData.Services))
 	}
@@ -87,14 +177,49 @@ func main() {
 	// Load HTML templates
 	router.LoadHTMLGlob("templates/*")
 
+	// Login, the status page and badges are the only public routes. Agents
+	// register and push with their own token.
+	router.GET("/login", authHandler.LoginPage)
+	router.POST("/api/auth/login", authHandler.Login)
+	router.GET("/status", statusPageHandler.Page)
+	router.GET("/status.json", statusPageHandler.Feed)
+	router.GET("/badge/:token/status.svg", badgeHandler.StatusBadge)
+	router.GET("/badge/:token/uptime.svg", badgeHandler.UptimeBadge)
+	router.GET("/badge/:token/response-time.svg", badgeHandler.ResponseTimeBadge)
+	router.POST("/api/agent/push", hostHandler.Push)
+	router.POST("/api/agent/register", hostHandler.Register)
+
+	// Prometheus metrics need a viewer session or API token, unless
+	// METRICS_PUBLIC=true (e.g. when /metrics is only reachable internally)
+	if os.Getenv("METRICS_PUBLIC") == "true" {
+		router.GET("/metrics", metricsHandler.Metrics)
+	} else {
+		router.GET("/metrics", authHandler.RequireAuth(), handlers.Authorize(), metricsHandler.Metrics)
+	}
+
 	// Serve dashboard
-	router.GET("/", func(c *gin.Context) {
+	router.GET("/", authHandler.RequireLogin(), func(c *gin.Context) {
 		c.HTML(200, "index.html", nil)
 	})
 
-	// API routes
-	api := router.Group("/api")
+	// API routes (session cookie or "Authorization: Bearer <API token>")
+	api := router.Group("/api", authHandler.RequireAuth(), handlers.Authorize())
 	{
+		// Account endpoints
+		api.POST("/auth/logout", authHandler.Logout)
+		api.GET("/auth/me", authHandler.GetCurrentUser)
+		api.PUT("/auth/password", authHandler.ChangePassword)
+		api.GET("/auth/tokens", authHandler.GetTokens)
+		api.POST("/auth/tokens", authHandler.CreateToken)
+		api.DELETE("/auth/tokens/:id", authHandler.DeleteToken)
+
+		// User management endpoints
+		api.GET("/users", authHandler.GetAllUsers)
+		api.POST("/users", authHandler.CreateUser)
+		api.PUT("/users/:id/role", authHandler.SetUserRole)
+		api.PUT("/users/:id/password", authHandler.ResetPassword)
+		api.DELETE("/users/:id", authHandler.DeleteUser)
+
 		// Service endpoints
 		api.GET("/services", serviceHandler.GetAllServices)
 		api.GET("/services/:id", serviceHandler.GetService)
@@ -104,14 +229,42 @@ func main() {
 		api.POST("/services/:id/check", serviceHandler.CheckServiceNow)
 		api.GET("/services/:id/statistics", serviceHandler.GetServiceStatistics)
 		api.GET("/services/:id/history", serviceHandler.GetServiceHistory)
+		api.POST("/services/:id/badge", badgeHandler.EnableBadges)
+		api.DELETE("/services/:id/badge", badgeHandler.DisableBadges)
+
+		// Host endpoints (agents in push mode)
+		api.GET("/hosts", hostHandler.GetAllHosts)
+		api.POST("/hosts", hostHandler.CreateHost)
+		api.GET("/hosts/:id", hostHandler.GetHost)
+		api.DELETE("/hosts/:id", hostHandler.DeleteHost)
+		api.GET("/hosts/:id/samples", hostHandler.GetHostSamples)
 
 		// Telegram endpoints
 		api.GET("/telegram/config", telegramHandler.GetConfig)
 		api.PUT("/telegram/config", telegramHandler.UpdateConfig)
 		api.POST("/telegram/test", telegramHandler.TestNotification)
 
+		// Notification channel endpoints
+		api.GET("/notifications/channels", notificationHandler.GetAllChannels)
+		api.GET("/notifications/channels/:id", notificationHandler.GetChannel)
+		api.POST("/notifications/channels", notificationHandler.CreateChannel)
+		api.PUT("/notifications/channels/:id", notificationHandler.UpdateChannel)
+		api.DELETE("/notifications/channels/:id", notificationHandler.DeleteChannel)
+		api.POST("/notifications/channels/:id/test", notificationHandler.TestChannel)
+		api.GET("/notifications/channels/:id/deliveries", notificationHandler.GetDeliveries)
+		api.POST("/notifications/test", notificationHandler.TestChannelConfig)
+
+		// Status page configuration
+		api.GET("/status-page", statusPageHandler.GetConfig)
+		api.PUT("/status-page", statusPageHandler.UpdateConfig)
+
+		// Live updates (Server-Sent Events)
+		api.GET("/events", eventsHandler.Stream)
+
 		// System endpoints
 		api.GET("/system/info", systemHandler.GetSystemInfo)
+		api.GET("/system/alert-config", systemHandler.GetAlertConfig)
+		api.PUT("/system/alert-config", systemHandler.UpdateAlertConfig)
 	}
 
 	// Handle graceful shutdown
@@ -139,3 +292,28 @@ func main() {
 		fmt.Printf("Error starting server: %v\n", err)
 	}
 }
+
+// streamSystemInfo publishes system info while dashboards are connected
+func streamSystemInfo(systemService *services.SystemService, events *services.EventBroker) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if events.SubscriberCount() == 0 {
+			continue
+		}
+		info, err := systemService.GetSystemInfo()
+		if err != nil {
+			fmt.Printf("Error reading system info: %v\n", err)
+			continue
+		}
+		events.Publish(services.EventSystemInfo, info)
+	}
+}
+
+// retentionFromEnv reads a retention period in days from an environment variable.
+// Zero (unset or invalid) selects the store's default.
+func retentionFromEnv(name string) time.Duration {
+	days, _ := strconv.Atoi(os.Getenv(name))
+	return time.Duration(days) * 24 * time.Hour
+}
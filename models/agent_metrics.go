@@ -0,0 +1,271 @@
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AgentMetrics is the metrics snapshot reported by the monitoring agent
+// (MetricsResponse in agent/main.go). The JSON layout must match the agent's.
+type AgentMetrics struct {
+	Hostname   string                 `json:"hostname"`
+	Timestamp  time.Time              `json:"timestamp"`
+	Status     string                 `json:"status"`
+	CPU        AgentCPUMetrics        `json:"cpu"`
+	Memory     AgentMemoryMetrics     `json:"memory"`
+	Disk       []AgentDiskMetrics     `json:"disk"`
+	DiskIO     []AgentDiskIOMetrics   `json:"disk_io,omitempty"`
+	RAID       []AgentRAIDMetrics     `json:"raid,omitempty"`
+	Network    AgentNetworkMetrics    `json:"network"`
+	Pressure   *AgentPressure         `json:"pressure,omitempty"`
+	Services   []AgentServiceMetric   `json:"services,omitempty"`
+	Ports      []AgentPortMetric      `json:"ports,omitempty"`
+	TopCPU     []AgentProcessMetric   `json:"top_cpu,omitempty"`
+	TopMemory  []AgentProcessMetric   `json:"top_memory,omitempty"`
+	Containers []AgentContainerMetric `json:"containers,omitempty"`
+}
+
+// AgentCPUMetrics holds an agent's CPU usage
+type AgentCPUMetrics struct {
+	UsagePercent float64 `json:"usage_percent"`
+	Cores        int     `json:"cores"`
+	AgentCPUTimes
+	LoadAverage *AgentLoadAverage  `json:"load_average,omitempty"`
+	PerCore     []AgentCoreMetrics `json:"per_core,omitempty"`
+}
+
+// AgentCPUTimes splits CPU time by mode, in percent
+type AgentCPUTimes struct {
+	User    float64 `json:"user_percent"`
+	Nice    float64 `json:"nice_percent"`
+	System  float64 `json:"system_percent"`
+	Idle    float64 `json:"idle_percent"`
+	IOWait  float64 `json:"iowait_percent"`
+	IRQ     float64 `json:"irq_percent"`
+	SoftIRQ float64 `json:"softirq_percent"`
+	Steal   float64 `json:"steal_percent"`
+}
+
+// AgentCoreMetrics holds the usage of one logical core
+type AgentCoreMetrics struct {
+	Core         int     `json:"core"`
+	UsagePercent float64 `json:"usage_percent"`
+	AgentCPUTimes
+}
+
+// AgentLoadAverage holds the 1, 5 and 15-minute load averages
+type AgentLoadAverage struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+}
+
+// UnmarshalJSON also accepts the "1m 5m 15m" string older agents report
+func (l *AgentLoadAverage) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		type plain AgentLoadAverage // Without this method
+		return json.Unmarshal(data, (*plain)(l))
+	}
+
+	fields := strings.Fields(text)
+	values := []*float64{&l.Load1, &l.Load5, &l.Load15}
+	for i := range min(len(fields), len(values)) {
+		*values[i], _ = strconv.ParseFloat(fields[i], 64)
+	}
+	return nil
+}
+
+// Load1 returns the 1-minute load average, or 0 if it is unknown
+func (c AgentCPUMetrics) Load1() float64 {
+	if c.LoadAverage == nil {
+		return 0
+	}
+	return c.LoadAverage.Load1
+}
+
+// AgentMemoryMetrics holds an agent's memory and swap usage
+type AgentMemoryMetrics struct {
+	TotalMB        uint64  `json:"total_mb"`
+	UsedMB         uint64  `json:"used_mb"`
+	AvailableMB    uint64  `json:"available_mb"`
+	UsagePercent   float64 `json:"usage_percent"`
+	SwapTotalMB    uint64  `json:"swap_total_mb,omitempty"`
+	SwapUsedMB     uint64  `json:"swap_used_mb,omitempty"`
+	SwapPercent    float64 `json:"swap_percent,omitempty"`
+	TotalBytes     uint64  `json:"total_bytes"`
+	UsedBytes      uint64  `json:"used_bytes"`
+	AvailableBytes uint64  `json:"available_bytes"`
+	SwapTotalBytes uint64  `json:"swap_total_bytes,omitempty"`
+	SwapUsedBytes  uint64  `json:"swap_used_bytes,omitempty"`
+}
+
+// AgentDiskMetrics holds the usage of one mounted filesystem
+type AgentDiskMetrics struct {
+	Mount          string  `json:"mount"`
+	TotalGB        float64 `json:"total_gb"`
+	UsedGB         float64 `json:"used_gb"`
+	AvailableGB    float64 `json:"available_gb"`
+	UsagePercent   float64 `json:"usage_percent"`
+	Filesystem     string  `json:"filesystem,omitempty"`
+	FSType         string  `json:"fs_type,omitempty"`
+	ReadOnly       bool    `json:"read_only"`
+	TotalBytes     uint64  `json:"total_bytes"`
+	UsedBytes      uint64  `json:"used_bytes"`
+	AvailableBytes uint64  `json:"available_bytes"`
+
+	// Inode counts are 0 for filesystems that allocate inodes dynamically
+	InodesTotal        uint64  `json:"inodes_total"`
+	InodesUsed         uint64  `json:"inodes_used"`
+	InodesFree         uint64  `json:"inodes_free"`
+	InodesUsagePercent float64 `json:"inodes_usage_percent"`
+}
+
+// AgentRAIDMetrics holds the state of a Linux software RAID array
+type AgentRAIDMetrics struct {
+	Device        string   `json:"device"`
+	Level         string   `json:"level"`
+	Status        string   `json:"status"` // clean, degraded, rebuilding, resyncing, checking or inactive
+	Degraded      bool     `json:"degraded"`
+	Devices       int      `json:"devices"`
+	ActiveDevices int      `json:"active_devices"`
+	FailedDevices int      `json:"failed_devices"`
+	Members       []string `json:"members"`
+	SyncAction    string   `json:"sync_action,omitempty"`
+	SyncPercent   float64  `json:"sync_percent,omitempty"`
+}
+
+// AgentNetworkMetrics holds an agent's network traffic counters
+type AgentNetworkMetrics struct {
+	RxBytes    uint64                  `json:"rx_bytes"`
+	TxBytes    uint64                  `json:"tx_bytes"`
+	RxMB       float64                 `json:"rx_mb"`
+	TxMB       float64                 `json:"tx_mb"`
+	RxRate     float64                 `json:"rx_bytes_per_sec"`
+	TxRate     float64                 `json:"tx_bytes_per_sec"`
+	Interfaces []AgentNetworkInterface `json:"interfaces,omitempty"`
+}
+
+// AgentNetworkInterface holds the traffic counters and rates of one interface
+type AgentNetworkInterface struct {
+	Name          string  `json:"name"`
+	RxBytes       uint64  `json:"rx_bytes"`
+	TxBytes       uint64  `json:"tx_bytes"`
+	RxMB          float64 `json:"rx_mb"`
+	TxMB          float64 `json:"tx_mb"`
+	RxPackets     uint64  `json:"rx_packets"`
+	TxPackets     uint64  `json:"tx_packets"`
+	RxErrors      uint64  `json:"rx_errors"`
+	TxErrors      uint64  `json:"tx_errors"`
+	RxDropped     uint64  `json:"rx_dropped"`
+	TxDropped     uint64  `json:"tx_dropped"`
+	RxRate        float64 `json:"rx_bytes_per_sec"`
+	TxRate        float64 `json:"tx_bytes_per_sec"`
+	RxPacketRate  float64 `json:"rx_packets_per_sec"`
+	TxPacketRate  float64 `json:"tx_packets_per_sec"`
+	RxErrorRate   float64 `json:"rx_errors_per_sec"`
+	TxErrorRate   float64 `json:"tx_errors_per_sec"`
+	RxDroppedRate float64 `json:"rx_dropped_per_sec"`
+	TxDroppedRate float64 `json:"tx_dropped_per_sec"`
+}
+
+// AgentDiskIOMetrics holds the I/O counters and rates of one block device
+type AgentDiskIOMetrics struct {
+	Device         string  `json:"device"`
+	Reads          uint64  `json:"reads"`
+	Writes         uint64  `json:"writes"`
+	ReadBytes      uint64  `json:"read_bytes"`
+	WriteBytes     uint64  `json:"write_bytes"`
+	IOTimeMillis   uint64  `json:"io_time_ms"`
+	ReadIOPS       float64 `json:"read_iops"`
+	WriteIOPS      float64 `json:"write_iops"`
+	ReadRate       float64 `json:"read_bytes_per_sec"`
+	WriteRate      float64 `json:"write_bytes_per_sec"`
+	AwaitMillis    float64 `json:"await_ms"`
+	UtilizationPct float64 `json:"util_percent"`
+}
+
+// AgentPressure holds an agent's pressure stall information (Linux PSI)
+type AgentPressure struct {
+	CPU    *AgentPressureStall `json:"cpu,omitempty"`
+	Memory *AgentPressureStall `json:"memory,omitempty"`
+	IO     *AgentPressureStall `json:"io,omitempty"`
+}
+
+// AgentPressureStall holds the stall averages of one resource: some is time at
+// least one task was stalled, full is time all non-idle tasks were
+type AgentPressureStall struct {
+	Some AgentPressureAverages  `json:"some"`
+	Full *AgentPressureAverages `json:"full,omitempty"`
+}
+
+// AgentPressureAverages are stall percentages over 10, 60 and 300 seconds
+type AgentPressureAverages struct {
+	Avg10        float64 `json:"avg10"`
+	Avg60        float64 `json:"avg60"`
+	Avg300       float64 `json:"avg300"`
+	TotalSeconds float64 `json:"total_seconds"`
+}
+
+// AgentServiceMetric reports whether a watched process is running, and its
+// resource usage summed over all matching processes
+type AgentServiceMetric struct {
+	Name          string  `json:"name"`
+	Status        string  `json:"status"` // running, stopped or unknown
+	PID           int     `json:"pid,omitempty"`
+	ProcessCount  int     `json:"process_count,omitempty"`
+	CPUPercent    float64 `json:"cpu_percent"` // Of one core
+	RSSBytes      uint64  `json:"rss_bytes"`
+	Threads       int     `json:"threads"`
+	OpenFDs       int     `json:"open_fds"`
+	ReadBytes     uint64  `json:"read_bytes"`
+	WriteBytes    uint64  `json:"write_bytes"`
+	UptimeSeconds int64   `json:"uptime_seconds"`
+}
+
+// AgentProcessMetric is one entry of an agent's top CPU or memory list
+type AgentProcessMetric struct {
+	PID        int     `json:"pid"`
+	Name       string  `json:"name"`
+	Command    string  `json:"command,omitempty"`
+	CPUPercent float64 `json:"cpu_percent"`
+	RSSBytes   uint64  `json:"rss_bytes"`
+	Threads    int     `json:"threads"`
+}
+
+// AgentContainerMetric holds the resource usage of one container on an agent's host
+type AgentContainerMetric struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	Image            string  `json:"image,omitempty"`
+	CPUPercent       float64 `json:"cpu_percent"` // Of one core
+	CPUSeconds       float64 `json:"cpu_seconds"`
+	MemoryBytes      uint64  `json:"memory_bytes"`
+	MemoryLimitBytes uint64  `json:"memory_limit_bytes"` // 0 if unlimited
+	MemoryPercent    float64 `json:"memory_percent"`
+	OOMKills         uint64  `json:"oom_kills"`
+	ReadBytes        uint64  `json:"read_bytes"`
+	WriteBytes       uint64  `json:"write_bytes"`
+	ReadRate         float64 `json:"read_bytes_per_sec"`
+	WriteRate        float64 `json:"write_bytes_per_sec"`
+}
+
+// AgentPortMetric reports whether a watched port is listening
+type AgentPortMetric struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol,omitempty"` // tcp or udp
+	Family   string `json:"family,omitempty"`   // ipv4 or ipv6, if only one is checked
+	Status   string `json:"status"`             // listening or closed
+	Process  string `json:"process,omitempty"`
+}
+
+// MaxDiskUsage returns the highest usage percentage of any filesystem
+func (m *AgentMetrics) MaxDiskUsage() float64 {
+	usage := 0.0
+	for _, disk := range m.Disk {
+		usage = max(usage, disk.UsagePercent)
+	}
+	return usage
+}
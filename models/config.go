@@ -13,4 +13,7 @@ type NotificationEvent string
 const (
 	EventServiceDown NotificationEvent = "service_down"
 	EventServiceUp   NotificationEvent = "service_up"
+	EventSSLExpiry   NotificationEvent = "ssl_expiry"
+	EventSystem      NotificationEvent = "system_alert"
+	EventTest        NotificationEvent = "test"
 )
@@ -2,11 +2,15 @@ package models
 
 import "time"
 
-// ServiceHistory stores historical check results for a service
+// ServiceHistory is the check history of a service over a time range. Checks
+// holds raw results; Rollups holds aggregates when a 1m or 1h resolution is requested.
 type ServiceHistory struct {
-	ServiceID string              `json:"service_id"`
-	Checks    []HealthCheckRecord `json:"checks"`
-	MaxChecks int                 `json:"max_checks"` // Maximum number of checks to keep
+	ServiceID  string              `json:"service_id"`
+	From       time.Time           `json:"from"`
+	To         time.Time           `json:"to"`
+	Resolution string              `json:"resolution"` // raw, 1m or 1h
+	Checks     []HealthCheckRecord `json:"checks"`
+	Rollups    []HistoryRollup     `json:"rollups,omitempty"`
 }
 
 // HealthCheckRecord represents a single health check result with timestamp
@@ -15,27 +19,41 @@ type HealthCheckRecord struct {
 	Status       ServiceStatus `json:"status"`
 	ResponseTime int64         `json:"response_time"` // in milliseconds
 	ErrorMessage string        `json:"error_message,omitempty"`
+	Ping         *PingStats    `json:"ping,omitempty"` // ICMP checks only
 }
 
-// NewServiceHistory creates a new service history
-func NewServiceHistory(serviceID string, maxChecks int) *ServiceHistory {
-	if maxChecks == 0 {
-		maxChecks = 100 // default to keep last 100 checks
-	}
-	return &ServiceHistory{
-		ServiceID: serviceID,
-		Checks:    make([]HealthCheckRecord, 0),
-		MaxChecks: maxChecks,
-	}
+// HistoryRollup aggregates the checks of a service within one minute or hour.
+// Response times only cover available (up or degraded) checks.
+type HistoryRollup struct {
+	Timestamp       time.Time `json:"timestamp"` // Start of the bucket (UTC)
+	Checks          int       `json:"checks"`
+	UpCount         int       `json:"up_count"` // includes degraded checks
+	DownCount       int       `json:"down_count"`
+	DegradedCount   int       `json:"degraded_count"`
+	AvgResponseTime float64   `json:"avg_response_time"` // in milliseconds
+	MinResponseTime int64     `json:"min_response_time"` // in milliseconds
+	MaxResponseTime int64     `json:"max_response_time"` // in milliseconds
 }
 
-// AddCheck adds a new check result to the history
-func (h *ServiceHistory) AddCheck(record HealthCheckRecord) {
-	h.Checks = append(h.Checks, record)
+// Add counts a check in the rollup
+func (r *HistoryRollup) Add(record HealthCheckRecord) {
+	r.Checks++
 
-	// Keep only the last MaxChecks entries
-	if len(h.Checks) > h.MaxChecks {
-		h.Checks = h.Checks[len(h.Checks)-h.MaxChecks:]
+	if record.Status.IsAvailable() {
+		if r.UpCount == 0 || record.ResponseTime < r.MinResponseTime {
+			r.MinResponseTime = record.ResponseTime
+		}
+		if record.ResponseTime > r.MaxResponseTime {
+			r.MaxResponseTime = record.ResponseTime
+		}
+		total := r.AvgResponseTime*float64(r.UpCount) + float64(record.ResponseTime)
+		r.UpCount++
+		r.AvgResponseTime = total / float64(r.UpCount)
+		if record.Status == StatusDegraded {
+			r.DegradedCount++
+		}
+	} else if record.Status == StatusDown {
+		r.DownCount++
 	}
 }
 
@@ -51,16 +69,19 @@ func (h *ServiceHistory) GetStatistics() *ServiceStatistics {
 	}
 
 	var totalResponseTime int64
-	var upCount, downCount int
+	var upCount, downCount, degradedCount int
 	var uptimeStart *time.Time
 	var downtimeStart *time.Time
 	var currentUptime, currentDowntime time.Duration
 	var totalUptime, totalDowntime time.Duration
 
 	for i, check := range h.Checks {
-		if check.Status == StatusUp {
+		if check.Status.IsAvailable() {
 			upCount++
 			totalResponseTime += check.ResponseTime
+			if check.Status == StatusDegraded {
+				degradedCount++
+			}
 
 			if uptimeStart == nil {
 				uptimeStart = &check.Timestamp
@@ -78,7 +99,7 @@ func (h *ServiceHistory) GetStatistics() *ServiceStatistics {
 			prevCheck := h.Checks[i-1]
 			duration := check.Timestamp.Sub(prevCheck.Timestamp)
 
-			if prevCheck.Status == StatusUp {
+			if prevCheck.Status.IsAvailable() {
 				totalUptime += duration
 			} else if prevCheck.Status == StatusDown {
 				totalDowntime += duration
@@ -88,7 +109,7 @@ func (h *ServiceHistory) GetStatistics() *ServiceStatistics {
 
 	// Calculate current uptime/downtime
 	lastCheck := h.Checks[len(h.Checks)-1]
-	if lastCheck.Status == StatusUp && uptimeStart != nil {
+	if lastCheck.Status.IsAvailable() && uptimeStart != nil {
 		currentUptime = time.Since(*uptimeStart)
 	} else if lastCheck.Status == StatusDown && downtimeStart != nil {
 		currentDowntime = time.Since(*downtimeStart)
@@ -96,6 +117,7 @@ func (h *ServiceHistory) GetStatistics() *ServiceStatistics {
 
 	stats.UpCount = upCount
 	stats.DownCount = downCount
+	stats.DegradedCount = degradedCount
 
 	if upCount > 0 {
 		stats.UptimePercentage = float64(upCount) / float64(len(h.Checks)) * 100
@@ -122,8 +144,9 @@ func (h *ServiceHistory) GetStatistics() *ServiceStatistics {
 type ServiceStatistics struct {
 	ServiceID           string              `json:"service_id"`
 	TotalChecks         int                 `json:"total_checks"`
-	UpCount             int                 `json:"up_count"`
+	UpCount             int                 `json:"up_count"`       // includes degraded checks
 	DownCount           int                 `json:"down_count"`
+	DegradedCount       int                 `json:"degraded_count"`
 	UptimePercentage    float64             `json:"uptime_percentage"`
 	AverageResponseTime int64               `json:"average_response_time"` // in milliseconds
 	CurrentUptime       int64               `json:"current_uptime"`        // in seconds
@@ -131,4 +154,46 @@ type ServiceStatistics struct {
 	TotalUptime         int64               `json:"total_uptime"`          // in seconds
 	TotalDowntime       int64               `json:"total_downtime"`        // in seconds
 	Last24Hours         []HealthCheckRecord `json:"last_24_hours"`
+	From                time.Time           `json:"from"`
+	To                  time.Time           `json:"to"`
+	Resolution          string              `json:"resolution"` // Data the counts were taken from: raw, 1m or 1h
+}
+
+// applyRollups replaces the counts, averages and totals with values aggregated
+// from rollups, for ranges older than the raw checks still kept
+func (s *ServiceStatistics) applyRollups(rollups []HistoryRollup, resolution string) {
+	bucket := time.Minute
+	if resolution == "1h" {
+		bucket = time.Hour
+	}
+
+	var checks, upCount, downCount, degradedCount int
+	var totalResponseTime float64
+	var totalUptime, totalDowntime time.Duration
+	for _, rollup := range rollups {
+		checks += rollup.Checks
+		upCount += rollup.UpCount
+		downCount += rollup.DownCount
+		degradedCount += rollup.DegradedCount
+		totalResponseTime += rollup.AvgResponseTime * float64(rollup.UpCount)
+
+		// Split each bucket's duration by the share of checks that were up or down
+		if rollup.Checks > 0 {
+			totalUptime += bucket * time.Duration(rollup.UpCount) / time.Duration(rollup.Checks)
+			totalDowntime += bucket * time.Duration(rollup.DownCount) / time.Duration(rollup.Checks)
+		}
+	}
+
+	s.TotalChecks = checks
+	s.UpCount = upCount
+	s.DownCount = downCount
+	s.DegradedCount = degradedCount
+	s.UptimePercentage = 0
+	s.AverageResponseTime = 0
+	if checks > 0 && upCount > 0 {
+		s.UptimePercentage = float64(upCount) / float64(checks) * 100
+		s.AverageResponseTime = int64(totalResponseTime / float64(upCount))
+	}
+	s.TotalUptime = int64(totalUptime.Seconds())
+	s.TotalDowntime = int64(totalDowntime.Seconds())
 }
@@ -1,88 +1,528 @@
 package models
 
-import "sync"
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
 
-// HistoryStore manages service history storage
+// Segment directories inside each service's history directory
+const (
+	rawSegmentDir    = "raw"
+	minuteSegmentDir = "1m"
+	hourSegmentDir   = "1h"
+
+	daySegmentLayout   = "2006-01-02"
+	monthSegmentLayout = "2006-01"
+)
+
+// ErrInvalidResolution is returned for rollup queries with an unknown resolution
+var ErrInvalidResolution = errors.New("resolution must be raw, 1m or 1h")
+
+// HistoryRetention controls how long each resolution of history is kept
+type HistoryRetention struct {
+	Raw    time.Duration // Individual check results
+	Minute time.Duration // 1-minute rollups
+	Hour   time.Duration // 1-hour rollups
+}
+
+// DefaultHistoryRetention keeps a week of raw checks, 90 days of 1-minute
+// rollups and two years of 1-hour rollups
+var DefaultHistoryRetention = HistoryRetention{
+	Raw:    7 * 24 * time.Hour,
+	Minute: 90 * 24 * time.Hour,
+	Hour:   730 * 24 * time.Hour,
+}
+
+// HistoryStore keeps check history on disk as append-only JSON-lines segments.
+// Raw checks are written to one segment per day; 1-minute rollups to one
+// segment per day and 1-hour rollups to one segment per month. Rollups are
+// written when their bucket closes, and open buckets are rebuilt from the raw
+// segments after a restart.
 type HistoryStore struct {
-	histories map[string]*ServiceHistory
+	dir       string
+	retention HistoryRetention
+	series    map[string]*historySeries // Open rollup buckets, loaded on first use
 	mu        sync.RWMutex
-	maxChecks int
 }
 
-// NewHistoryStore creates a new history store
-func NewHistoryStore(maxChecks int) *HistoryStore {
-	if maxChecks == 0 {
-		maxChecks = 100
+// historySeries holds the rollup buckets still receiving checks for a service
+type historySeries struct {
+	minute  *HistoryRollup
+	hour    *HistoryRollup
+	lastDay string // Day of the last raw segment written, to prune on rollover
+}
+
+// NewHistoryStore opens (or creates) a history store in dir
+func NewHistoryStore(dir string, retention HistoryRetention) (*HistoryStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
 	}
-	return &HistoryStore{
-		histories: make(map[string]*ServiceHistory),
-		maxChecks: maxChecks,
+
+	if retention.Raw <= 0 {
+		retention.Raw = DefaultHistoryRetention.Raw
+	}
+	if retention.Minute <= 0 {
+		retention.Minute = DefaultHistoryRetention.Minute
+	}
+	if retention.Hour <= 0 {
+		retention.Hour = DefaultHistoryRetention.Hour
+	}
+
+	store := &HistoryStore{
+		dir:       dir,
+		retention: retention,
+		series:    make(map[string]*historySeries),
 	}
+	store.Prune()
+	return store, nil
 }
 
-// AddCheckResult adds a check result to service history
+// AddCheckResult appends a check result to the service's history and updates its rollups
 func (s *HistoryStore) AddCheckResult(serviceID string, record HealthCheckRecord) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	history, exists := s.histories[serviceID]
-	if !exists {
-		history = NewServiceHistory(serviceID, s.maxChecks)
-		s.histories[serviceID] = history
+	series, err := s.loadSeries(serviceID)
+	if err != nil {
+		fmt.Printf("Error loading history for %s: %v\n", serviceID, err)
+		return
+	}
+
+	timestamp := record.Timestamp.UTC()
+	day := timestamp.Format(daySegmentLayout)
+	if err := s.appendSegment(serviceID, rawSegmentDir, day, record); err != nil {
+		fmt.Printf("Error writing history for %s: %v\n", serviceID, err)
+		return
+	}
+
+	if series.lastDay != day {
+		series.lastDay = day
+		s.pruneService(serviceID, time.Now())
 	}
 
-	history.AddCheck(record)
+	s.accumulate(serviceID, series, record)
 }
 
-// GetHistory retrieves history for a service
-func (s *HistoryStore) GetHistory(serviceID string) *ServiceHistory {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// accumulate adds a record to the open rollup buckets, writing out buckets it closes
+func (s *HistoryStore) accumulate(serviceID string, series *historySeries, record HealthCheckRecord) {
+	s.rollup(serviceID, &series.minute, minuteSegmentDir, record)
+	s.rollup(serviceID, &series.hour, hourSegmentDir, record)
+}
+
+// rollup adds a record to an open bucket at a resolution. If the record belongs
+// to a later bucket, the open one is written to its segment first; a record
+// that arrives late for an already written bucket is counted in the open one.
+func (s *HistoryStore) rollup(serviceID string, bucket **HistoryRollup, resolution string, record HealthCheckRecord) {
+	step, layout := time.Minute, daySegmentLayout
+	if resolution == hourSegmentDir {
+		step, layout = time.Hour, monthSegmentLayout
+	}
+
+	start := record.Timestamp.UTC().Truncate(step)
+	if open := *bucket; open != nil && start.After(open.Timestamp) {
+		if err := s.appendSegment(serviceID, resolution, open.Timestamp.Format(layout), open); err != nil {
+			fmt.Printf("Error writing %s rollup for %s: %v\n", resolution, serviceID, err)
+		}
+		*bucket = nil
+	}
+	if *bucket == nil {
+		*bucket = &HistoryRollup{Timestamp: start}
+	}
+	(*bucket).Add(record)
+}
+
+// loadSeries returns the open rollup buckets for a service, rebuilding them
+// from raw checks newer than the last rollups written. Callers must hold the write lock.
+func (s *HistoryStore) loadSeries(serviceID string) (*historySeries, error) {
+	if series, ok := s.series[serviceID]; ok {
+		return series, nil
+	}
+	if err := validHistoryID(serviceID); err != nil {
+		return nil, err
+	}
+
+	lastMinute, err := s.lastRollup(serviceID, minuteSegmentDir)
+	if err != nil {
+		return nil, err
+	}
+	lastHour, err := s.lastRollup(serviceID, hourSegmentDir)
+	if err != nil {
+		return nil, err
+	}
 
-	return s.histories[serviceID]
+	// Raw checks before the end of the last written bucket are already rolled up
+	var minuteDone, hourDone time.Time
+	if lastMinute != nil {
+		minuteDone = lastMinute.Timestamp.Add(time.Minute)
+	}
+	if lastHour != nil {
+		hourDone = lastHour.Timestamp.Add(time.Hour)
+	}
+	replayFrom := minuteDone
+	if hourDone.Before(replayFrom) {
+		replayFrom = hourDone
+	}
+
+	records, err := s.readRaw(serviceID, replayFrom, time.Now().Add(time.Hour))
+	if err != nil {
+		return nil, err
+	}
+
+	series := &historySeries{}
+	for _, record := range records {
+		timestamp := record.Timestamp.UTC()
+		if !timestamp.Before(minuteDone) {
+			s.rollup(serviceID, &series.minute, minuteSegmentDir, record)
+		}
+		if !timestamp.Before(hourDone) {
+			s.rollup(serviceID, &series.hour, hourSegmentDir, record)
+		}
+	}
+	if len(records) > 0 {
+		series.lastDay = records[len(records)-1].Timestamp.UTC().Format(daySegmentLayout)
+	}
+
+	s.series[serviceID] = series
+	return series, nil
+}
+
+// lastRollup returns the newest rollup written at a resolution, or nil
+func (s *HistoryStore) lastRollup(serviceID, resolution string) (*HistoryRollup, error) {
+	segments, err := s.segments(serviceID, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	// Segments are named by date, so the newest non-empty one holds the last rollup
+	for i := len(segments) - 1; i >= 0; i-- {
+		var last *HistoryRollup
+		err := readSegment(segments[i], func(line []byte) {
+			var rollup HistoryRollup
+			if json.Unmarshal(line, &rollup) == nil {
+				last = &rollup
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			return last, nil
+		}
+	}
+	return nil, nil
 }
 
-// GetStatistics retrieves statistics for a service
-func (s *HistoryStore) GetStatistics(serviceID string) *ServiceStatistics {
+// GetHistory returns the raw checks for a service between from and to, oldest first
+func (s *HistoryStore) GetHistory(serviceID string, from, to time.Time) ([]HealthCheckRecord, error) {
+	if err := validHistoryID(serviceID); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	history, exists := s.histories[serviceID]
-	if !exists {
-		return &ServiceStatistics{
-			ServiceID: serviceID,
+	return s.readRaw(serviceID, from, to)
+}
+
+// GetRollups returns rollups at resolution "1m" or "1h" between from and to,
+// oldest first, including the bucket still receiving checks
+func (s *HistoryStore) GetRollups(serviceID, resolution string, from, to time.Time) ([]HistoryRollup, error) {
+	if resolution != minuteSegmentDir && resolution != hourSegmentDir {
+		return nil, ErrInvalidResolution
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	series, err := s.loadSeries(serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	layout, step := daySegmentLayout, time.Minute
+	open := series.minute
+	if resolution == hourSegmentDir {
+		layout, step = monthSegmentLayout, time.Hour
+		open = series.hour
+	}
+
+	// A bucket is included if any part of it falls within the range
+	from = from.Truncate(step)
+
+	segments, err := s.segmentsBetween(serviceID, resolution, layout, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	rollups := []HistoryRollup{}
+	for _, segment := range segments {
+		err := readSegment(segment, func(line []byte) {
+			var rollup HistoryRollup
+			if json.Unmarshal(line, &rollup) != nil {
+				return
+			}
+			if !rollup.Timestamp.Before(from) && !rollup.Timestamp.After(to) {
+				rollups = append(rollups, rollup)
+			}
+		})
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	return history.GetStatistics()
+	if open != nil && !open.Timestamp.Before(from) && !open.Timestamp.After(to) {
+		rollups = append(rollups, *open)
+	}
+
+	return rollups, nil
 }
 
-// DeleteHistory removes history for a service
+// GetStatistics calculates statistics for a service between from and to. Raw
+// checks are used while they are retained; older ranges fall back to rollups.
+func (s *HistoryStore) GetStatistics(serviceID string, from, to time.Time) (*ServiceStatistics, error) {
+	now := time.Now()
+
+	// Segments are pruned whole, so data is complete from the start of the
+	// day segment that contains the retention cutoff
+	rawCutoff := now.Add(-s.retention.Raw).Truncate(24 * time.Hour)
+	minuteCutoff := now.Add(-s.retention.Minute).Truncate(24 * time.Hour)
+
+	resolution := "raw"
+	switch {
+	case !from.Before(rawCutoff):
+	case !from.Before(minuteCutoff):
+		resolution = minuteSegmentDir
+	default:
+		resolution = hourSegmentDir
+	}
+
+	// Current uptime/downtime and the 24 hour chart always come from raw checks
+	rawFrom := from
+	if resolution != "raw" {
+		rawFrom = rawCutoff
+	}
+	records, err := s.GetHistory(serviceID, rawFrom, to)
+	if err != nil {
+		return nil, err
+	}
+	history := &ServiceHistory{ServiceID: serviceID, Checks: records}
+	stats := history.GetStatistics()
+
+	if resolution != "raw" {
+		rollups, err := s.GetRollups(serviceID, resolution, from, to)
+		if err != nil {
+			return nil, err
+		}
+		stats.applyRollups(rollups, resolution)
+	}
+
+	stats.From = from
+	stats.To = to
+	stats.Resolution = resolution
+	return stats, nil
+}
+
+// DeleteHistory removes all history for a service
 func (s *HistoryStore) DeleteHistory(serviceID string) {
+	if validHistoryID(serviceID) != nil {
+		return
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	delete(s.histories, serviceID)
+	delete(s.series, serviceID)
+	if err := os.RemoveAll(filepath.Join(s.dir, serviceID)); err != nil {
+		fmt.Printf("Error deleting history for %s: %v\n", serviceID, err)
+	}
+}
+
+// ImportLegacy writes histories saved in monitoring_data.json by earlier
+// versions into the store. Services that already have history on disk are
+// skipped, so it is safe to run on every start. It returns the number of
+// services imported.
+func (s *HistoryStore) ImportLegacy(histories map[string]*ServiceHistory) int {
+	imported := 0
+	for serviceID, history := range histories {
+		if history == nil || len(history.Checks) == 0 || validHistoryID(serviceID) != nil {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(s.dir, serviceID)); err == nil {
+			continue
+		}
+
+		checks := append([]HealthCheckRecord(nil), history.Checks...)
+		sort.Slice(checks, func(i, j int) bool {
+			return checks[i].Timestamp.Before(checks[j].Timestamp)
+		})
+		for _, check := range checks {
+			s.AddCheckResult(serviceID, check)
+		}
+		imported++
+	}
+	return imported
 }
 
-// GetAllAsMap returns all histories as a map (for persistence)
-func (s *HistoryStore) GetAllAsMap() map[string]*ServiceHistory {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// Prune deletes segments older than the retention of their resolution
+func (s *HistoryStore) Prune() {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
 
-	historyCopy := make(map[string]*ServiceHistory)
-	for k, v := range s.histories {
-		historyCopy[k] = v
+	now := time.Now()
+	for _, entry := range entries {
+		if entry.IsDir() {
+			s.pruneService(entry.Name(), now)
+		}
 	}
-	return historyCopy
 }
 
-// LoadFromMap loads histories from a map (from persistence)
-func (s *HistoryStore) LoadFromMap(histories map[string]*ServiceHistory) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// pruneService deletes a service's expired segments. A segment is only
+// removed once everything in it is older than the retention.
+func (s *HistoryStore) pruneService(serviceID string, now time.Time) {
+	resolutions := []struct {
+		dir    string
+		layout string
+		length func(time.Time) time.Time
+		keep   time.Duration
+	}{
+		{rawSegmentDir, daySegmentLayout, func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }, s.retention.Raw},
+		{minuteSegmentDir, daySegmentLayout, func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }, s.retention.Minute},
+		{hourSegmentDir, monthSegmentLayout, func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }, s.retention.Hour},
+	}
+
+	for _, resolution := range resolutions {
+		segments, err := s.segments(serviceID, resolution.dir)
+		if err != nil {
+			continue
+		}
+		for _, segment := range segments {
+			start, err := time.Parse(resolution.layout, strings.TrimSuffix(filepath.Base(segment), ".jsonl"))
+			if err != nil {
+				continue
+			}
+			if resolution.length(start).Before(now.Add(-resolution.keep)) {
+				os.Remove(segment)
+			}
+		}
+	}
+}
+
+// readRaw reads raw checks between from and to, oldest first
+func (s *HistoryStore) readRaw(serviceID string, from, to time.Time) ([]HealthCheckRecord, error) {
+	segments, err := s.segmentsBetween(serviceID, rawSegmentDir, daySegmentLayout, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []HealthCheckRecord{}
+	for _, segment := range segments {
+		err := readSegment(segment, func(line []byte) {
+			var record HealthCheckRecord
+			if json.Unmarshal(line, &record) != nil {
+				return
+			}
+			if !record.Timestamp.Before(from) && !record.Timestamp.After(to) {
+				records = append(records, record)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+	return records, nil
+}
+
+// segments lists a service's segment files at a resolution in date order
+func (s *HistoryStore) segments(serviceID, resolution string) ([]string, error) {
+	segments, err := filepath.Glob(filepath.Join(s.dir, serviceID, resolution, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(segments)
+	return segments, nil
+}
+
+// segmentsBetween lists the segments that may contain entries between from and to
+func (s *HistoryStore) segmentsBetween(serviceID, resolution, layout string, from, to time.Time) ([]string, error) {
+	segments, err := s.segments(serviceID, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compare by name: segment names sort in date order
+	first := from.UTC().Format(layout)
+	last := to.UTC().Format(layout)
+
+	var selected []string
+	for _, segment := range segments {
+		name := strings.TrimSuffix(filepath.Base(segment), ".jsonl")
+		if name >= first && name <= last {
+			selected = append(selected, segment)
+		}
+	}
+	return selected, nil
+}
+
+// appendSegment appends one JSON line to a segment file
+func (s *HistoryStore) appendSegment(serviceID, resolution, name string, value interface{}) error {
+	dir := filepath.Join(s.dir, serviceID, resolution)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, name+".jsonl"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// readSegment calls fn for every line of a segment. A line cut short by a
+// crash simply fails to decode in fn and is skipped.
+func readSegment(path string, fn func(line []byte)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fn(scanner.Bytes())
+	}
+	return scanner.Err()
+}
 
-	if histories != nil {
-		s.histories = histories
+// validHistoryID rejects service IDs that cannot be used as a directory name
+func validHistoryID(serviceID string) error {
+	if serviceID == "" || serviceID == "." || serviceID == ".." || strings.ContainsAny(serviceID, `/\`) {
+		return fmt.Errorf("invalid service ID %q", serviceID)
 	}
+	return nil
 }
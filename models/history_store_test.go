@@ -0,0 +1,192 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistoryRollupAdd(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []HealthCheckRecord
+		want    HistoryRollup
+	}{
+		{
+			name:    "up checks",
+			records: []HealthCheckRecord{{Status: StatusUp, ResponseTime: 100}, {Status: StatusUp, ResponseTime: 300}},
+			want:    HistoryRollup{Checks: 2, UpCount: 2, AvgResponseTime: 200, MinResponseTime: 100, MaxResponseTime: 300},
+		},
+		{
+			name:    "down checks have no response time",
+			records: []HealthCheckRecord{{Status: StatusDown, ResponseTime: 5000}, {Status: StatusUp, ResponseTime: 80}},
+			want:    HistoryRollup{Checks: 2, UpCount: 1, DownCount: 1, AvgResponseTime: 80, MinResponseTime: 80, MaxResponseTime: 80},
+		},
+		{
+			name:    "degraded counts as up",
+			records: []HealthCheckRecord{{Status: StatusDegraded, ResponseTime: 900}, {Status: StatusUp, ResponseTime: 100}},
+			want:    HistoryRollup{Checks: 2, UpCount: 2, DegradedCount: 1, AvgResponseTime: 500, MinResponseTime: 100, MaxResponseTime: 900},
+		},
+		{
+			name:    "unknown only counted as a check",
+			records: []HealthCheckRecord{{Status: StatusUnknown}},
+			want:    HistoryRollup{Checks: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var rollup HistoryRollup
+			for _, record := range test.records {
+				rollup.Add(record)
+			}
+			if rollup != test.want {
+				t.Fatalf("rollup = %+v, want %+v", rollup, test.want)
+			}
+		})
+	}
+}
+
+// historyRecords returns checks spread over three minutes of one hour and
+// the first minute of the next, starting at base
+func historyRecords(base time.Time) []HealthCheckRecord {
+	return []HealthCheckRecord{
+		{Timestamp: base, Status: StatusUp, ResponseTime: 100},
+		{Timestamp: base.Add(30 * time.Second), Status: StatusDown},
+		{Timestamp: base.Add(70 * time.Second), Status: StatusDegraded, ResponseTime: 300},
+		{Timestamp: base.Add(2 * time.Minute), Status: StatusUp, ResponseTime: 200},
+		{Timestamp: base.Add(time.Hour), Status: StatusUp, ResponseTime: 50},
+	}
+}
+
+func TestHistoryStoreRollups(t *testing.T) {
+	base := time.Now().UTC().Add(-3 * time.Hour).Truncate(time.Hour)
+	records := historyRecords(base)
+
+	minutes := []HistoryRollup{
+		{Timestamp: base, Checks: 2, UpCount: 1, DownCount: 1, AvgResponseTime: 100, MinResponseTime: 100, MaxResponseTime: 100},
+		{Timestamp: base.Add(time.Minute), Checks: 1, UpCount: 1, DegradedCount: 1, AvgResponseTime: 300, MinResponseTime: 300, MaxResponseTime: 300},
+		{Timestamp: base.Add(2 * time.Minute), Checks: 1, UpCount: 1, AvgResponseTime: 200, MinResponseTime: 200, MaxResponseTime: 200},
+		{Timestamp: base.Add(time.Hour), Checks: 1, UpCount: 1, AvgResponseTime: 50, MinResponseTime: 50, MaxResponseTime: 50},
+	}
+	hours := []HistoryRollup{
+		{Timestamp: base, Checks: 4, UpCount: 3, DownCount: 1, DegradedCount: 1, AvgResponseTime: 200, MinResponseTime: 100, MaxResponseTime: 300},
+		{Timestamp: base.Add(time.Hour), Checks: 1, UpCount: 1, AvgResponseTime: 50, MinResponseTime: 50, MaxResponseTime: 50},
+	}
+
+	// After a restart mid-hour the open minute bucket is rebuilt from the raw
+	// checks after the closed minutes, and the open hour from all of them
+	tests := []struct {
+		name       string
+		resolution string
+		restartAt  int // Reopen the store before adding this record; 0 never reopens
+		want       []HistoryRollup
+	}{
+		{name: "1m", resolution: "1m", want: minutes},
+		{name: "1h", resolution: "1h", want: hours},
+		{name: "1m rebuilt mid-hour", resolution: "1m", restartAt: 3, want: minutes},
+		{name: "1h rebuilt mid-hour", resolution: "1h", restartAt: 3, want: hours},
+		{name: "1m rebuilt at the end", resolution: "1m", restartAt: len(records), want: minutes},
+		{name: "1h rebuilt at the end", resolution: "1h", restartAt: len(records), want: hours},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store := openHistoryStore(t, dir)
+			for i, record := range records {
+				if i == test.restartAt && i > 0 {
+					store = openHistoryStore(t, dir)
+				}
+				store.AddCheckResult("svc", record)
+			}
+			if test.restartAt == len(records) {
+				store = openHistoryStore(t, dir)
+			}
+
+			rollups, err := store.GetRollups("svc", test.resolution, base, time.Now())
+			if err != nil {
+				t.Fatalf("GetRollups: %v", err)
+			}
+			if len(rollups) != len(test.want) {
+				t.Fatalf("got %d rollups, want %d: %+v", len(rollups), len(test.want), rollups)
+			}
+			for i, want := range test.want {
+				got := rollups[i]
+				if !got.Timestamp.Equal(want.Timestamp) {
+					t.Errorf("rollup %d starts at %v, want %v", i, got.Timestamp, want.Timestamp)
+				}
+				got.Timestamp = want.Timestamp
+				if got != want {
+					t.Errorf("rollup %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHistoryStoreRawHistory(t *testing.T) {
+	dir := t.TempDir()
+	store := openHistoryStore(t, dir)
+
+	base := time.Now().UTC().Add(-3 * time.Hour).Truncate(time.Hour)
+	for _, record := range historyRecords(base) {
+		store.AddCheckResult("svc", record)
+	}
+
+	checks, err := store.GetHistory("svc", base.Add(time.Minute), base.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("GetHistory: %v", err)
+	}
+	if len(checks) != 2 || checks[0].Status != StatusDegraded || checks[1].Status != StatusUp {
+		t.Fatalf("checks = %+v, want the degraded and up checks of minutes 1 and 2", checks)
+	}
+
+	if _, err := store.GetRollups("svc", "5m", base, time.Now()); err != ErrInvalidResolution {
+		t.Errorf("GetRollups(5m) error = %v, want ErrInvalidResolution", err)
+	}
+	if _, err := store.GetHistory("../svc", base, time.Now()); err == nil {
+		t.Error("GetHistory accepted a service ID outside the store")
+	}
+
+	store.DeleteHistory("svc")
+	if _, err := os.Stat(filepath.Join(dir, "svc")); !os.IsNotExist(err) {
+		t.Errorf("history directory still exists after DeleteHistory: %v", err)
+	}
+}
+
+func TestHistoryStorePrune(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().UTC().AddDate(0, 0, -10)
+	recent := time.Now().UTC().Add(-time.Hour)
+
+	store := openHistoryStore(t, dir)
+	store.AddCheckResult("svc", HealthCheckRecord{Timestamp: old, Status: StatusUp})
+	store.AddCheckResult("svc", HealthCheckRecord{Timestamp: recent, Status: StatusUp})
+
+	// Raw segments past their retention are pruned; rollups are kept longer
+	store = openHistoryStore(t, dir)
+	if _, err := os.Stat(filepath.Join(dir, "svc", rawSegmentDir, old.Format(daySegmentLayout)+".jsonl")); !os.IsNotExist(err) {
+		t.Errorf("expired raw segment was kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "svc", minuteSegmentDir, old.Format(daySegmentLayout)+".jsonl")); err != nil {
+		t.Errorf("minute rollup segment was pruned: %v", err)
+	}
+
+	checks, err := store.GetHistory("svc", old.Add(-time.Hour), time.Now())
+	if err != nil || len(checks) != 1 || !checks[0].Timestamp.Equal(recent) {
+		t.Errorf("checks = %+v (%v), want only the recent check", checks, err)
+	}
+}
+
+// openHistoryStore opens a store in dir with a retention of three days of raw checks
+func openHistoryStore(t *testing.T, dir string) *HistoryStore {
+	t.Helper()
+
+	store, err := NewHistoryStore(dir, HistoryRetention{Raw: 3 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("NewHistoryStore: %v", err)
+	}
+	return store
+}
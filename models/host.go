@@ -0,0 +1,349 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrHostNotFound  = errors.New("host not found")
+	ErrHostNameTaken = errors.New("a host with this name already exists")
+)
+
+// HostStatus reflects whether a host's agent is still pushing metrics
+type HostStatus string
+
+const (
+	HostPending HostStatus = "pending" // Created, but no metrics pushed yet
+	HostUp      HostStatus = "up"      // Pushing on schedule
+	HostStale   HostStatus = "stale"   // Missed a few pushes
+	HostDown    HostStatus = "down"    // Stopped pushing
+)
+
+const (
+	// HostStaleAfter and HostDownAfter are the number of missed push
+	// intervals after which a host is considered stale or down
+	HostStaleAfter = 3
+	HostDownAfter  = 10
+
+	// DefaultPushInterval is assumed for agents that don't report their interval
+	DefaultPushInterval = 10 // seconds
+
+	// maxHostSamples is how many recent samples are kept per host (2 hours at 10s)
+	maxHostSamples = 720
+)
+
+// Host is a machine running the monitoring agent in push mode
+type Host struct {
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	TokenHash    string        `json:"token_hash,omitempty"` // SHA-256 of the agent token; cleared in API responses
+	TokenPrefix  string        `json:"token_prefix"`         // Start of the agent token, to recognise it
+	CreatedAt    time.Time     `json:"created_at"`
+	RegisteredAt time.Time     `json:"registered_at,omitempty"` // When the agent last sent its facts
+	Facts        *HostFacts    `json:"facts,omitempty"`
+	LastSeen     time.Time     `json:"last_seen,omitempty"`
+	PushInterval int           `json:"push_interval,omitempty"` // seconds, as reported by the agent
+	Metrics      *AgentMetrics `json:"metrics,omitempty"`       // Latest pushed metrics
+	Status       HostStatus    `json:"status"`
+
+	// Current summarizes Metrics in API responses; it is not stored
+	Current *HostSample `json:"current,omitempty"`
+}
+
+// HostFacts describes a host, as reported by its agent when it registers
+// (HostFacts in agent/facts.go)
+type HostFacts struct {
+	Hostname         string   `json:"hostname"`
+	OS               string   `json:"os"`       // e.g. "Ubuntu 22.04.4 LTS"
+	Platform         string   `json:"platform"` // linux, darwin, ...
+	Kernel           string   `json:"kernel,omitempty"`
+	Arch             string   `json:"arch"`
+	CPUCount         int      `json:"cpu_count"`
+	MemoryTotalBytes uint64   `json:"memory_total_bytes"`
+	AgentVersion     string   `json:"agent_version"`
+	IPAddresses      []string `json:"ip_addresses"`
+}
+
+// Public returns a copy of the host without the token hash, with Current
+// summarizing its latest metrics
+func (h *Host) Public() *Host {
+	public := *h
+	public.TokenHash = ""
+	if h.Metrics != nil {
+		current := NewHostSample(h.LastSeen, h.Metrics)
+		public.Current = &current
+	}
+	return &public
+}
+
+// Summary returns the public copy of the host without the full metrics, for
+// listing the fleet
+func (h *Host) Summary() *Host {
+	summary := h.Public()
+	summary.Metrics = nil
+	return summary
+}
+
+// StatusAt returns the host's status at the given time, based on when it last pushed
+func (h *Host) StatusAt(now time.Time) HostStatus {
+	if h.LastSeen.IsZero() {
+		return HostPending
+	}
+
+	interval := time.Duration(h.PushInterval) * time.Second
+	if interval <= 0 {
+		interval = DefaultPushInterval * time.Second
+	}
+
+	silence := now.Sub(h.LastSeen)
+	switch {
+	case silence > HostDownAfter*interval:
+		return HostDown
+	case silence > HostStaleAfter*interval:
+		return HostStale
+	default:
+		return HostUp
+	}
+}
+
+// HostSample is a compact summary of one push, kept for charts
+type HostSample struct {
+	Timestamp     time.Time `json:"timestamp"`
+	CPUPercent    float64   `json:"cpu_percent"`
+	IOWaitPercent float64   `json:"iowait_percent"`
+	StealPercent  float64   `json:"steal_percent"`
+	MemoryPercent float64   `json:"memory_percent"`
+	SwapPercent   float64   `json:"swap_percent"`
+	DiskPercent   float64   `json:"disk_percent"` // Fullest filesystem
+	Load1         float64   `json:"load1"`
+	RxBytes       uint64    `json:"rx_bytes"`
+	TxBytes       uint64    `json:"tx_bytes"`
+	RxRate        float64   `json:"rx_bytes_per_sec"`
+	TxRate        float64   `json:"tx_bytes_per_sec"`
+}
+
+// NewHostSample summarizes an agent metrics snapshot
+func NewHostSample(receivedAt time.Time, metrics *AgentMetrics) HostSample {
+	return HostSample{
+		Timestamp:     receivedAt,
+		CPUPercent:    metrics.CPU.UsagePercent,
+		IOWaitPercent: metrics.CPU.IOWait,
+		StealPercent:  metrics.CPU.Steal,
+		MemoryPercent: metrics.Memory.UsagePercent,
+		SwapPercent:   metrics.Memory.SwapPercent,
+		DiskPercent:   metrics.MaxDiskUsage(),
+		Load1:         metrics.CPU.Load1(),
+		RxBytes:       metrics.Network.RxBytes,
+		TxBytes:       metrics.Network.TxBytes,
+		RxRate:        metrics.Network.RxRate,
+		TxRate:        metrics.Network.TxRate,
+	}
+}
+
+// HostStore manages hosts and their recent samples. Samples are kept in
+// memory only; the latest metrics of each host are persisted with the host.
+type HostStore struct {
+	hosts   map[string]*Host
+	samples map[string][]HostSample
+	mu      sync.RWMutex
+	onSave  func() // callback when hosts are added, removed or change status
+}
+
+// NewHostStore creates a new host store
+func NewHostStore() *HostStore {
+	return &HostStore{
+		hosts:   make(map[string]*Host),
+		samples: make(map[string][]HostSample),
+	}
+}
+
+// SetOnSave sets the callback for when hosts change
+func (s *HostStore) SetOnSave(onSave func()) {
+	s.onSave = onSave
+}
+
+// triggerSave calls the onSave callback if set
+func (s *HostStore) triggerSave() {
+	if s.onSave != nil {
+		go s.onSave()
+	}
+}
+
+// LoadFromMap loads hosts from persistence
+func (s *HostStore) LoadFromMap(hosts map[string]*Host) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if hosts != nil {
+		s.hosts = hosts
+	}
+}
+
+// GetAllAsMap returns all hosts as a map (for persistence)
+func (s *HostStore) GetAllAsMap() map[string]*Host {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hostsCopy := make(map[string]*Host)
+	for k, v := range s.hosts {
+		hostCopy := *v
+		hostsCopy[k] = &hostCopy
+	}
+	return hostsCopy
+}
+
+// Add adds a new host. Names are unique, ignoring case.
+func (s *HostStore) Add(host *Host) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.hosts {
+		if strings.EqualFold(existing.Name, host.Name) {
+			return ErrHostNameTaken
+		}
+	}
+
+	s.hosts[host.ID] = host
+	s.triggerSave()
+	return nil
+}
+
+// Get returns a copy of a host
+func (s *HostStore) Get(id string) (*Host, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	host, exists := s.hosts[id]
+	if !exists {
+		return nil, ErrHostNotFound
+	}
+	hostCopy := *host
+	return &hostCopy, nil
+}
+
+// GetByTokenHash returns a copy of the host an agent token belongs to
+func (s *HostStore) GetByTokenHash(hash string) (*Host, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, host := range s.hosts {
+		if host.TokenHash == hash {
+			hostCopy := *host
+			return &hostCopy, nil
+		}
+	}
+	return nil, ErrHostNotFound
+}
+
+// GetAll returns copies of all hosts sorted by name
+func (s *HostStore) GetAll() []*Host {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hosts := make([]*Host, 0, len(s.hosts))
+	for _, host := range s.hosts {
+		hostCopy := *host
+		hosts = append(hosts, &hostCopy)
+	}
+
+	sort.Slice(hosts, func(i, j int) bool {
+		return strings.ToLower(hosts[i].Name) < strings.ToLower(hosts[j].Name)
+	})
+
+	return hosts
+}
+
+// RecordPush stores a host's latest metrics and appends a sample. Pushes are
+// not saved on their own, as they arrive every few seconds; the latest
+// metrics are written with the next save.
+func (s *HostStore) RecordPush(id string, receivedAt time.Time, interval int, metrics *AgentMetrics) (*Host, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	host, exists := s.hosts[id]
+	if !exists {
+		return nil, ErrHostNotFound
+	}
+
+	host.LastSeen = receivedAt
+	host.PushInterval = interval
+	host.Metrics = metrics
+
+	samples := append(s.samples[id], NewHostSample(receivedAt, metrics))
+	if len(samples) > maxHostSamples {
+		samples = samples[len(samples)-maxHostSamples:]
+	}
+	s.samples[id] = samples
+
+	hostCopy := *host
+	return &hostCopy, nil
+}
+
+// SetFacts stores the facts an agent registered with
+func (s *HostStore) SetFacts(id string, facts *HostFacts, registeredAt time.Time) (*Host, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	host, exists := s.hosts[id]
+	if !exists {
+		return nil, ErrHostNotFound
+	}
+
+	host.Facts = facts
+	host.RegisteredAt = registeredAt
+	s.triggerSave()
+
+	hostCopy := *host
+	return &hostCopy, nil
+}
+
+// Delete removes a host and its samples. Its agent token stops working.
+func (s *HostStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.hosts[id]; !exists {
+		return ErrHostNotFound
+	}
+
+	delete(s.hosts, id)
+	delete(s.samples, id)
+	s.triggerSave()
+	return nil
+}
+
+// SetStatus records a host's status and reports whether it changed
+func (s *HostStore) SetStatus(id string, status HostStatus) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	host, exists := s.hosts[id]
+	if !exists || host.Status == status {
+		return false
+	}
+	host.Status = status
+	s.triggerSave()
+	return true
+}
+
+// GetSamples returns a host's recent samples since the given time, oldest first
+func (s *HostStore) GetSamples(id string, since time.Time) ([]HostSample, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, exists := s.hosts[id]; !exists {
+		return nil, ErrHostNotFound
+	}
+
+	samples := make([]HostSample, 0)
+	for _, sample := range s.samples[id] {
+		if !sample.Timestamp.Before(since) {
+			samples = append(samples, sample)
+		}
+	}
+	return samples, nil
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// IncidentRetention is how long resolved incidents are kept
+const IncidentRetention = 90 * 24 * time.Hour
+
+// Incident is a period during which a service was confirmed down
+type Incident struct {
+	ID          string     `json:"id"`
+	ServiceID   string     `json:"service_id"`
+	ServiceName string     `json:"service_name"`
+	StartedAt   time.Time  `json:"started_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"` // nil while ongoing
+	Message     string     `json:"message,omitempty"`     // Error that confirmed the outage
+}
+
+// Ongoing reports whether the incident has not been resolved yet
+func (i *Incident) Ongoing() bool {
+	return i.ResolvedAt == nil
+}
+
+// IncidentStore keeps the outage history of services
+type IncidentStore struct {
+	incidents map[string]*Incident
+	mu        sync.RWMutex
+	onSave    func() // callback when data changes
+}
+
+// NewIncidentStore creates a new incident store
+func NewIncidentStore() *IncidentStore {
+	return &IncidentStore{
+		incidents: make(map[string]*Incident),
+	}
+}
+
+// SetOnSave sets the callback for when incidents change
+func (s *IncidentStore) SetOnSave(onSave func()) {
+	s.onSave = onSave
+}
+
+// triggerSave calls the onSave callback if set
+func (s *IncidentStore) triggerSave() {
+	if s.onSave != nil {
+		go s.onSave()
+	}
+}
+
+// LoadFromMap loads incidents from persistence
+func (s *IncidentStore) LoadFromMap(incidents map[string]*Incident) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if incidents != nil {
+		s.incidents = incidents
+	}
+}
+
+// GetAllAsMap returns all incidents as a map (for persistence)
+func (s *IncidentStore) GetAllAsMap() map[string]*Incident {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	incidentsCopy := make(map[string]*Incident)
+	for k, v := range s.incidents {
+		incidentsCopy[k] = v
+	}
+	return incidentsCopy
+}
+
+// Open records a new incident, unless its service already has one ongoing.
+// Resolved incidents past IncidentRetention are dropped.
+func (s *IncidentStore) Open(incident *Incident) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, existing := range s.incidents {
+		if existing.ServiceID == incident.ServiceID && existing.Ongoing() {
+			return
+		}
+		if !existing.Ongoing() && incident.StartedAt.Sub(*existing.ResolvedAt) > IncidentRetention {
+			delete(s.incidents, id)
+		}
+	}
+
+	s.incidents[incident.ID] = incident
+	s.triggerSave()
+}
+
+// Resolve ends the ongoing incident of a service, if any
+func (s *IncidentStore) Resolve(serviceID string, resolvedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, incident := range s.incidents {
+		if incident.ServiceID == serviceID && incident.Ongoing() {
+			resolved := resolvedAt
+			incident.ResolvedAt = &resolved
+			s.triggerSave()
+			return
+		}
+	}
+}
+
+// GetSince returns the incidents of the given services that were ongoing at
+// or started after since, newest first
+func (s *IncidentStore) GetSince(serviceIDs map[string]bool, since time.Time) []*Incident {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	incidents := make([]*Incident, 0)
+	for _, incident := range s.incidents {
+		if !serviceIDs[incident.ServiceID] {
+			continue
+		}
+		if incident.Ongoing() || !incident.ResolvedAt.Before(since) {
+			copied := *incident
+			incidents = append(incidents, &copied)
+		}
+	}
+
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].StartedAt.After(incidents[j].StartedAt)
+	})
+
+	return incidents
+}
+
+// DeleteForService removes all incidents of a service
+func (s *IncidentStore) DeleteForService(serviceID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := false
+	for id, incident := range s.incidents {
+		if incident.ServiceID == serviceID {
+			delete(s.incidents, id)
+			deleted = true
+		}
+	}
+	if deleted {
+		s.triggerSave()
+	}
+}
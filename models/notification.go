@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+// ChannelType identifies the implementation behind a notification channel
+type ChannelType string
+
+const (
+	ChannelTelegram ChannelType = "telegram"
+	ChannelWebhook  ChannelType = "webhook"
+	ChannelEmail    ChannelType = "email"
+)
+
+// SMTPSecurity selects how the connection to the SMTP server is secured
+type SMTPSecurity string
+
+const (
+	SMTPSecurityNone     SMTPSecurity = "none"     // Plain connection, STARTTLS is not attempted
+	SMTPSecuritySTARTTLS SMTPSecurity = "starttls" // Upgrade with STARTTLS (usually port 587)
+	SMTPSecurityTLS      SMTPSecurity = "tls"      // Implicit TLS (usually port 465)
+)
+
+// NotificationChannel is a configured destination for alerts.
+// Exactly one of the type-specific config fields is set, matching Type.
+type NotificationChannel struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name" binding:"required"`
+	Type      ChannelType `json:"type" binding:"required"`
+	Enabled   bool        `json:"enabled"`
+	Default   bool        `json:"default"` // Used by services without their own channel list, and for system alerts
+	CreatedAt time.Time   `json:"created_at"`
+
+	Telegram *TelegramConfig `json:"telegram,omitempty"`
+	Webhook  *WebhookConfig  `json:"webhook,omitempty"`
+	Email    *EmailConfig    `json:"email,omitempty"`
+}
+
+// WebhookConfig holds the settings for an outgoing HTTP webhook
+type WebhookConfig struct {
+	URL          string            `json:"url" binding:"required"`
+	Method       string            `json:"method,omitempty"`        // POST (default), PUT, PATCH, ...
+	Headers      map[string]string `json:"headers,omitempty"`       // Extra request headers
+	BodyTemplate string            `json:"body_template,omitempty"` // Go text/template; empty sends the event as JSON
+	Secret       string            `json:"secret,omitempty"`        // HMAC-SHA256 signing key (optional)
+	MaxRetries   *int              `json:"max_retries,omitempty"`   // Retries after the first attempt (default: 3, max: 10)
+	Timeout      int               `json:"timeout,omitempty"`       // Per-attempt timeout in seconds (default: 10)
+}
+
+// EmailConfig holds the SMTP settings for an email channel
+type EmailConfig struct {
+	Host               string       `json:"host" binding:"required"`
+	Port               int          `json:"port,omitempty"`     // Defaults to 587, or 465 for implicit TLS
+	Security           SMTPSecurity `json:"security,omitempty"` // starttls (default), tls or none
+	Username           string       `json:"username,omitempty"` // Leave empty to send without authentication
+	Password           string       `json:"password,omitempty"`
+	From               string       `json:"from" binding:"required"`
+	To                 []string     `json:"to" binding:"required"`
+	InsecureSkipVerify bool         `json:"insecure_skip_verify,omitempty"` // Accept self-signed server certificates
+	Timeout            int          `json:"timeout,omitempty"`              // Connection timeout in seconds (default: 10)
+}
+
+// AlertEvent is the data describing an alert, passed to webhook templates
+type AlertEvent struct {
+	Event     NotificationEvent `json:"event"`
+	Timestamp time.Time         `json:"timestamp"`
+	Message   string            `json:"message"`
+	Service   *AlertService     `json:"service,omitempty"` // Service events only
+	System    *SystemAlert      `json:"system,omitempty"`  // System alerts only
+}
+
+// AlertService is the part of a service included in alerts. Request headers and
+// bodies are left out because they may carry credentials.
+type AlertService struct {
+	ID            string        `json:"id"`
+	Name          string        `json:"name"`
+	CheckType     CheckType     `json:"check_type"`
+	Target        string        `json:"target"` // URL, host:port or record the service checks
+	Status        ServiceStatus `json:"status"`
+	ResponseTime  int64         `json:"response_time"` // in milliseconds
+	ErrorMessage  string        `json:"error_message,omitempty"`
+	LastCheck     time.Time     `json:"last_check"`
+	SSLCertExpiry time.Time     `json:"ssl_cert_expiry,omitempty"`
+	SSLCertIssuer string        `json:"ssl_cert_issuer,omitempty"`
+	SSLDaysLeft   int           `json:"ssl_days_left,omitempty"`
+}
+
+// DeliveryAttempt records one attempt to deliver a notification
+type DeliveryAttempt struct {
+	ChannelID  string            `json:"channel_id"`
+	Event      NotificationEvent `json:"event"`
+	Attempt    int               `json:"attempt"` // 1 for the first try
+	Timestamp  time.Time         `json:"timestamp"`
+	Duration   int64             `json:"duration"` // in milliseconds
+	StatusCode int               `json:"status_code,omitempty"`
+	Success    bool              `json:"success"`
+	Error      string            `json:"error,omitempty"`
+	Response   string            `json:"response,omitempty"` // Start of the response body
+}
@@ -3,15 +3,27 @@ package models
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"sync"
 )
 
 // AppData represents all persistent application data
 type AppData struct {
-	Services          map[string]*MonitoredService `json:"services"`
-	TelegramConfig    *TelegramConfig              `json:"telegram_config"`
-	Histories         map[string]*ServiceHistory   `json:"histories"`
-	SystemAlertConfig *SystemAlertConfig           `json:"system_alert_config"`
+	Services             map[string]*MonitoredService    `json:"services"`
+	NotificationChannels map[string]*NotificationChannel `json:"notification_channels"`
+	SystemAlertConfig    *SystemAlertConfig              `json:"system_alert_config"`
+	Users                map[string]*User                `json:"users"`
+	APITokens            map[string]*APIToken            `json:"api_tokens"`
+	StatusPage           *StatusPageConfig               `json:"status_page,omitempty"`
+	Incidents            map[string]*Incident            `json:"incidents"`
+	Hosts                map[string]*Host                `json:"hosts"`
+
+	// Deprecated: check history is kept in the on-disk HistoryStore. Histories
+	// found here are imported into it on startup.
+	Histories map[string]*ServiceHistory `json:"histories,omitempty"`
+
+	// Deprecated: the global Telegram config is migrated to a notification channel on startup
+	TelegramConfig *TelegramConfig `json:"telegram_config,omitempty"`
 }
 
 // PersistenceManager handles saving and loading data to/from disk
@@ -27,7 +39,9 @@ func NewPersistenceManager(filePath string) *PersistenceManager {
 	}
 }
 
-// Save writes the app data to disk
+// Save writes the app data to disk. The data is written to a temporary file
+// in the same directory and renamed over the data file, so a crash mid-write
+// leaves the previous version intact.
 func (p *PersistenceManager) Save(data *AppData) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -37,7 +51,28 @@ func (p *PersistenceManager) Save(data *AppData) error {
 		return err
 	}
 
-	return os.WriteFile(p.filePath, jsonData, 0644)
+	temp, err := os.CreateTemp(filepath.Dir(p.filePath), filepath.Base(p.filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name()) // No-op once renamed
+
+	if _, err := temp.Write(jsonData); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), p.filePath)
 }
 
 // Load reads the app data from disk
@@ -48,11 +83,8 @@ func (p *PersistenceManager) Load() (*AppData, error) {
 	// If file doesn't exist, return empty data
 	if _, err := os.Stat(p.filePath); os.IsNotExist(err) {
 		return &AppData{
-			Services:   make(map[string]*MonitoredService),
-			Histories:  make(map[string]*ServiceHistory),
-			TelegramConfig: &TelegramConfig{
-				Enabled: false,
-			},
+			Services:             make(map[string]*MonitoredService),
+			NotificationChannels: make(map[string]*NotificationChannel),
 			SystemAlertConfig: &SystemAlertConfig{
 				DiskSpaceThreshold: 80.0,
 				CPUThreshold:       90.0,
@@ -76,13 +108,8 @@ func (p *PersistenceManager) Load() (*AppData, error) {
 	if appData.Services == nil {
 		appData.Services = make(map[string]*MonitoredService)
 	}
-	if appData.Histories == nil {
-		appData.Histories = make(map[string]*ServiceHistory)
-	}
-	if appData.TelegramConfig == nil {
-		appData.TelegramConfig = &TelegramConfig{
-			Enabled: false,
-		}
+	if appData.NotificationChannels == nil {
+		appData.NotificationChannels = make(map[string]*NotificationChannel)
 	}
 	if appData.SystemAlertConfig == nil {
 		appData.SystemAlertConfig = &SystemAlertConfig{
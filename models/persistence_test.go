@@ -0,0 +1,47 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPersistenceManagerSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "monitoring_data.json")
+	persistence := NewPersistenceManager(path)
+
+	for _, name := range []string{"first", "second"} {
+		data := &AppData{Services: map[string]*MonitoredService{"svc": {ID: "svc", Name: name}}}
+		if err := persistence.Save(data); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+
+		loaded, err := persistence.Load()
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if service := loaded.Services["svc"]; service == nil || service.Name != name {
+			t.Fatalf("loaded services = %+v, want %s", loaded.Services, name)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Fatalf("directory holds %v, want only the data file", names)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("data file mode = %v, want 0644", info.Mode().Perm())
+	}
+}
@@ -1,32 +1,46 @@
 package models
 
-import "time"
+import (
+	"net/url"
+	"strings"
+	"time"
+)
 
 // ServiceStatus represents the current status of a monitored service
 type ServiceStatus string
 
 const (
 	StatusUp      ServiceStatus = "up"
-	StatusDown    ServiceStatus = "down"
-	StatusUnknown ServiceStatus = "unknown"
+	StatusDown     ServiceStatus = "down"
+	StatusDegraded ServiceStatus = "degraded" // Reachable, but outside configured loss/latency limits
+	StatusPending  ServiceStatus = "pending"  // Last result differs from the confirmed status, awaiting confirmation
+	StatusUnknown  ServiceStatus = "unknown"
 )
 
+// IsAvailable reports whether the service is reachable (up or degraded)
+func (s ServiceStatus) IsAvailable() bool {
+	return s == StatusUp || s == StatusDegraded
+}
+
 // CheckType represents the type of health check
 type CheckType string
 
 const (
-	CheckTypeHTTP CheckType = "http"
-	CheckTypeTCP  CheckType = "tcp"
-	CheckTypeUDP  CheckType = "udp"
+	CheckTypeHTTP  CheckType = "http"
+	CheckTypeTCP   CheckType = "tcp"
+	CheckTypeUDP   CheckType = "udp"
+	CheckTypeDNS   CheckType = "dns"
+	CheckTypeICMP  CheckType = "icmp"
+	CheckTypeAgent CheckType = "agent" // Fetches the monitoring agent's metrics and checks them against thresholds
 )
 
 // MonitoredService represents a service or website to be monitored
 type MonitoredService struct {
 	ID              string        `json:"id"`
 	Name            string        `json:"name" binding:"required"`
-	CheckType       CheckType     `json:"check_type"`                // http, tcp, or udp
-	URL             string        `json:"url"`                       // For HTTP checks
-	Host            string        `json:"host"`                      // For TCP/UDP checks
+	CheckType       CheckType     `json:"check_type"`                // http, tcp, udp, dns, icmp or agent
+	URL             string        `json:"url"`                       // For HTTP checks, or the agent's /metrics URL for agent checks
+	Host            string        `json:"host"`                      // For TCP/UDP/ICMP checks, or the name to query for DNS checks
 	Port            int           `json:"port"`                      // For TCP/UDP checks
 	CheckInterval   int           `json:"check_interval"`            // in seconds
 	Timeout         int           `json:"timeout"`                   // in seconds
@@ -42,12 +56,123 @@ type MonitoredService struct {
 	SSLDaysLeft     int           `json:"ssl_days_left,omitempty"`   // Days until SSL expires
 	SSLAlertSent    bool          `json:"ssl_alert_sent,omitempty"`  // Track if SSL expiry alert was sent
 
-	// Telegram alert overrides (optional, falls back to default if not set)
-	TelegramBotToken string `json:"telegram_bot_token,omitempty"` // Override bot token for this service
-	TelegramChatID   string `json:"telegram_chat_id,omitempty"`   // Override chat ID for this service
-	TelegramEnabled  *bool  `json:"telegram_enabled,omitempty"`   // Override enabled status (nil = use default)
+	// HTTP request and response assertions (optional, HTTP checks only)
+	HTTPMethod          string            `json:"http_method,omitempty"`           // GET (default), POST, HEAD, ...
+	HTTPHeaders         map[string]string `json:"http_headers,omitempty"`          // Extra request headers (also sent by agent checks, e.g. Authorization)
+	HTTPBody            string            `json:"http_body,omitempty"`             // Request body
+	ExpectedStatusCodes []string          `json:"expected_status_codes,omitempty"` // e.g. "200", "200-204", "3xx" (default: 200-399)
+	BodyAssertions      []BodyAssertion   `json:"body_assertions,omitempty"`       // All must pass for the service to be up
+
+	// DNS check settings (DNS checks only)
+	DNSServer     string       `json:"dns_server,omitempty"`      // Resolver as host or host:port (default: first nameserver in /etc/resolv.conf)
+	DNSRecordType string       `json:"dns_record_type,omitempty"` // A (default), AAAA, CNAME, MX, TXT, NS or SOA
+	DNSExpected   []string     `json:"dns_expected,omitempty"`    // Expected answers; empty means any answer is accepted
+	DNSMatchMode  DNSMatchMode `json:"dns_match_mode,omitempty"`  // contains (default) or exact
+
+	// ICMP ping settings (ICMP checks only, 0 disables a limit)
+	PingCount          int     `json:"ping_count,omitempty"`           // Echo requests per check (default: 4, max: 20)
+	DegradedPacketLoss float64 `json:"degraded_packet_loss,omitempty"` // Loss % at or above which the service is degraded
+	DownPacketLoss     float64 `json:"down_packet_loss,omitempty"`     // Loss % at or above which the service is down (default: 100)
+	DegradedLatency    int64   `json:"degraded_latency,omitempty"`     // Average RTT in ms above which the service is degraded
+	DownLatency        int64   `json:"down_latency,omitempty"`         // Average RTT in ms above which the service is down
+
+	// Agent resource thresholds (agent checks only, 0 disables a limit)
+	AgentCPUThreshold    float64            `json:"agent_cpu_threshold,omitempty"`    // CPU usage % above which the host is down
+	AgentMemoryThreshold float64            `json:"agent_memory_threshold,omitempty"` // Memory usage %
+	AgentSwapThreshold   float64            `json:"agent_swap_threshold,omitempty"`   // Swap usage %
+	AgentDiskThreshold   float64            `json:"agent_disk_threshold,omitempty"`   // Usage % of every filesystem
+	AgentDiskThresholds  map[string]float64 `json:"agent_disk_thresholds,omitempty"`  // Usage % per mount point, overriding AgentDiskThreshold (0 skips the mount)
+	AgentInodeThreshold  float64            `json:"agent_inode_threshold,omitempty"`  // Inode usage % of every filesystem
+	AgentLoadThreshold   float64            `json:"agent_load_threshold,omitempty"`   // 1-minute load average
+	AgentRAIDDegraded    bool               `json:"agent_raid_degraded,omitempty"`    // Down while a software RAID array is degraded
+	AgentProcesses       []string           `json:"agent_processes,omitempty"`        // Processes that must be running
+	AgentPorts           []int              `json:"agent_ports,omitempty"`            // Ports that must be listening
+
+	// Status confirmation (0 or 1 means a single result is enough)
+	DownAfter  int `json:"down_after,omitempty"`  // Consecutive failed checks before the service is marked down
+	UpAfter    int `json:"up_after,omitempty"`    // Consecutive successful checks before a down service is marked up
+	RetryCount int `json:"retry_count,omitempty"` // Immediate re-checks of a failed check before it counts as a failure
+
+	// Confirmation state, maintained by the monitor
+	ConfirmedStatus      ServiceStatus `json:"confirmed_status,omitempty"` // Status alerts were last sent for
+	ConsecutiveFailures  int           `json:"consecutive_failures"`
+	ConsecutiveSuccesses int           `json:"consecutive_successes"`
+
+	// Alert routing
+	NotificationChannels []string `json:"notification_channels,omitempty"` // Channel IDs to alert (empty = default channels)
+	AlertsDisabled       bool     `json:"alerts_disabled,omitempty"`       // Suppress all alerts for this service
+
+	// Free-form labels for grouping and filtering, e.g. "prod" or "api"
+	Tags []string `json:"tags,omitempty"`
+
+	// Public badges (opt-in); set through /api/services/:id/badge
+	BadgeToken string `json:"badge_token,omitempty"` // Serves badges without authentication at /badge/<token>/...
+
+	// Deprecated: legacy per-service Telegram overrides. They are migrated to
+	// notification channels on startup and only kept to read old data files.
+	TelegramBotToken string `json:"telegram_bot_token,omitempty"`
+	TelegramChatID   string `json:"telegram_chat_id,omitempty"`
+	TelegramEnabled  *bool  `json:"telegram_enabled,omitempty"`
+}
+
+// Redacted returns a copy of the service for users who can't edit it: the
+// request headers and body, which often carry credentials, and any legacy
+// Telegram override are removed, and a password in the URL is masked
+func (s *MonitoredService) Redacted() *MonitoredService {
+	redacted := *s
+	redacted.HTTPHeaders = nil
+	redacted.HTTPBody = ""
+	redacted.TelegramBotToken = ""
+	redacted.TelegramChatID = ""
+	if parsed, err := url.Parse(s.URL); err == nil && parsed.User != nil {
+		redacted.URL = parsed.Redacted()
+	}
+	return &redacted
 }
 
+// NormalizeTags trims tags and drops empty and duplicate ones, keeping their
+// order. Tags containing commas are split, so the metrics "tags" label stays unambiguous.
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, value := range tags {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "" || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// AssertionType represents the kind of response body assertion
+type AssertionType string
+
+const (
+	AssertContains    AssertionType = "contains"     // Body contains Value
+	AssertNotContains AssertionType = "not_contains" // Body does not contain Value
+	AssertRegex       AssertionType = "regex"        // Body matches the regular expression in Value
+	AssertJSONPath    AssertionType = "json_path"    // JSON value at Path equals Value
+)
+
+// BodyAssertion describes a check performed on an HTTP response body
+type BodyAssertion struct {
+	Type  AssertionType `json:"type"`
+	Path  string        `json:"path,omitempty"` // For json_path, e.g. "$.status" or "data.items[0].state"
+	Value string        `json:"value"`
+}
+
+// DNSMatchMode controls how DNS answers are compared with the expected values
+type DNSMatchMode string
+
+const (
+	DNSMatchContains DNSMatchMode = "contains" // Every expected value appears in the answers
+	DNSMatchExact    DNSMatchMode = "exact"    // Answers equal the expected values exactly
+)
+
 // HealthCheckResult represents the result of a health check
 type HealthCheckResult struct {
 	ServiceID     string
@@ -58,4 +183,16 @@ type HealthCheckResult struct {
 	SSLCertExpiry time.Time
 	SSLCertIssuer string
 	SSLDaysLeft   int
+	Ping          *PingStats
+}
+
+// PingStats holds the outcome of an ICMP check
+type PingStats struct {
+	Sent       int     `json:"sent"`
+	Received   int     `json:"received"`
+	PacketLoss float64 `json:"packet_loss"` // in percent
+	MinRTT     float64 `json:"min_rtt"`     // in milliseconds
+	AvgRTT     float64 `json:"avg_rtt"`     // in milliseconds
+	MaxRTT     float64 `json:"max_rtt"`     // in milliseconds
+	Jitter     float64 `json:"jitter"`      // mean difference between consecutive RTTs, in milliseconds
 }
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// StatusPageConfig chooses which services appear on the public status page
+// and how they are presented. Only display names are published; service
+// names, URLs and error messages stay private.
+type StatusPageConfig struct {
+	Enabled     bool              `json:"enabled"`
+	Title       string            `json:"title"`
+	Description string            `json:"description,omitempty"`
+	Groups      []StatusPageGroup `json:"groups"`
+}
+
+// StatusPageGroup is a named group of components, e.g. "API" or "Websites"
+type StatusPageGroup struct {
+	Name       string                `json:"name"`
+	Components []StatusPageComponent `json:"components"`
+}
+
+// StatusPageComponent publishes one monitored service under a display name
+type StatusPageComponent struct {
+	ServiceID   string `json:"service_id"`
+	DisplayName string `json:"display_name"`
+}
+
+// ComponentStatus is the public status of a component
+type ComponentStatus string
+
+const (
+	ComponentOperational   ComponentStatus = "operational"
+	ComponentDegraded      ComponentStatus = "degraded"
+	ComponentOutage        ComponentStatus = "outage"
+	ComponentUnknown       ComponentStatus = "unknown"
+	ComponentPartialOutage ComponentStatus = "partial_outage" // Overall status only
+	ComponentMajorOutage   ComponentStatus = "major_outage"   // Overall status only
+)
+
+// StatusPage is the public view of the status page, served as HTML and JSON
+type StatusPage struct {
+	Title       string                `json:"title"`
+	Description string                `json:"description,omitempty"`
+	Status      ComponentStatus       `json:"status"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+	Groups      []StatusPageGroupView `json:"groups"`
+	Incidents   []StatusPageIncident  `json:"incidents"`
+}
+
+// StatusPageGroupView is a group of components on the public page
+type StatusPageGroupView struct {
+	Name       string                    `json:"name"`
+	Status     ComponentStatus           `json:"status"`
+	Components []StatusPageComponentView `json:"components"`
+}
+
+// StatusPageComponentView is the public status and uptime of one component
+type StatusPageComponentView struct {
+	Name   string          `json:"name"`
+	Status ComponentStatus `json:"status"`
+	Uptime *float64        `json:"uptime"` // Percentage over all days shown; nil without checks
+	Days   []StatusPageDay `json:"days"`   // Oldest first, one per UTC day
+}
+
+// StatusPageDay is one bar of a component's uptime history
+type StatusPageDay struct {
+	Date      string   `json:"date"`   // YYYY-MM-DD (UTC)
+	Uptime    *float64 `json:"uptime"` // Percentage; nil without checks
+	Checks    int      `json:"checks"`
+	DownCount int      `json:"down_count"`
+}
+
+// StatusPageIncident is an outage of a component, without internal details
+type StatusPageIncident struct {
+	Component  string     `json:"component"`
+	StartedAt  time.Time  `json:"started_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"` // nil while ongoing
+}
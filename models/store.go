@@ -11,12 +11,29 @@ var (
 	ErrServiceExists   = errors.New("service already exists")
 )
 
+// ServiceEventType describes the kind of change made to the store
+type ServiceEventType string
+
+const (
+	ServiceCreated ServiceEventType = "created"
+	ServiceUpdated ServiceEventType = "updated"
+	ServiceDeleted ServiceEventType = "deleted"
+)
+
+// ServiceEvent is delivered to store listeners after a service changes
+type ServiceEvent struct {
+	Type    ServiceEventType
+	Service *MonitoredService
+}
+
 // ServiceStore manages the storage of monitored services
 type ServiceStore struct {
 	services    map[string]*MonitoredService
 	mu          sync.RWMutex
 	persistence *PersistenceManager
 	onSave      func() // callback when data changes
+	listeners   []func(ServiceEvent)
+	listenersMu sync.RWMutex
 }
 
 // NewServiceStore creates a new service store
@@ -58,17 +75,39 @@ func (s *ServiceStore) triggerSave() {
 	}
 }
 
+// Subscribe registers a listener that is called after every add, update or delete.
+// Listeners are invoked without the store lock held, so they may read from the store.
+func (s *ServiceStore) Subscribe(listener func(ServiceEvent)) {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// notify delivers an event to all registered listeners
+func (s *ServiceStore) notify(eventType ServiceEventType, service *MonitoredService) {
+	s.listenersMu.RLock()
+	listeners := s.listeners
+	s.listenersMu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(ServiceEvent{Type: eventType, Service: service})
+	}
+}
+
 // Add adds a new service to the store
 func (s *ServiceStore) Add(service *MonitoredService) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 
 	if _, exists := s.services[service.ID]; exists {
+		s.mu.Unlock()
 		return ErrServiceExists
 	}
 
 	s.services[service.ID] = service
 	s.triggerSave()
+	s.mu.Unlock()
+
+	s.notify(ServiceCreated, service)
 	return nil
 }
 
@@ -85,6 +124,22 @@ func (s *ServiceStore) Get(id string) (*MonitoredService, error) {
 	return service, nil
 }
 
+// GetByBadgeToken retrieves the service with the given public badge token
+func (s *ServiceStore) GetByBadgeToken(token string) (*MonitoredService, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if token == "" {
+		return nil, ErrServiceNotFound
+	}
+	for _, service := range s.services {
+		if service.BadgeToken == token {
+			return service, nil
+		}
+	}
+	return nil, ErrServiceNotFound
+}
+
 // GetAll returns all monitored services sorted by creation date (newest first)
 func (s *ServiceStore) GetAll() []*MonitoredService {
 	s.mu.RLock()
@@ -106,27 +161,57 @@ func (s *ServiceStore) GetAll() []*MonitoredService {
 // Update updates an existing service
 func (s *ServiceStore) Update(service *MonitoredService) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 
 	if _, exists := s.services[service.ID]; !exists {
+		s.mu.Unlock()
 		return ErrServiceNotFound
 	}
 
 	s.services[service.ID] = service
 	s.triggerSave()
+	s.mu.Unlock()
+
+	s.notify(ServiceUpdated, service)
 	return nil
 }
 
+// Modify changes a service under the store lock, so concurrent changes (a
+// check result and a user's edit) don't undo each other. The change is made
+// to a copy that replaces the stored service; services returned by Get are
+// never modified in place.
+func (s *ServiceStore) Modify(id string, modify func(*MonitoredService)) (*MonitoredService, error) {
+	s.mu.Lock()
+
+	current, exists := s.services[id]
+	if !exists {
+		s.mu.Unlock()
+		return nil, ErrServiceNotFound
+	}
+
+	updated := *current
+	modify(&updated)
+	s.services[id] = &updated
+	s.triggerSave()
+	s.mu.Unlock()
+
+	s.notify(ServiceUpdated, &updated)
+	return &updated, nil
+}
+
 // Delete removes a service from the store
 func (s *ServiceStore) Delete(id string) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 
-	if _, exists := s.services[id]; !exists {
+	service, exists := s.services[id]
+	if !exists {
+		s.mu.Unlock()
 		return ErrServiceNotFound
 	}
 
 	delete(s.services, id)
 	s.triggerSave()
+	s.mu.Unlock()
+
+	s.notify(ServiceDeleted, service)
 	return nil
 }
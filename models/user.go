@@ -0,0 +1,357 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUserNotFound  = errors.New("user not found")
+	ErrUsernameTaken = errors.New("username already taken")
+	ErrTokenNotFound = errors.New("API token not found")
+	ErrInvalidRole   = errors.New("role must be viewer, operator or admin")
+)
+
+// Role controls what a user may do. Each role includes the permissions of the ones below it.
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"   // Read services, statistics, history and system info
+	RoleOperator Role = "operator" // Also create, update and check services
+	RoleAdmin    Role = "admin"    // Also manage notification channels, users and settings
+)
+
+// roleLevels orders the roles from least to most privileged
+var roleLevels = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	_, ok := roleLevels[r]
+	return ok
+}
+
+// Allows reports whether r grants at least the permissions of required
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && roleLevels[r] >= roleLevels[required]
+}
+
+// User is a local account that can sign in to the dashboard and API
+type User struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	Role         Role      `json:"role"`
+	PasswordHash string    `json:"password_hash,omitempty"` // bcrypt; cleared in API responses
+	CreatedAt    time.Time `json:"created_at"`
+	LastLogin    time.Time `json:"last_login,omitempty"`
+}
+
+// Public returns a copy of the user without the password hash
+func (u *User) Public() *User {
+	public := *u
+	public.PasswordHash = ""
+	return &public
+}
+
+// APIToken is a personal token that authenticates scripts as its user.
+// Only a hash of the token is stored; the token itself is shown once on creation.
+type APIToken struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`               // Start of the token, to recognise it in lists
+	TokenHash string     `json:"token_hash,omitempty"` // SHA-256; cleared in API responses
+	CreatedAt time.Time  `json:"created_at"`
+	LastUsed  time.Time  `json:"last_used,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Public returns a copy of the token without its hash
+func (t *APIToken) Public() *APIToken {
+	public := *t
+	public.TokenHash = ""
+	return &public
+}
+
+// Expired reports whether the token has passed its expiry date
+func (t *APIToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && now.After(*t.ExpiresAt)
+}
+
+// UserStore manages user accounts and their API tokens
+type UserStore struct {
+	users  map[string]*User
+	tokens map[string]*APIToken
+	mu     sync.RWMutex
+	onSave func() // callback when data changes
+}
+
+// NewUserStore creates a new user store
+func NewUserStore() *UserStore {
+	return &UserStore{
+		users:  make(map[string]*User),
+		tokens: make(map[string]*APIToken),
+	}
+}
+
+// SetOnSave sets the callback for when users or tokens change
+func (s *UserStore) SetOnSave(onSave func()) {
+	s.onSave = onSave
+}
+
+// triggerSave calls the onSave callback if set
+func (s *UserStore) triggerSave() {
+	if s.onSave != nil {
+		go s.onSave()
+	}
+}
+
+// LoadFromMap loads users and tokens from persistence
+func (s *UserStore) LoadFromMap(users map[string]*User, tokens map[string]*APIToken) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if users != nil {
+		s.users = users
+	}
+	for _, user := range s.users {
+		// Accounts created before roles existed had full access
+		if user.Role == "" {
+			user.Role = RoleAdmin
+		}
+	}
+	if tokens != nil {
+		s.tokens = tokens
+	}
+}
+
+// GetAllAsMap returns all users as a map (for persistence)
+func (s *UserStore) GetAllAsMap() map[string]*User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	usersCopy := make(map[string]*User)
+	for k, v := range s.users {
+		usersCopy[k] = v
+	}
+	return usersCopy
+}
+
+// GetTokensAsMap returns all API tokens as a map (for persistence)
+func (s *UserStore) GetTokensAsMap() map[string]*APIToken {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokensCopy := make(map[string]*APIToken)
+	for k, v := range s.tokens {
+		tokensCopy[k] = v
+	}
+	return tokensCopy
+}
+
+// Count returns the number of users
+func (s *UserStore) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.users)
+}
+
+// Add adds a new user. Usernames are unique, ignoring case.
+func (s *UserStore) Add(user *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findByUsername(user.Username) != nil {
+		return ErrUsernameTaken
+	}
+
+	s.users[user.ID] = user
+	s.triggerSave()
+	return nil
+}
+
+// Get retrieves a user by ID
+func (s *UserStore) Get(id string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, exists := s.users[id]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// GetByUsername retrieves a user by username, ignoring case
+func (s *UserStore) GetByUsername(username string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user := s.findByUsername(username)
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *UserStore) findByUsername(username string) *User {
+	for _, user := range s.users {
+		if strings.EqualFold(user.Username, username) {
+			return user
+		}
+	}
+	return nil
+}
+
+// GetAll returns all users sorted by username
+func (s *UserStore) GetAll() []*User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]*User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return strings.ToLower(users[i].Username) < strings.ToLower(users[j].Username)
+	})
+
+	return users
+}
+
+// CountRole returns the number of users with the given role
+func (s *UserStore) CountRole(role Role) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, user := range s.users {
+		if user.Role == role {
+			count++
+		}
+	}
+	return count
+}
+
+// Update replaces an existing user
+func (s *UserStore) Update(user *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[user.ID]; !exists {
+		return ErrUserNotFound
+	}
+	if other := s.findByUsername(user.Username); other != nil && other.ID != user.ID {
+		return ErrUsernameTaken
+	}
+
+	s.users[user.ID] = user
+	s.triggerSave()
+	return nil
+}
+
+// Delete removes a user and all of their API tokens
+func (s *UserStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[id]; !exists {
+		return ErrUserNotFound
+	}
+
+	delete(s.users, id)
+	for tokenID, token := range s.tokens {
+		if token.UserID == id {
+			delete(s.tokens, tokenID)
+		}
+	}
+	s.triggerSave()
+	return nil
+}
+
+// AddToken stores a new API token
+func (s *UserStore) AddToken(token *APIToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[token.UserID]; !exists {
+		return ErrUserNotFound
+	}
+
+	s.tokens[token.ID] = token
+	s.triggerSave()
+	return nil
+}
+
+// GetTokensForUser returns a user's API tokens, newest first
+func (s *UserStore) GetTokensForUser(userID string) []*APIToken {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := make([]*APIToken, 0)
+	for _, token := range s.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.After(tokens[j].CreatedAt)
+	})
+
+	return tokens
+}
+
+// GetTokenByHash finds the API token with the given hash
+func (s *UserStore) GetTokenByHash(hash string) (*APIToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, token := range s.tokens {
+		if token.TokenHash == hash {
+			return token, nil
+		}
+	}
+	return nil, ErrTokenNotFound
+}
+
+// TouchToken records that a token was used. To avoid rewriting the data file
+// on every request, the change is only saved once a minute per token.
+func (s *UserStore) TouchToken(id string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, exists := s.tokens[id]
+	if !exists {
+		return
+	}
+
+	save := now.Sub(token.LastUsed) > time.Minute
+	token.LastUsed = now
+	if save {
+		s.triggerSave()
+	}
+}
+
+// DeleteToken removes one of a user's API tokens
+func (s *UserStore) DeleteToken(userID, tokenID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, exists := s.tokens[tokenID]
+	if !exists || token.UserID != userID {
+		return ErrTokenNotFound
+	}
+
+	delete(s.tokens, tokenID)
+	s.triggerSave()
+	return nil
+}
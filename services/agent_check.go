@@ -0,0 +1,151 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"monitoring/models"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxAgentResponseSize limits the size of the metrics read from an agent
+const maxAgentResponseSize = 1 << 20
+
+// checkAgent fetches the metrics of a host running the monitoring agent and
+// checks them against the service's thresholds. The host is down if the agent
+// can't be reached or any threshold is exceeded; the error message names each
+// failing metric.
+func (m *MonitorService) checkAgent(service *models.MonitoredService, result *models.HealthCheckResult) *models.HealthCheckResult {
+	timeout := time.Duration(service.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	start := time.Now()
+	metrics, err := fetchAgentMetrics(service, timeout)
+	result.ResponseTime = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Status = models.StatusDown
+		result.ErrorMessage = fmt.Sprintf("Agent check failed: %v", err)
+		return result
+	}
+
+	failures := evaluateAgentThresholds(service, metrics)
+	if len(failures) == 0 {
+		result.Status = models.StatusUp
+		return result
+	}
+
+	host := metrics.Hostname
+	if host == "" {
+		host = service.Name
+	}
+	result.Status = models.StatusDown
+	result.ErrorMessage = host + ": " + strings.Join(failures, "; ")
+	return result
+}
+
+// fetchAgentMetrics requests and decodes an agent's JSON metrics
+func fetchAgentMetrics(service *models.MonitoredService, timeout time.Duration) (*models.AgentMetrics, error) {
+	req, err := http.NewRequest(http.MethodGet, service.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	for name, value := range service.HTTPHeaders {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	// The agent also serves Prometheus text; ask for JSON explicitly
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP status code: %d", resp.StatusCode)
+	}
+
+	var metrics models.AgentMetrics
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxAgentResponseSize)).Decode(&metrics); err != nil {
+		return nil, fmt.Errorf("invalid metrics response: %w", err)
+	}
+	return &metrics, nil
+}
+
+// evaluateAgentThresholds returns a description of every threshold the
+// metrics exceed, of every degraded RAID array if those are checked, and of
+// every required process or port that is missing
+func evaluateAgentThresholds(service *models.MonitoredService, metrics *models.AgentMetrics) []string {
+	var failures []string
+	exceeds := func(name string, value, threshold float64, unit string) {
+		if threshold > 0 && value > threshold {
+			failures = append(failures, fmt.Sprintf("%s %.1f%s is above %.1f%s", name, value, unit, threshold, unit))
+		}
+	}
+
+	exceeds("CPU usage", metrics.CPU.UsagePercent, service.AgentCPUThreshold, "%")
+	exceeds("memory usage", metrics.Memory.UsagePercent, service.AgentMemoryThreshold, "%")
+	exceeds("swap usage", metrics.Memory.SwapPercent, service.AgentSwapThreshold, "%")
+
+	for _, disk := range metrics.Disk {
+		threshold, ok := service.AgentDiskThresholds[disk.Mount]
+		if !ok {
+			threshold = service.AgentDiskThreshold
+		}
+		exceeds("disk usage on "+disk.Mount, disk.UsagePercent, threshold, "%")
+		exceeds("inode usage on "+disk.Mount, disk.InodesUsagePercent, service.AgentInodeThreshold, "%")
+	}
+
+	// Mounts with their own threshold are expected to exist
+	mounts := make([]string, 0, len(service.AgentDiskThresholds))
+	for mount, threshold := range service.AgentDiskThresholds {
+		if threshold > 0 && !slices.ContainsFunc(metrics.Disk, func(d models.AgentDiskMetrics) bool { return d.Mount == mount }) {
+			mounts = append(mounts, mount)
+		}
+	}
+	sort.Strings(mounts)
+	for _, mount := range mounts {
+		failures = append(failures, fmt.Sprintf("filesystem %s is not mounted", mount))
+	}
+
+	for _, array := range metrics.RAID {
+		if service.AgentRAIDDegraded && array.Degraded {
+			failures = append(failures, fmt.Sprintf("RAID array %s is %s (%d of %d devices active)", array.Device, array.Status, array.ActiveDevices, array.Devices))
+		}
+	}
+
+	if service.AgentLoadThreshold > 0 && metrics.CPU.LoadAverage != nil {
+		exceeds("load average", metrics.CPU.Load1(), service.AgentLoadThreshold, "")
+	}
+
+	for _, name := range service.AgentProcesses {
+		running := slices.ContainsFunc(metrics.Services, func(p models.AgentServiceMetric) bool {
+			return p.Name == name && p.Status == "running"
+		})
+		if !running {
+			failures = append(failures, fmt.Sprintf("process %s is not running", name))
+		}
+	}
+
+	for _, port := range service.AgentPorts {
+		listening := slices.ContainsFunc(metrics.Ports, func(p models.AgentPortMetric) bool {
+			return p.Port == port && p.Status == "listening"
+		})
+		if !listening {
+			failures = append(failures, fmt.Sprintf("port %d is not listening", port))
+		}
+	}
+
+	return failures
+}
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"monitoring/models"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// SessionTTL is how long a dashboard session stays valid after login
+	SessionTTL = 7 * 24 * time.Hour
+
+	// APITokenPrefix starts every personal API token, so they are easy to spot in configs
+	APITokenPrefix = "mon_"
+
+	minPasswordLength = 8
+	maxPasswordLength = 72 // bcrypt ignores anything longer
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidSession     = errors.New("session is invalid or expired")
+	ErrInvalidToken       = errors.New("API token is invalid or expired")
+	ErrWeakPassword       = fmt.Errorf("password must be between %d and %d characters", minPasswordLength, maxPasswordLength)
+	ErrInvalidUsername    = errors.New("username must be 1-64 characters without spaces")
+	ErrLastAdmin          = errors.New("at least one admin account is required")
+)
+
+// dummyPasswordHash is compared against when a username does not exist, so
+// failed logins take the same time whether or not the user exists
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+
+// session is a signed-in dashboard session, keyed by the hash of its cookie value
+type session struct {
+	userID    string
+	expiresAt time.Time
+}
+
+// AuthService manages user accounts, dashboard sessions and API tokens
+type AuthService struct {
+	users    *models.UserStore
+	sessions map[string]*session
+	mu       sync.Mutex
+}
+
+// NewAuthService creates an auth service backed by the given user store
+func NewAuthService(users *models.UserStore) *AuthService {
+	return &AuthService{
+		users:    users,
+		sessions: make(map[string]*session),
+	}
+}
+
+// Bootstrap creates the initial admin account when no users exist. If password
+// is empty, a random one is generated and returned so it can be shown once.
+func (a *AuthService) Bootstrap(username, password string) (bool, string, error) {
+	if a.users.Count() > 0 {
+		return false, "", nil
+	}
+
+	if username == "" {
+		username = "admin"
+	}
+	if password == "" {
+		generated, err := randomToken(12)
+		if err != nil {
+			return false, "", err
+		}
+		password = generated
+	}
+
+	if _, err := a.CreateUser(username, password, models.RoleAdmin); err != nil {
+		return false, "", err
+	}
+	return true, password, nil
+}
+
+// CreateUser adds a new account with a bcrypt-hashed password
+func (a *AuthService) CreateUser(username, password string, role models.Role) (*models.User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" || len(username) > 64 || strings.ContainsAny(username, " \t\r\n") {
+		return nil, ErrInvalidUsername
+	}
+	if !role.Valid() {
+		return nil, models.ErrInvalidRole
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		ID:           uuid.New().String(),
+		Username:     username,
+		Role:         role,
+		PasswordHash: hash,
+		CreatedAt:    time.Now(),
+	}
+	if err := a.users.Add(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// DeleteUser removes an account, its API tokens and its sessions
+func (a *AuthService) DeleteUser(id string) error {
+	user, err := a.users.Get(id)
+	if err != nil {
+		return err
+	}
+	if user.Role == models.RoleAdmin && a.users.CountRole(models.RoleAdmin) == 1 {
+		return ErrLastAdmin
+	}
+
+	if err := a.users.Delete(id); err != nil {
+		return err
+	}
+	a.endSessionsForUser(id, "")
+	return nil
+}
+
+// SetRole changes a user's role. The last admin cannot be demoted.
+func (a *AuthService) SetRole(userID string, role models.Role) (*models.User, error) {
+	if !role.Valid() {
+		return nil, models.ErrInvalidRole
+	}
+
+	user, err := a.users.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == models.RoleAdmin && role != models.RoleAdmin && a.users.CountRole(models.RoleAdmin) == 1 {
+		return nil, ErrLastAdmin
+	}
+
+	updated := *user
+	updated.Role = role
+	if err := a.users.Update(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// SetPassword replaces a user's password and signs out their other sessions.
+// keepSession is the cookie value of the session to leave signed in, if any.
+func (a *AuthService) SetPassword(userID, password, keepSession string) error {
+	user, err := a.users.Get(userID)
+	if err != nil {
+		return err
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	updated := *user
+	updated.PasswordHash = hash
+	if err := a.users.Update(&updated); err != nil {
+		return err
+	}
+
+	a.endSessionsForUser(userID, keepSession)
+	return nil
+}
+
+// CheckPassword verifies a user's password
+func (a *AuthService) CheckPassword(user *models.User, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil
+}
+
+// Login checks credentials and starts a session. It returns the session value
+// to store in the cookie.
+func (a *AuthService) Login(username, password string) (*models.User, string, error) {
+	user, err := a.users.GetByUsername(username)
+	if err != nil {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, "", ErrInvalidCredentials
+	}
+	if !a.CheckPassword(user, password) {
+		return nil, "", ErrInvalidCredentials
+	}
+
+	value, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	a.mu.Lock()
+	a.pruneSessions(now)
+	a.sessions[hashToken(value)] = &session{userID: user.ID, expiresAt: now.Add(SessionTTL)}
+	a.mu.Unlock()
+
+	updated := *user
+	updated.LastLogin = now
+	a.users.Update(&updated)
+
+	return &updated, value, nil
+}
+
+// Logout ends a session
+func (a *AuthService) Logout(value string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.sessions, hashToken(value))
+}
+
+// ValidateSession returns the user signed in with a session cookie value
+func (a *AuthService) ValidateSession(value string) (*models.User, error) {
+	if value == "" {
+		return nil, ErrInvalidSession
+	}
+
+	a.mu.Lock()
+	current, exists := a.sessions[hashToken(value)]
+	if exists && time.Now().After(current.expiresAt) {
+		delete(a.sessions, hashToken(value))
+		exists = false
+	}
+	a.mu.Unlock()
+
+	if !exists {
+		return nil, ErrInvalidSession
+	}
+
+	user, err := a.users.Get(current.userID)
+	if err != nil {
+		return nil, ErrInvalidSession
+	}
+	return user, nil
+}
+
+// CreateAPIToken issues a personal API token. The returned string is the only
+// copy of the token; just its hash is stored.
+func (a *AuthService) CreateAPIToken(userID, name string, expiresAt *time.Time) (*models.APIToken, string, error) {
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	value := APITokenPrefix + secret
+
+	token := &models.APIToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    value[:len(APITokenPrefix)+6],
+		TokenHash: hashToken(value),
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	if err := a.users.AddToken(token); err != nil {
+		return nil, "", err
+	}
+	return token, value, nil
+}
+
+// ValidateAPIToken returns the user an API token belongs to
+func (a *AuthService) ValidateAPIToken(value string) (*models.User, error) {
+	if !strings.HasPrefix(value, APITokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+
+	token, err := a.users.GetTokenByHash(hashToken(value))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now()
+	if token.Expired(now) {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := a.users.Get(token.UserID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	a.users.TouchToken(token.ID, now)
+	return user, nil
+}
+
+// GetAPITokens lists a user's API tokens without their hashes
+func (a *AuthService) GetAPITokens(userID string) []*models.APIToken {
+	tokens := a.users.GetTokensForUser(userID)
+	public := make([]*models.APIToken, len(tokens))
+	for i, token := range tokens {
+		public[i] = token.Public()
+	}
+	return public
+}
+
+// DeleteAPIToken revokes one of a user's API tokens
+func (a *AuthService) DeleteAPIToken(userID, tokenID string) error {
+	return a.users.DeleteToken(userID, tokenID)
+}
+
+// endSessionsForUser signs a user out everywhere except the kept session
+func (a *AuthService) endSessionsForUser(userID, keepSession string) {
+	keep := ""
+	if keepSession != "" {
+		keep = hashToken(keepSession)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for hash, current := range a.sessions {
+		if current.userID == userID && hash != keep {
+			delete(a.sessions, hash)
+		}
+	}
+}
+
+// pruneSessions drops expired sessions. Callers must hold the lock.
+func (a *AuthService) pruneSessions(now time.Time) {
+	for hash, current := range a.sessions {
+		if now.After(current.expiresAt) {
+			delete(a.sessions, hash)
+		}
+	}
+}
+
+// hashPassword validates and bcrypt-hashes a password
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return "", ErrWeakPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// hashToken returns the hex SHA-256 of a session or API token value
+func hashToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken returns n random bytes encoded as URL-safe base64
+func randomToken(n int) (string, error) {
+	buffer := make([]byte, n)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"monitoring/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBadgeWindow is the uptime window used when none is requested
+	DefaultBadgeWindow = 30 * 24 * time.Hour
+
+	// maxBadgeWindow limits uptime windows to what the hourly rollups keep by default
+	maxBadgeWindow = 365 * 24 * time.Hour
+
+	// maxBadgeLabelLength keeps custom labels readable
+	maxBadgeLabelLength = 40
+
+	// badgeCacheTTL limits how often uptime badges are computed from history
+	badgeCacheTTL = 30 * time.Second
+)
+
+// Badge colors, matching shields.io
+const (
+	badgeBrightGreen = "#4c1"
+	badgeGreen       = "#97ca00"
+	badgeYellow      = "#dfb317"
+	badgeOrange      = "#fe7d37"
+	badgeRed         = "#e05d44"
+	badgeGrey        = "#9f9f9f"
+)
+
+// ErrInvalidBadgeWindow is returned for uptime windows that cannot be parsed
+var ErrInvalidBadgeWindow = errors.New("window must be a duration like 24h, 7d or 30d, up to 365d")
+
+// Badge is a two-part shields-style badge
+type Badge struct {
+	Label   string
+	Message string
+	Color   string
+}
+
+// badgeCacheKey identifies a cached uptime badge
+type badgeCacheKey struct {
+	token  string
+	window time.Duration
+}
+
+// cachedBadge is an uptime badge and when it was computed
+type cachedBadge struct {
+	badge    Badge
+	cachedAt time.Time
+}
+
+// BadgeService issues public badge tokens and renders service badges
+type BadgeService struct {
+	store   *models.ServiceStore
+	history *models.HistoryStore
+	cache   map[badgeCacheKey]cachedBadge
+	cacheMu sync.Mutex
+}
+
+// NewBadgeService creates a new badge service
+func NewBadgeService(store *models.ServiceStore, history *models.HistoryStore) *BadgeService {
+	return &BadgeService{
+		store:   store,
+		history: history,
+		cache:   make(map[badgeCacheKey]cachedBadge),
+	}
+}
+
+// EnableBadges gives a service a new public badge token, replacing any existing one
+func (b *BadgeService) EnableBadges(serviceID string) (*models.MonitoredService, error) {
+	token, err := randomToken(18)
+	if err != nil {
+		return nil, err
+	}
+	return b.setToken(serviceID, token)
+}
+
+// DisableBadges removes a service's badge token, so its badges stop working
+func (b *BadgeService) DisableBadges(serviceID string) (*models.MonitoredService, error) {
+	return b.setToken(serviceID, "")
+}
+
+func (b *BadgeService) setToken(serviceID, token string) (*models.MonitoredService, error) {
+	return b.store.Modify(serviceID, func(service *models.MonitoredService) {
+		service.BadgeToken = token
+	})
+}
+
+// GetService returns the service a badge token belongs to
+func (b *BadgeService) GetService(token string) (*models.MonitoredService, error) {
+	return b.store.GetByBadgeToken(token)
+}
+
+// StatusBadge shows the current status of a service
+func (b *BadgeService) StatusBadge(service *models.MonitoredService) Badge {
+	badge := Badge{Label: "status", Message: string(service.Status), Color: badgeGrey}
+	switch service.Status {
+	case models.StatusUp:
+		badge.Color = badgeBrightGreen
+	case models.StatusDegraded:
+		badge.Color = badgeYellow
+	case models.StatusDown:
+		badge.Color = badgeRed
+	case "":
+		badge.Message = string(models.StatusUnknown)
+	}
+	return badge
+}
+
+// UptimeBadge shows the uptime percentage of a service over the given window.
+// Badges are public, so they are computed at most every badgeCacheTTL per
+// token and window. The history is read without holding the cache lock, so a
+// slow read for one badge doesn't hold up the others.
+func (b *BadgeService) UptimeBadge(service *models.MonitoredService, window time.Duration) (Badge, error) {
+	key := badgeCacheKey{token: service.BadgeToken, window: window}
+
+	b.cacheMu.Lock()
+	cached, ok := b.cache[key]
+	b.cacheMu.Unlock()
+	if ok && time.Since(cached.cachedAt) < badgeCacheTTL {
+		return cached.badge, nil
+	}
+
+	badge, err := b.uptimeBadge(service, window)
+	if err != nil {
+		return badge, err
+	}
+
+	b.cacheMu.Lock()
+	defer b.cacheMu.Unlock()
+
+	// Drop expired entries, so arbitrary windows can't grow the cache
+	for cachedKey, cached := range b.cache {
+		if time.Since(cached.cachedAt) >= badgeCacheTTL {
+			delete(b.cache, cachedKey)
+		}
+	}
+	b.cache[key] = cachedBadge{badge: badge, cachedAt: time.Now()}
+	return badge, nil
+}
+
+// uptimeBadge computes an uptime badge from the check history
+func (b *BadgeService) uptimeBadge(service *models.MonitoredService, window time.Duration) (Badge, error) {
+	badge := Badge{Label: "uptime " + FormatBadgeWindow(window), Message: "no data", Color: badgeGrey}
+
+	now := time.Now()
+	stats, err := b.history.GetStatistics(service.ID, now.Add(-window), now)
+	if err != nil {
+		return badge, err
+	}
+	if stats.TotalChecks == 0 {
+		return badge, nil
+	}
+
+	uptime := stats.UptimePercentage
+	badge.Message = formatPercent(uptime)
+	switch {
+	case uptime >= 99.9:
+		badge.Color = badgeBrightGreen
+	case uptime >= 99:
+		badge.Color = badgeGreen
+	case uptime >= 95:
+		badge.Color = badgeYellow
+	case uptime >= 90:
+		badge.Color = badgeOrange
+	default:
+		badge.Color = badgeRed
+	}
+	return badge, nil
+}
+
+// ResponseTimeBadge shows the latest response time of a service
+func (b *BadgeService) ResponseTimeBadge(service *models.MonitoredService) Badge {
+	badge := Badge{Label: "response time", Message: "n/a", Color: badgeGrey}
+	if service.LastCheck.IsZero() || !service.Status.IsAvailable() {
+		return badge
+	}
+
+	badge.Message = fmt.Sprintf("%d ms", service.ResponseTime)
+	switch {
+	case service.ResponseTime < 300:
+		badge.Color = badgeBrightGreen
+	case service.ResponseTime < 1000:
+		badge.Color = badgeYellow
+	default:
+		badge.Color = badgeOrange
+	}
+	return badge
+}
+
+// NotFoundBadge is shown for unknown or disabled badge tokens, so embeds show
+// a readable badge instead of a broken image
+func NotFoundBadge() Badge {
+	return Badge{Label: "badge", Message: "not found", Color: badgeGrey}
+}
+
+// ErrorBadge reports a problem rendering a badge
+func ErrorBadge(label, message string) Badge {
+	return Badge{Label: label, Message: message, Color: badgeRed}
+}
+
+// WithLabel replaces the badge label, if a non-empty one is given
+func (badge Badge) WithLabel(label string) Badge {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return badge
+	}
+	if runes := []rune(label); len(runes) > maxBadgeLabelLength {
+		label = string(runes[:maxBadgeLabelLength])
+	}
+	badge.Label = label
+	return badge
+}
+
+// ParseBadgeWindow parses an uptime window such as "24h", "7d" or "30d".
+// An empty string selects DefaultBadgeWindow.
+func ParseBadgeWindow(value string) (time.Duration, error) {
+	if value == "" {
+		return DefaultBadgeWindow, nil
+	}
+
+	var window time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, ErrInvalidBadgeWindow
+		}
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, ErrInvalidBadgeWindow
+		}
+		window = parsed
+	}
+
+	if window < time.Hour || window > maxBadgeWindow {
+		return 0, ErrInvalidBadgeWindow
+	}
+	return window, nil
+}
+
+// FormatBadgeWindow formats a window for a badge label, e.g. "30d" or "12h"
+func FormatBadgeWindow(window time.Duration) string {
+	if window%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", window/(24*time.Hour))
+	}
+	return fmt.Sprintf("%dh", window/time.Hour)
+}
+
+// formatPercent formats an uptime percentage with up to two decimals
+func formatPercent(value float64) string {
+	formatted := strconv.FormatFloat(value, 'f', 2, 64)
+	formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+	return formatted + "%"
+}
+
+// RenderSVG renders the badge in the shields.io "flat" style
+func (badge Badge) RenderSVG() []byte {
+	labelWidth := textWidth(badge.Label) + 10
+	messageWidth := textWidth(badge.Message) + 10
+	width := labelWidth + messageWidth
+
+	label := html.EscapeString(badge.Label)
+	message := html.EscapeString(badge.Message)
+
+	// Text is drawn at 10x scale, as shields.io does, for sub-pixel positioning
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[2]s: %[3]s">`+
+		`<title>%[2]s: %[3]s</title>`+
+		`<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`+
+		`<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>`+
+		`<g clip-path="url(#r)"><rect width="%[4]d" height="20" fill="#555"/><rect x="%[4]d" width="%[5]d" height="20" fill="%[6]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>`+
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="110">`+
+		`<text x="%[7]d" y="150" fill="#010101" fill-opacity=".3" transform="scale(.1)" textLength="%[8]d">%[2]s</text>`+
+		`<text x="%[7]d" y="140" transform="scale(.1)" textLength="%[8]d">%[2]s</text>`+
+		`<text x="%[9]d" y="150" fill="#010101" fill-opacity=".3" transform="scale(.1)" textLength="%[10]d">%[3]s</text>`+
+		`<text x="%[9]d" y="140" transform="scale(.1)" textLength="%[10]d">%[3]s</text>`+
+		`</g></svg>`,
+		width, label, message, labelWidth, messageWidth, badge.Color,
+		labelWidth*5, (labelWidth-10)*10,
+		labelWidth*10+messageWidth*5, (messageWidth-10)*10,
+	))
+}
+
+// textWidth estimates the width in pixels of text in 11px Verdana
+func textWidth(text string) int {
+	width := 0.0
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune("iljI.,:;|!' ", r):
+			width += 3.5
+		case strings.ContainsRune("frt()[]1", r):
+			width += 4.8
+		case strings.ContainsRune("mwMW%", r):
+			width += 10.5
+		case r >= 'A' && r <= 'Z':
+			width += 7.5
+		default:
+			width += 6.8
+		}
+	}
+	return int(width + 0.5)
+}
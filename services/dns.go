@@ -0,0 +1,298 @@
+package services
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"monitoring/models"
+	"net"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsRecordTypes maps the record names accepted in DNSRecordType to query types
+var dnsRecordTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"MX":    dnsmessage.TypeMX,
+	"TXT":   dnsmessage.TypeTXT,
+	"NS":    dnsmessage.TypeNS,
+	"SOA":   dnsmessage.TypeSOA,
+}
+
+// checkDNS queries a resolver for a record and compares the answers with the expected values
+func (m *MonitorService) checkDNS(service *models.MonitoredService, result *models.HealthCheckResult) *models.HealthCheckResult {
+	timeout := time.Duration(service.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	recordType := strings.ToUpper(service.DNSRecordType)
+	if recordType == "" {
+		recordType = "A"
+	}
+	qtype, ok := dnsRecordTypes[recordType]
+	if !ok {
+		result.Status = models.StatusDown
+		result.ErrorMessage = fmt.Sprintf("Unsupported DNS record type: %s", service.DNSRecordType)
+		return result
+	}
+
+	server := dnsServerAddress(service.DNSServer)
+
+	start := time.Now()
+	answers, err := queryDNS(server, service.Host, qtype, timeout)
+	result.ResponseTime = time.Since(start).Milliseconds()
+
+	if err != nil {
+		result.Status = models.StatusDown
+		result.ErrorMessage = fmt.Sprintf("DNS query for %s %s via %s failed: %v", recordType, service.Host, server, err)
+		return result
+	}
+
+	if len(answers) == 0 {
+		result.Status = models.StatusDown
+		result.ErrorMessage = fmt.Sprintf("No %s records found for %s", recordType, service.Host)
+		return result
+	}
+
+	if mismatch := compareDNSAnswers(answers, service.DNSExpected, service.DNSMatchMode, qtype); mismatch != "" {
+		result.Status = models.StatusDown
+		result.ErrorMessage = mismatch
+		return result
+	}
+
+	result.Status = models.StatusUp
+	return result
+}
+
+// dnsServerAddress returns the resolver address to query, with the port defaulted to 53
+func dnsServerAddress(server string) string {
+	if server == "" {
+		server = systemNameserver()
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		return net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+	return server
+}
+
+// systemNameserver returns the first nameserver from /etc/resolv.conf
+func systemNameserver() string {
+	data, err := os.ReadFile("/etc/resolv.conf")
+	if err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 && fields[0] == "nameserver" {
+				return fields[1]
+			}
+		}
+	}
+	return "127.0.0.1"
+}
+
+// queryDNS sends a single question over UDP, retrying over TCP if the answer was truncated
+func queryDNS(server, name string, qtype dnsmessage.Type, timeout time.Duration) ([]string, error) {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name %q: %v", name, err)
+	}
+
+	id := uint16(rand.UintN(1 << 16))
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	response, err := exchangeDNS("udp", server, packed, deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(response); err != nil {
+		return nil, fmt.Errorf("malformed response: %v", err)
+	}
+	if msg.Truncated {
+		if response, err = exchangeDNS("tcp", server, packed, deadline); err != nil {
+			return nil, err
+		}
+		if err := msg.Unpack(response); err != nil {
+			return nil, fmt.Errorf("malformed response: %v", err)
+		}
+	}
+
+	if msg.ID != id {
+		return nil, errors.New("response ID does not match query")
+	}
+
+	switch msg.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, errors.New("NXDOMAIN")
+	case dnsmessage.RCodeServerFailure:
+		return nil, errors.New("SERVFAIL")
+	case dnsmessage.RCodeRefused:
+		return nil, errors.New("REFUSED")
+	default:
+		return nil, fmt.Errorf("rcode %s", msg.RCode)
+	}
+
+	var answers []string
+	for _, answer := range msg.Answers {
+		if answer.Header.Type != qtype {
+			continue // e.g. the CNAME chain in front of an A record
+		}
+		if value := formatDNSRecord(answer.Body); value != "" {
+			answers = append(answers, value)
+		}
+	}
+
+	return answers, nil
+}
+
+// exchangeDNS sends a packed query and returns the raw response
+func exchangeDNS(network, server string, query []byte, deadline time.Time) ([]byte, error) {
+	conn, err := net.DialTimeout(network, server, time.Until(deadline))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	if network == "udp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		buffer := make([]byte, 65535)
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return nil, err
+		}
+		return buffer[:n], nil
+	}
+
+	// DNS over TCP prefixes every message with its length
+	framed := make([]byte, 2+len(query))
+	binary.BigEndian.PutUint16(framed, uint16(len(query)))
+	copy(framed[2:], query)
+	if _, err := conn.Write(framed); err != nil {
+		return nil, err
+	}
+
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	response := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// formatDNSRecord renders a record as the string users compare against
+func formatDNSRecord(body dnsmessage.ResourceBody) string {
+	switch r := body.(type) {
+	case *dnsmessage.AResource:
+		return netip.AddrFrom4(r.A).String()
+	case *dnsmessage.AAAAResource:
+		return netip.AddrFrom16(r.AAAA).String()
+	case *dnsmessage.CNAMEResource:
+		return normalizeDNSName(r.CNAME.String())
+	case *dnsmessage.MXResource:
+		return normalizeDNSName(r.MX.String())
+	case *dnsmessage.NSResource:
+		return normalizeDNSName(r.NS.String())
+	case *dnsmessage.TXTResource:
+		return strings.Join(r.TXT, "")
+	case *dnsmessage.SOAResource:
+		return normalizeDNSName(r.NS.String())
+	}
+	return ""
+}
+
+// normalizeDNSValue canonicalises an answer or expected value for comparison.
+// Addresses are compared parsed and names case-insensitively without the
+// trailing dot; TXT values are compared verbatim, since case and dots matter in
+// them (e.g. verification tokens).
+func normalizeDNSValue(value string, qtype dnsmessage.Type) string {
+	switch qtype {
+	case dnsmessage.TypeTXT:
+		return value
+	case dnsmessage.TypeA, dnsmessage.TypeAAAA:
+		value = strings.TrimSpace(value)
+		if addr, err := netip.ParseAddr(value); err == nil {
+			return addr.String()
+		}
+		return value
+	}
+	return normalizeDNSName(strings.TrimSpace(value))
+}
+
+func normalizeDNSName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// compareDNSAnswers returns a description of the mismatch, or "" if the answers are acceptable
+func compareDNSAnswers(answers, expected []string, mode models.DNSMatchMode, qtype dnsmessage.Type) string {
+	if len(expected) == 0 {
+		return ""
+	}
+
+	got := make(map[string]bool)
+	for _, answer := range answers {
+		got[normalizeDNSValue(answer, qtype)] = true
+	}
+
+	want := make(map[string]bool)
+	var missing []string
+	for _, value := range expected {
+		normalized := normalizeDNSValue(value, qtype)
+		want[normalized] = true
+		if !got[normalized] {
+			missing = append(missing, value)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Sprintf("DNS answers %v do not contain %v", sortedStrings(answers), missing)
+	}
+
+	if mode == models.DNSMatchExact {
+		var unexpected []string
+		for _, answer := range answers {
+			if !want[normalizeDNSValue(answer, qtype)] {
+				unexpected = append(unexpected, answer)
+			}
+		}
+		if len(unexpected) > 0 {
+			return fmt.Sprintf("DNS answers contain unexpected values %v", unexpected)
+		}
+	}
+
+	return ""
+}
+
+func sortedStrings(values []string) []string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"encoding/binary"
+	"io"
+	"monitoring/models"
+	"net"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// stubZone is what the stub resolver answers for a name
+type stubZone struct {
+	rcode     dnsmessage.RCode
+	a         [][4]byte
+	cname     string
+	txt       [][]string
+	silent    bool // Never answer, so the query times out
+	truncated bool // Set TC over UDP, so the client retries over TCP
+}
+
+// startStubResolver serves the zones over UDP and TCP on the same local port
+// and returns its address
+func startStubResolver(t *testing.T, zones map[string]stubZone) string {
+	t.Helper()
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen tcp: %v", err)
+	}
+	t.Cleanup(func() { tcp.Close() })
+
+	udp, err := net.ListenPacket("udp", tcp.Addr().String())
+	if err != nil {
+		t.Fatalf("listen udp: %v", err)
+	}
+	t.Cleanup(func() { udp.Close() })
+
+	go func() {
+		buffer := make([]byte, 65535)
+		for {
+			n, addr, err := udp.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			if response := stubResponse(buffer[:n], zones, true); response != nil {
+				udp.WriteTo(response, addr)
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			conn, err := tcp.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				var length [2]byte
+				if _, err := io.ReadFull(conn, length[:]); err != nil {
+					return
+				}
+				query := make([]byte, binary.BigEndian.Uint16(length[:]))
+				if _, err := io.ReadFull(conn, query); err != nil {
+					return
+				}
+				response := stubResponse(query, zones, false)
+				if response == nil {
+					return
+				}
+				framed := binary.BigEndian.AppendUint16(nil, uint16(len(response)))
+				conn.Write(append(framed, response...))
+			}()
+		}
+	}()
+
+	return tcp.Addr().String()
+}
+
+// stubResponse builds the answer to a packed query, or nil to stay silent
+func stubResponse(packed []byte, zones map[string]stubZone, overUDP bool) []byte {
+	var query dnsmessage.Message
+	if err := query.Unpack(packed); err != nil || len(query.Questions) != 1 {
+		return nil
+	}
+	question := query.Questions[0]
+
+	zone, ok := zones[strings.TrimSuffix(question.Name.String(), ".")]
+	if !ok {
+		zone = stubZone{rcode: dnsmessage.RCodeNameError}
+	}
+	if zone.silent {
+		return nil
+	}
+
+	response := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 query.ID,
+			Response:           true,
+			RecursionDesired:   query.RecursionDesired,
+			RecursionAvailable: true,
+			RCode:              zone.rcode,
+			Truncated:          zone.truncated && overUDP,
+		},
+		Questions: query.Questions,
+	}
+
+	header := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 60}
+	if !response.Truncated {
+		if zone.cname != "" {
+			header.Type = dnsmessage.TypeCNAME
+			response.Answers = append(response.Answers, dnsmessage.Resource{
+				Header: header,
+				Body:   &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName(zone.cname)},
+			})
+		}
+		if question.Type == dnsmessage.TypeA {
+			header.Type = dnsmessage.TypeA
+			for _, a := range zone.a {
+				response.Answers = append(response.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.AResource{A: a}})
+			}
+		}
+		if question.Type == dnsmessage.TypeTXT {
+			header.Type = dnsmessage.TypeTXT
+			for _, txt := range zone.txt {
+				response.Answers = append(response.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.TXTResource{TXT: txt}})
+			}
+		}
+	}
+
+	packedResponse, err := response.Pack()
+	if err != nil {
+		return nil
+	}
+	return packedResponse
+}
+
+func TestCheckDNS(t *testing.T) {
+	server := startStubResolver(t, map[string]stubZone{
+		"example.test": {a: [][4]byte{{192, 0, 2, 1}, {192, 0, 2, 2}}},
+		"www.example.test": {
+			cname: "Edge.Example.TEST.",
+			a:     [][4]byte{{192, 0, 2, 10}},
+		},
+		"txt.example.test":    {txt: [][]string{{"site-verification=AbC.123."}, {"v=spf1 ", "-all"}}},
+		"big.example.test":    {a: [][4]byte{{192, 0, 2, 20}}, truncated: true},
+		"broken.example.test": {rcode: dnsmessage.RCodeServerFailure},
+		"slow.example.test":   {silent: true},
+	})
+
+	tests := []struct {
+		name       string
+		host       string
+		recordType string
+		expected   []string
+		mode       models.DNSMatchMode
+		wantUp     bool
+		wantError  string
+	}{
+		{name: "any answer", host: "example.test", wantUp: true},
+		{name: "contains subset", host: "example.test", expected: []string{"192.0.2.2"}, wantUp: true},
+		{name: "contains missing value", host: "example.test", expected: []string{"192.0.2.3"}, wantError: "do not contain [192.0.2.3]"},
+		{name: "exact match", host: "example.test", expected: []string{"192.0.2.2", "192.0.2.1"}, mode: models.DNSMatchExact, wantUp: true},
+		{name: "exact rejects extra answers", host: "example.test", expected: []string{"192.0.2.1"}, mode: models.DNSMatchExact, wantError: "unexpected values [192.0.2.2]"},
+		{name: "A behind CNAME", host: "www.example.test", expected: []string{"192.0.2.10"}, mode: models.DNSMatchExact, wantUp: true},
+		{name: "CNAME ignores case and trailing dot", host: "www.example.test", recordType: "CNAME", expected: []string{"edge.example.test"}, mode: models.DNSMatchExact, wantUp: true},
+		{name: "TXT verbatim", host: "txt.example.test", recordType: "TXT", expected: []string{"site-verification=AbC.123.", "v=spf1 -all"}, mode: models.DNSMatchExact, wantUp: true},
+		{name: "TXT is case sensitive", host: "txt.example.test", recordType: "TXT", expected: []string{"site-verification=abc.123."}, wantError: "do not contain"},
+		{name: "TXT keeps trailing dot", host: "txt.example.test", recordType: "TXT", expected: []string{"site-verification=AbC.123"}, wantError: "do not contain"},
+		{name: "truncated answer retried over TCP", host: "big.example.test", expected: []string{"192.0.2.20"}, wantUp: true},
+		{name: "NXDOMAIN", host: "missing.example.test", wantError: "NXDOMAIN"},
+		{name: "SERVFAIL", host: "broken.example.test", wantError: "SERVFAIL"},
+		{name: "no records of the type", host: "example.test", recordType: "TXT", wantError: "No TXT records found"},
+		{name: "timeout", host: "slow.example.test", wantError: "timeout"},
+	}
+
+	monitor := &MonitorService{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := &models.MonitoredService{
+				Host:          test.host,
+				Timeout:       1,
+				DNSServer:     server,
+				DNSRecordType: test.recordType,
+				DNSExpected:   test.expected,
+				DNSMatchMode:  test.mode,
+			}
+			result := monitor.checkDNS(service, &models.HealthCheckResult{})
+
+			if test.wantUp {
+				if result.Status != models.StatusUp {
+					t.Fatalf("status = %s (%s), want up", result.Status, result.ErrorMessage)
+				}
+				return
+			}
+			if result.Status != models.StatusDown {
+				t.Fatalf("status = %s, want down", result.Status)
+			}
+			if !strings.Contains(result.ErrorMessage, test.wantError) {
+				t.Fatalf("error = %q, want it to contain %q", result.ErrorMessage, test.wantError)
+			}
+		})
+	}
+}
+
+func TestDNSServerAddress(t *testing.T) {
+	tests := map[string]string{
+		"1.1.1.1":           "1.1.1.1:53",
+		"127.0.0.1:5353":    "127.0.0.1:5353",
+		"2606:4700::1111":   "[2606:4700::1111]:53",
+		"[2606:4700::1111]": "[2606:4700::1111]:53",
+	}
+	for server, want := range tests {
+		if got := dnsServerAddress(server); got != want {
+			t.Errorf("dnsServerAddress(%q) = %q, want %q", server, got, want)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"monitoring/models"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSMTPPort    = 587
+	defaultSMTPTLSPort = 465
+	defaultSMTPTimeout = 10 * time.Second
+)
+
+// emailField is one labelled line of an alert email
+type emailField struct {
+	Label string
+	Value string
+}
+
+// emailAlert is the content of an alert, rendered as both plain text and HTML
+type emailAlert struct {
+	Subject string
+	Title   string
+	Color   string // Accent colour of the HTML heading
+	Fields  []emailField
+	Note    string
+}
+
+var emailHTMLTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:24px;background:#f5f5f5;font-family:Arial,Helvetica,sans-serif;color:#333;">
+  <table role="presentation" width="100%" style="max-width:600px;margin:0 auto;background:#ffffff;border-radius:8px;border-top:4px solid {{.Color}};">
+    <tr><td style="padding:24px;">
+      <h2 style="margin:0 0 16px;color:{{.Color}};">{{.Title}}</h2>
+      <table role="presentation" style="border-collapse:collapse;">
+        {{range .Fields}}<tr>
+          <td style="padding:4px 16px 4px 0;font-weight:bold;vertical-align:top;white-space:nowrap;">{{.Label}}</td>
+          <td style="padding:4px 0;word-break:break-all;">{{.Value}}</td>
+        </tr>{{end}}
+      </table>
+      {{if .Note}}<p style="margin:16px 0 0;color:#666;font-style:italic;">{{.Note}}</p>{{end}}
+    </td></tr>
+  </table>
+</body>
+</html>
+`))
+
+// EmailService sends notifications by email over SMTP. It implements Notifier.
+type EmailService struct {
+	config *models.EmailConfig
+}
+
+// NewEmailService creates an email notifier after validating the SMTP settings
+func NewEmailService(config *models.EmailConfig) (*EmailService, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("SMTP host is required")
+	}
+	if _, err := mail.ParseAddress(config.From); err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %v", config.From, err)
+	}
+	if len(config.To) == 0 {
+		return nil, fmt.Errorf("at least one recipient is required")
+	}
+	for _, to := range config.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %v", to, err)
+		}
+	}
+
+	switch config.Security {
+	case "", models.SMTPSecuritySTARTTLS, models.SMTPSecurityTLS, models.SMTPSecurityNone:
+	default:
+		return nil, fmt.Errorf("unknown SMTP security %q", config.Security)
+	}
+
+	return &EmailService{
+		config: config,
+	}, nil
+}
+
+// SendServiceDownAlert sends an alert when a service goes down
+func (e *EmailService) SendServiceDownAlert(service *models.MonitoredService) error {
+	return e.send(&emailAlert{
+		Subject: fmt.Sprintf("[DOWN] %s", service.Name),
+		Title:   "🔴 Service Down Alert",
+		Color:   "#dc3545",
+		Fields: []emailField{
+			{"Service", service.Name},
+			{"Target", serviceTarget(service)},
+			{"Status", "DOWN"},
+			{"Error", service.ErrorMessage},
+			{"Time", service.LastCheck.Format("2006-01-02 15:04:05")},
+		},
+	})
+}
+
+// SendServiceUpAlert sends an alert when a service comes back up
+func (e *EmailService) SendServiceUpAlert(service *models.MonitoredService) error {
+	return e.send(&emailAlert{
+		Subject: fmt.Sprintf("[UP] %s", service.Name),
+		Title:   "🟢 Service Recovered",
+		Color:   "#28a745",
+		Fields: []emailField{
+			{"Service", service.Name},
+			{"Target", serviceTarget(service)},
+			{"Status", "UP"},
+			{"Response Time", fmt.Sprintf("%dms", service.ResponseTime)},
+			{"Time", service.LastCheck.Format("2006-01-02 15:04:05")},
+		},
+	})
+}
+
+// SendSSLExpiryAlert sends an alert when SSL certificate is expiring soon
+func (e *EmailService) SendSSLExpiryAlert(service *models.MonitoredService) error {
+	return e.send(&emailAlert{
+		Subject: fmt.Sprintf("[SSL] Certificate for %s expires in %d days", service.Name, service.SSLDaysLeft),
+		Title:   "🔒 SSL Certificate Expiry Alert",
+		Color:   "#fd7e14",
+		Fields: []emailField{
+			{"Service", service.Name},
+			{"Target", serviceTarget(service)},
+			{"Days Until Expiry", strconv.Itoa(service.SSLDaysLeft)},
+			{"Expiry Date", service.SSLCertExpiry.Format("2006-01-02 15:04:05")},
+			{"Issuer", service.SSLCertIssuer},
+			{"Time", time.Now().Format("2006-01-02 15:04:05")},
+		},
+		Note: "Please renew your SSL certificate.",
+	})
+}
+
+// SendSystemAlert sends a system resource alert
+func (e *EmailService) SendSystemAlert(resourceType string, device string, currentValue float64, threshold float64) error {
+	var emoji, resourceName string
+
+	switch resourceType {
+	case "disk":
+		emoji = "💾"
+		resourceName = "Disk Space"
+	case "cpu":
+		emoji = "🔥"
+		resourceName = "CPU Usage"
+	case "memory":
+		emoji = "⚠️"
+		resourceName = "Memory Usage"
+	default:
+		emoji = "⚠️"
+		resourceName = "System Resource"
+	}
+
+	fields := []emailField{{"Resource", resourceName}}
+	if device != "" {
+		fields = append(fields, emailField{"Device", device})
+	}
+	fields = append(fields,
+		emailField{"Current Usage", fmt.Sprintf("%.1f%%", currentValue)},
+		emailField{"Threshold", fmt.Sprintf("%.1f%%", threshold)},
+		emailField{"Time", time.Now().Format("2006-01-02 15:04:05")},
+	)
+
+	return e.send(&emailAlert{
+		Subject: fmt.Sprintf("[SYSTEM] %s at %.1f%%", resourceName, currentValue),
+		Title:   fmt.Sprintf("%s %s Alert", emoji, resourceName),
+		Color:   "#ffc107",
+		Fields:  fields,
+		Note:    "Please check your system resources.",
+	})
+}
+
+// SendTestMessage sends a test notification
+func (e *EmailService) SendTestMessage() error {
+	return e.send(&emailAlert{
+		Subject: "Test Notification",
+		Title:   "✅ Test Notification",
+		Color:   "#667eea",
+		Fields: []emailField{
+			{"SMTP Server", net.JoinHostPort(e.config.Host, strconv.Itoa(e.port()))},
+			{"Time", time.Now().Format("2006-01-02 15:04:05")},
+		},
+		Note: "Your monitoring service is successfully connected to this mailbox!",
+	})
+}
+
+// port returns the configured SMTP port or the default for the security mode
+func (e *EmailService) port() int {
+	if e.config.Port != 0 {
+		return e.config.Port
+	}
+	if e.config.Security == models.SMTPSecurityTLS {
+		return defaultSMTPTLSPort
+	}
+	return defaultSMTPPort
+}
+
+// send builds the message and delivers it to every recipient
+func (e *EmailService) send(alert *emailAlert) error {
+	message, err := e.buildMessage(alert)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %v", err)
+	}
+
+	client, err := e.dial()
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %v", err)
+	}
+	defer client.Close()
+
+	if e.config.Username != "" {
+		auth := smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %v", err)
+		}
+	}
+
+	from, _ := mail.ParseAddress(e.config.From)
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM failed: %v", err)
+	}
+	for _, to := range e.config.To {
+		recipient, _ := mail.ParseAddress(to)
+		if err := client.Rcpt(recipient.Address); err != nil {
+			return fmt.Errorf("SMTP RCPT TO %s failed: %v", recipient.Address, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA failed: %v", err)
+	}
+	if _, err := writer.Write(message); err != nil {
+		return fmt.Errorf("failed to write email: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected email: %v", err)
+	}
+
+	return client.Quit()
+}
+
+// dial connects to the SMTP server and secures the connection as configured
+func (e *EmailService) dial() (*smtp.Client, error) {
+	timeout := time.Duration(e.config.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = defaultSMTPTimeout
+	}
+
+	address := net.JoinHostPort(e.config.Host, strconv.Itoa(e.port()))
+	tlsConfig := &tls.Config{
+		ServerName:         e.config.Host,
+		InsecureSkipVerify: e.config.InsecureSkipVerify,
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if e.config.Security == models.SMTPSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Bound the whole conversation, not just the connect
+	conn.SetDeadline(time.Now().Add(2 * timeout))
+
+	client, err := smtp.NewClient(conn, e.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if e.config.Security == "" || e.config.Security == models.SMTPSecuritySTARTTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("STARTTLS failed: %v", err)
+		}
+	}
+
+	return client, nil
+}
+
+// buildMessage renders a multipart/alternative message with plain-text and HTML parts
+func (e *EmailService) buildMessage(alert *emailAlert) ([]byte, error) {
+	var htmlBody bytes.Buffer
+	if err := emailHTMLTemplate.Execute(&htmlBody, alert); err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+
+	if err := writeQuotedPrintablePart(parts, "text/plain; charset=UTF-8", []byte(plainTextEmail(alert))); err != nil {
+		return nil, err
+	}
+	if err := writeQuotedPrintablePart(parts, "text/html; charset=UTF-8", htmlBody.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	from, _ := mail.ParseAddress(e.config.From)
+	domain := "localhost"
+	if at := strings.LastIndex(from.Address, "@"); at >= 0 {
+		domain = from.Address[at+1:]
+	}
+
+	var message bytes.Buffer
+	headers := []emailField{
+		{"From", e.config.From},
+		{"To", strings.Join(e.config.To, ", ")},
+		{"Subject", mime.QEncoding.Encode("UTF-8", alert.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", randomMessageID(), domain)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", parts.Boundary())},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&message, "%s: %s\r\n", header.Label, header.Value)
+	}
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+
+	return message.Bytes(), nil
+}
+
+// writeQuotedPrintablePart adds a quoted-printable encoded part to a multipart message
+func writeQuotedPrintablePart(parts *multipart.Writer, contentType string, content []byte) error {
+	part, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := quotedprintable.NewWriter(part)
+	if _, err := encoder.Write(content); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// plainTextEmail renders the plain-text version of an alert
+func plainTextEmail(alert *emailAlert) string {
+	var text strings.Builder
+	text.WriteString(alert.Title + "\r\n\r\n")
+	for _, field := range alert.Fields {
+		fmt.Fprintf(&text, "%s: %s\r\n", field.Label, field.Value)
+	}
+	if alert.Note != "" {
+		text.WriteString("\r\n" + alert.Note + "\r\n")
+	}
+	return text.String()
+}
+
+// randomMessageID returns a random identifier for the Message-ID header
+func randomMessageID() string {
+	buffer := make([]byte, 16)
+	rand.Read(buffer)
+	return hex.EncodeToString(buffer)
+}
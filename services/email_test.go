@@ -0,0 +1,345 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"monitoring/models"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession is what the stub SMTP server received in one conversation
+type smtpSession struct {
+	tls        bool // Whether the message was sent over TLS
+	auth       string
+	from       string
+	recipients []string
+	data       []byte
+}
+
+// smtpStub is an in-process SMTP server that accepts every message
+type smtpStub struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	startTLS  bool // Advertise STARTTLS
+	sessions  chan smtpSession
+}
+
+// startSMTPStub serves SMTP on a local port. With implicitTLS the listener
+// speaks TLS from the first byte; otherwise startTLS controls whether
+// STARTTLS is offered.
+func startSMTPStub(t *testing.T, startTLS, implicitTLS bool) *smtpStub {
+	t.Helper()
+
+	stub := &smtpStub{
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{selfSignedCertificate(t)}},
+		startTLS:  startTLS,
+		sessions:  make(chan smtpSession, 1),
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	if implicitTLS {
+		listener = tls.NewListener(listener, stub.tlsConfig)
+	}
+	stub.listener = listener
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go stub.serve(conn, implicitTLS)
+		}
+	}()
+	return stub
+}
+
+// port returns the port the stub listens on
+func (s *smtpStub) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// serve handles one SMTP conversation
+func (s *smtpStub) serve(conn net.Conn, secure bool) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	session := smtpSession{tls: secure}
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 localhost ESMTP stub")
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, argument, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			text.PrintfLine("250-localhost")
+			if s.startTLS && !session.tls {
+				text.PrintfLine("250-STARTTLS")
+			}
+			text.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			text.PrintfLine("220 Ready to start TLS")
+			secured := tls.Server(conn, s.tlsConfig)
+			if err := secured.Handshake(); err != nil {
+				return
+			}
+			conn, session.tls = secured, true
+			text = textproto.NewConn(conn)
+		case "AUTH":
+			session.auth = argument
+			text.PrintfLine("235 Authenticated")
+		case "MAIL":
+			session.from = smtpPath(argument)
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			session.recipients = append(session.recipients, smtpPath(argument))
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			if session.data, err = text.ReadDotBytes(); err != nil {
+				return
+			}
+			text.PrintfLine("250 Queued")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			s.sessions <- session
+			return
+		default:
+			text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// smtpPath extracts the address from "FROM:<a@b>" or "TO:<a@b>"
+func smtpPath(argument string) string {
+	start, end := strings.Index(argument, "<"), strings.Index(argument, ">")
+	if start < 0 || end < start {
+		return argument
+	}
+	return argument[start+1 : end]
+}
+
+// selfSignedCertificate creates a throwaway certificate for 127.0.0.1
+func selfSignedCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestEmailServiceSend(t *testing.T) {
+	tests := []struct {
+		name        string
+		security    models.SMTPSecurity
+		startTLS    bool
+		implicitTLS bool
+		username    string
+		wantTLS     bool
+	}{
+		{name: "no TLS", security: models.SMTPSecurityNone, startTLS: true},
+		{name: "STARTTLS", security: models.SMTPSecuritySTARTTLS, startTLS: true, username: "alerts", wantTLS: true},
+		{name: "STARTTLS by default", startTLS: true, wantTLS: true},
+		{name: "implicit TLS", security: models.SMTPSecurityTLS, implicitTLS: true, wantTLS: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stub := startSMTPStub(t, test.startTLS, test.implicitTLS)
+			email, err := NewEmailService(&models.EmailConfig{
+				Host:               "127.0.0.1",
+				Port:               stub.port(),
+				Security:           test.security,
+				Username:           test.username,
+				Password:           "secret",
+				From:               "Monitoring <monitor@example.com>",
+				To:                 []string{"ops@example.com", "Jane <jane@example.org>"},
+				InsecureSkipVerify: true,
+				Timeout:            2,
+			})
+			if err != nil {
+				t.Fatalf("NewEmailService: %v", err)
+			}
+
+			service := &models.MonitoredService{
+				Name:         "API <prod>",
+				CheckType:    models.CheckTypeHTTP,
+				URL:          "https://api.example.com/health",
+				ErrorMessage: "connection refused",
+				LastCheck:    time.Now(),
+			}
+			if err := email.SendServiceDownAlert(service); err != nil {
+				t.Fatalf("SendServiceDownAlert: %v", err)
+			}
+
+			var session smtpSession
+			select {
+			case session = <-stub.sessions:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the SMTP server received no message")
+			}
+
+			if session.tls != test.wantTLS {
+				t.Errorf("sent over TLS = %v, want %v", session.tls, test.wantTLS)
+			}
+			if test.username != "" {
+				wantAuth := "PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00alerts\x00secret"))
+				if session.auth != wantAuth {
+					t.Errorf("AUTH = %q, want %q", session.auth, wantAuth)
+				}
+			} else if session.auth != "" {
+				t.Errorf("authenticated without a username: %q", session.auth)
+			}
+			if session.from != "monitor@example.com" {
+				t.Errorf("MAIL FROM = %q, want monitor@example.com", session.from)
+			}
+			if strings.Join(session.recipients, ",") != "ops@example.com,jane@example.org" {
+				t.Errorf("RCPT TO = %v, want [ops@example.com jane@example.org]", session.recipients)
+			}
+
+			checkAlertMessage(t, session.data)
+		})
+	}
+}
+
+// checkAlertMessage checks the headers and both parts of a service-down email
+func checkAlertMessage(t *testing.T, data []byte) {
+	t.Helper()
+
+	message, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	if to := message.Header.Get("To"); to != "ops@example.com, Jane <jane@example.org>" {
+		t.Errorf("To = %q", to)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil || subject != "[DOWN] API <prod>" {
+		t.Errorf("Subject = %q (%v), want [DOWN] API <prod>", subject, err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q (%v), want multipart/alternative", message.Header.Get("Content-Type"), err)
+	}
+
+	parts := multipart.NewReader(message.Body, params["boundary"])
+	bodies := make(map[string]string)
+	var order []string
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid part: %v", err)
+		}
+		// The reader decodes quoted-printable parts transparently
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("reading part: %v", err)
+		}
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		bodies[contentType] = string(body)
+		order = append(order, contentType)
+	}
+
+	// Clients show the last part they support, so HTML comes last
+	if strings.Join(order, ",") != "text/plain,text/html" {
+		t.Fatalf("parts = %v, want [text/plain text/html]", order)
+	}
+	for _, want := range []string{"Service Down Alert", "Service: API <prod>", "Target: https://api.example.com/health", "Error: connection refused"} {
+		if !strings.Contains(bodies["text/plain"], want) {
+			t.Errorf("plain text part does not contain %q:\n%s", want, bodies["text/plain"])
+		}
+	}
+	for _, want := range []string{"<h2", "API &lt;prod&gt;", "https://api.example.com/health", "connection refused"} {
+		if !strings.Contains(bodies["text/html"], want) {
+			t.Errorf("HTML part does not contain %q:\n%s", want, bodies["text/html"])
+		}
+	}
+}
+
+func TestEmailServiceRequiresSTARTTLS(t *testing.T) {
+	stub := startSMTPStub(t, false, false)
+	email, err := NewEmailService(&models.EmailConfig{
+		Host:    "127.0.0.1",
+		Port:    stub.port(),
+		From:    "monitor@example.com",
+		To:      []string{"ops@example.com"},
+		Timeout: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewEmailService: %v", err)
+	}
+
+	err = email.SendTestMessage()
+	if err == nil || !strings.Contains(err.Error(), "does not support STARTTLS") {
+		t.Fatalf("error = %v, want the missing STARTTLS to be reported", err)
+	}
+	select {
+	case <-stub.sessions:
+		t.Fatal("the message was sent without TLS")
+	default:
+	}
+}
+
+func TestNewEmailServiceValidation(t *testing.T) {
+	valid := models.EmailConfig{Host: "smtp.example.com", From: "monitor@example.com", To: []string{"ops@example.com"}}
+
+	tests := map[string]func(*models.EmailConfig){
+		"missing host":      func(c *models.EmailConfig) { c.Host = "" },
+		"invalid from":      func(c *models.EmailConfig) { c.From = "not an address" },
+		"no recipients":     func(c *models.EmailConfig) { c.To = nil },
+		"invalid recipient": func(c *models.EmailConfig) { c.To = []string{"ops@example.com", "nope"} },
+		"unknown security":  func(c *models.EmailConfig) { c.Security = "ssl" },
+	}
+	for name, modify := range tests {
+		config := valid
+		modify(&config)
+		if _, err := NewEmailService(&config); err == nil {
+			t.Errorf("%s: NewEmailService accepted the config", name)
+		}
+	}
+
+	if _, err := NewEmailService(&valid); err != nil {
+		t.Errorf("valid config rejected: %v", err)
+	}
+	if port := (&EmailService{config: &models.EmailConfig{Security: models.SMTPSecurityTLS}}).port(); port != 465 {
+		t.Errorf("implicit TLS port = %d, want 465", port)
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"monitoring/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types streamed to dashboard clients
+const (
+	EventServiceCreated = "service.created" // Data: the service
+	EventServiceUpdated = "service.updated" // Data: the service
+	EventServiceDeleted = "service.deleted" // Data: {"id": ...}
+	EventServiceCheck   = "service.check"   // Data: the check result
+	EventSystemInfo     = "system.info"     // Data: the system info
+	EventSystemAlert    = "system.alert"    // Data: the system alert
+	EventHostUpdated    = "host.updated"    // Data: the host
+	EventHostDeleted    = "host.deleted"    // Data: {"id": ...}
+
+	// EventResync tells a resuming client that events were missed and it
+	// should reload its state. It is sent to that client only.
+	EventResync = "resync"
+)
+
+const (
+	// eventReplaySize is how many recent events are kept for resuming clients
+	eventReplaySize = 500
+
+	// subscriberBufferSize is how many events may queue for a slow client
+	// before it is disconnected; it then resumes with Last-Event-ID
+	subscriberBufferSize = 64
+)
+
+// Event is one server-sent event. IDs have the form "<epoch>-<sequence>",
+// where the epoch changes on every restart.
+type Event struct {
+	ID   string
+	Type string
+	Data json.RawMessage
+}
+
+// EventBroker fans out events to connected clients and keeps a short replay
+// buffer so reconnecting clients can resume where they left off
+type EventBroker struct {
+	epoch       string
+	seq         uint64
+	buffer      []Event
+	subscribers map[chan Event]struct{}
+	mu          sync.Mutex
+}
+
+// NewEventBroker creates a new event broker
+func NewEventBroker() *EventBroker {
+	return &EventBroker{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		buffer:      make([]Event, 0, eventReplaySize),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish sends an event to all subscribers
+func (b *EventBroker) Publish(eventType string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		fmt.Printf("Error encoding %s event: %v\n", eventType, err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	event := Event{
+		ID:   fmt.Sprintf("%s-%d", b.epoch, b.seq),
+		Type: eventType,
+		Data: payload,
+	}
+
+	if len(b.buffer) == eventReplaySize {
+		b.buffer = append(b.buffer[:0], b.buffer[1:]...)
+	}
+	b.buffer = append(b.buffer, event)
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Too slow to keep up; it will reconnect and resume
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// PublishServiceEvent forwards service store changes. It is meant to be
+// registered with ServiceStore.Subscribe. Every signed-in user receives the
+// events, so services are sent redacted; editors fetch the full service.
+func (b *EventBroker) PublishServiceEvent(event models.ServiceEvent) {
+	switch event.Type {
+	case models.ServiceCreated:
+		b.Publish(EventServiceCreated, event.Service.Redacted())
+	case models.ServiceUpdated:
+		b.Publish(EventServiceUpdated, event.Service.Redacted())
+	case models.ServiceDeleted:
+		b.Publish(EventServiceDeleted, map[string]string{"id": event.Service.ID})
+	}
+}
+
+// Subscribe registers a client. If lastEventID is set, the events published
+// after it are returned for replay; resync is true if some of them are no
+// longer available. The channel is closed if the client falls behind.
+func (b *EventBroker) Subscribe(lastEventID string) (events <-chan Event, replay []Event, resync bool, cancel func()) {
+	ch := make(chan Event, subscriberBufferSize)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if lastEventID != "" {
+		replay, resync = b.since(lastEventID)
+	}
+	b.subscribers[ch] = struct{}{}
+
+	cancel = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, replay, resync, cancel
+}
+
+// since returns the buffered events after lastEventID. Callers must hold the lock.
+func (b *EventBroker) since(lastEventID string) ([]Event, bool) {
+	epoch, seqText, ok := strings.Cut(lastEventID, "-")
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if !ok || err != nil || epoch != b.epoch || seq > b.seq {
+		// Unknown ID, most likely from before a restart
+		return nil, true
+	}
+
+	missed := int(b.seq - seq)
+	if missed > len(b.buffer) {
+		return nil, true
+	}
+
+	replay := make([]Event, missed)
+	copy(replay, b.buffer[len(b.buffer)-missed:])
+	return replay, false
+}
+
+// SubscriberCount returns the number of connected clients
+func (b *EventBroker) SubscriberCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.subscribers)
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"monitoring/models"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// AgentTokenPrefix starts every agent push token
+	AgentTokenPrefix = "agt_"
+
+	// hostWatchInterval is how often host statuses are re-evaluated
+	hostWatchInterval = 5 * time.Second
+
+	// minPushInterval and maxPushInterval bound the interval agents may report
+	minPushInterval = 1
+	maxPushInterval = 3600
+)
+
+var (
+	ErrInvalidAgentToken = errors.New("agent token is invalid")
+	ErrInvalidHostName   = errors.New("host name must be 1-128 characters")
+)
+
+// HostService manages hosts that push metrics from the monitoring agent and
+// tracks whether they are still reporting
+type HostService struct {
+	store       *models.HostStore
+	listeners   []func(*models.Host)
+	deleted     []func(id string)
+	listenersMu sync.RWMutex
+}
+
+// NewHostService creates a host service backed by the given store
+func NewHostService(store *models.HostStore) *HostService {
+	return &HostService{
+		store: store,
+	}
+}
+
+// Subscribe registers a listener that is called with the summary of a host
+// whenever it registers, pushes metrics or its status changes
+func (h *HostService) Subscribe(listener func(*models.Host)) {
+	h.listenersMu.Lock()
+	defer h.listenersMu.Unlock()
+	h.listeners = append(h.listeners, listener)
+}
+
+// SubscribeDeleted registers a listener that is called with the ID of a
+// deleted host
+func (h *HostService) SubscribeDeleted(listener func(id string)) {
+	h.listenersMu.Lock()
+	defer h.listenersMu.Unlock()
+	h.deleted = append(h.deleted, listener)
+}
+
+func (h *HostService) notifyListeners(host *models.Host) {
+	h.listenersMu.RLock()
+	listeners := h.listeners
+	h.listenersMu.RUnlock()
+
+	summary := host.Summary()
+	for _, listener := range listeners {
+		listener(summary)
+	}
+}
+
+// Start re-evaluates host statuses periodically, so hosts whose agents stop
+// pushing are marked stale and then down
+func (h *HostService) Start() {
+	h.updateStatuses()
+	go func() {
+		ticker := time.NewTicker(hostWatchInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			h.updateStatuses()
+		}
+	}()
+}
+
+func (h *HostService) updateStatuses() {
+	now := time.Now()
+	for _, host := range h.store.GetAll() {
+		status := host.StatusAt(now)
+		if !h.store.SetStatus(host.ID, status) {
+			continue
+		}
+		if status == models.HostStale || status == models.HostDown {
+			fmt.Printf("🖥️  Host %s is %s (last push %s ago)\n", host.Name, status, now.Sub(host.LastSeen).Round(time.Second))
+		}
+		host.Status = status
+		h.notifyListeners(host)
+	}
+}
+
+// CreateHost registers a host and issues its agent token. The returned string
+// is the only copy of the token; just its hash is stored.
+func (h *HostService) CreateHost(name string) (*models.Host, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 128 {
+		return nil, "", ErrInvalidHostName
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	value := AgentTokenPrefix + secret
+
+	host := &models.Host{
+		ID:          uuid.New().String(),
+		Name:        name,
+		TokenHash:   hashToken(value),
+		TokenPrefix: value[:len(AgentTokenPrefix)+6],
+		CreatedAt:   time.Now(),
+		Status:      models.HostPending,
+	}
+	if err := h.store.Add(host); err != nil {
+		return nil, "", err
+	}
+	return host.Public(), value, nil
+}
+
+// authenticateAgent returns the host an agent token belongs to
+func (h *HostService) authenticateAgent(token string) (*models.Host, error) {
+	if !strings.HasPrefix(token, AgentTokenPrefix) {
+		return nil, ErrInvalidAgentToken
+	}
+	host, err := h.store.GetByTokenHash(hashToken(token))
+	if err != nil {
+		return nil, ErrInvalidAgentToken
+	}
+	return host, nil
+}
+
+// Register records the facts reported by the agent holding the given token.
+// Agents register when they start pushing and periodically after that.
+func (h *HostService) Register(token string, facts *models.HostFacts) (*models.Host, error) {
+	host, err := h.authenticateAgent(token)
+	if err != nil {
+		return nil, err
+	}
+
+	host, err = h.store.SetFacts(host.ID, facts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("🖥️  Host %s registered (%s, agent %s)\n", host.Name, facts.OS, facts.AgentVersion)
+
+	h.notifyListeners(host)
+	return host.Public(), nil
+}
+
+// Push records metrics pushed by the agent holding the given token
+func (h *HostService) Push(token string, interval int, metrics *models.AgentMetrics) (*models.Host, error) {
+	host, err := h.authenticateAgent(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if interval < minPushInterval || interval > maxPushInterval {
+		interval = models.DefaultPushInterval
+	}
+
+	host, err = h.store.RecordPush(host.ID, time.Now(), interval, metrics)
+	if err != nil {
+		return nil, err
+	}
+	if h.store.SetStatus(host.ID, models.HostUp) {
+		fmt.Printf("🖥️  Host %s is reporting\n", host.Name)
+	}
+	host.Status = models.HostUp
+
+	h.notifyListeners(host)
+	return host.Public(), nil
+}
+
+// GetAll returns the summaries of all hosts, or of those with the given
+// status if it isn't empty
+func (h *HostService) GetAll(status models.HostStatus) []*models.Host {
+	hosts := make([]*models.Host, 0)
+	for _, host := range h.store.GetAll() {
+		if status != "" && host.Status != status {
+			continue
+		}
+		hosts = append(hosts, host.Summary())
+	}
+	return hosts
+}
+
+// Get returns a host with its facts and latest metrics
+func (h *HostService) Get(id string) (*models.Host, error) {
+	host, err := h.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return host.Public(), nil
+}
+
+// Delete removes a host. Its agent can no longer push with its token.
+func (h *HostService) Delete(id string) error {
+	host, err := h.store.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := h.store.Delete(id); err != nil {
+		return err
+	}
+	fmt.Printf("🖥️  Host %s deleted\n", host.Name)
+
+	h.listenersMu.RLock()
+	listeners := h.deleted
+	h.listenersMu.RUnlock()
+	for _, listener := range listeners {
+		listener(id)
+	}
+	return nil
+}
+
+// GetSamples returns a host's samples since the given time
+func (h *HostService) GetSamples(id string, since time.Time) ([]models.HostSample, error) {
+	return h.store.GetSamples(id, since)
+}
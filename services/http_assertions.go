@@ -0,0 +1,199 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"monitoring/models"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxAssertionBodySize limits how much of a response body is read for assertions
+const maxAssertionBodySize = 1 << 20 // 1 MB
+
+// statusCodeAllowed reports whether code matches any of the expected patterns.
+// Patterns may be exact codes ("200"), ranges ("200-204") or classes ("2xx").
+// An empty list accepts any 2xx or 3xx response.
+func statusCodeAllowed(code int, expected []string) (bool, error) {
+	if len(expected) == 0 {
+		return code >= 200 && code < 400, nil
+	}
+
+	for _, pattern := range expected {
+		matched, err := matchStatusCode(code, pattern)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+
+	return false, nil
+}
+
+// matchStatusCode reports whether code matches a single expected pattern.
+// A blank pattern matches nothing.
+func matchStatusCode(code int, pattern string) (bool, error) {
+	pattern = strings.TrimSpace(strings.ToLower(pattern))
+
+	switch {
+	case pattern == "":
+		return false, nil
+	case len(pattern) == 3 && strings.HasSuffix(pattern, "xx"):
+		class, err := strconv.Atoi(pattern[:1])
+		if err != nil {
+			return false, fmt.Errorf("invalid status code pattern %q", pattern)
+		}
+		return code/100 == class, nil
+	case strings.Contains(pattern, "-"):
+		bounds := strings.SplitN(pattern, "-", 2)
+		low, errLow := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		high, errHigh := strconv.Atoi(strings.TrimSpace(bounds[1]))
+		if errLow != nil || errHigh != nil || low > high {
+			return false, fmt.Errorf("invalid status code range %q", pattern)
+		}
+		return code >= low && code <= high, nil
+	default:
+		exact, err := strconv.Atoi(pattern)
+		if err != nil {
+			return false, fmt.Errorf("invalid status code %q", pattern)
+		}
+		return code == exact, nil
+	}
+}
+
+// evaluateBodyAssertions runs every assertion against the body and returns a
+// description of each one that failed
+func evaluateBodyAssertions(body []byte, assertions []models.BodyAssertion) []string {
+	var failures []string
+	var parsed interface{}
+	var parseErr error
+	parsedOnce := false
+
+	for _, assertion := range assertions {
+		switch assertion.Type {
+		case models.AssertContains:
+			if !strings.Contains(string(body), assertion.Value) {
+				failures = append(failures, fmt.Sprintf("body does not contain %q", assertion.Value))
+			}
+		case models.AssertNotContains:
+			if strings.Contains(string(body), assertion.Value) {
+				failures = append(failures, fmt.Sprintf("body contains forbidden keyword %q", assertion.Value))
+			}
+		case models.AssertRegex:
+			re, err := regexp.Compile(assertion.Value)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("invalid regex %q: %v", assertion.Value, err))
+			} else if !re.Match(body) {
+				failures = append(failures, fmt.Sprintf("body does not match regex %q", assertion.Value))
+			}
+		case models.AssertJSONPath:
+			if !parsedOnce {
+				parseErr = json.Unmarshal(body, &parsed)
+				parsedOnce = true
+			}
+			if parseErr != nil {
+				failures = append(failures, fmt.Sprintf("body is not valid JSON: %v", parseErr))
+				continue
+			}
+			value, ok := lookupJSONPath(parsed, assertion.Path)
+			if !ok {
+				failures = append(failures, fmt.Sprintf("JSON path %s not found", assertion.Path))
+			} else if actual := jsonValueString(value); actual != assertion.Value {
+				failures = append(failures, fmt.Sprintf("JSON path %s is %q, expected %q", assertion.Path, actual, assertion.Value))
+			}
+		default:
+			failures = append(failures, fmt.Sprintf("unknown assertion type %q", assertion.Type))
+		}
+	}
+
+	return failures
+}
+
+// lookupJSONPath resolves a simple dotted path such as "$.data.items[0].name"
+func lookupJSONPath(data interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return data, true
+	}
+
+	current := data
+	for _, segment := range strings.Split(path, ".") {
+		// Split "items[0][1]" into the key and its indexes
+		key := segment
+		var indexes []string
+		if open := strings.Index(segment, "["); open >= 0 {
+			key = segment[:open]
+			for _, part := range strings.Split(segment[open:], "[")[1:] {
+				indexes = append(indexes, strings.TrimSuffix(part, "]"))
+			}
+		}
+
+		if key != "" {
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if current, ok = object[key]; !ok {
+				return nil, false
+			}
+		}
+
+		for _, indexStr := range indexes {
+			index, err := strconv.Atoi(indexStr)
+			if err != nil {
+				return nil, false
+			}
+			array, ok := current.([]interface{})
+			if !ok || index < 0 || index >= len(array) {
+				return nil, false
+			}
+			current = array[index]
+		}
+	}
+
+	return current, true
+}
+
+// validateJSONPath checks that a path has the form lookupJSONPath understands
+func validateJSONPath(path string) error {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if trimmed == "" {
+		return nil
+	}
+
+	for _, segment := range strings.Split(trimmed, ".") {
+		key, indexes, _ := strings.Cut(segment, "[")
+		if key == "" && indexes == "" {
+			return fmt.Errorf("invalid JSON path %q: empty segment", path)
+		}
+		if !strings.Contains(segment, "[") {
+			continue
+		}
+		if !strings.HasSuffix(indexes, "]") {
+			return fmt.Errorf("invalid JSON path %q: unclosed index", path)
+		}
+		for _, index := range strings.Split(strings.TrimSuffix(indexes, "]"), "][") {
+			if n, err := strconv.Atoi(index); err != nil || n < 0 {
+				return fmt.Errorf("invalid JSON path %q: index %q is not a non-negative integer", path, index)
+			}
+		}
+	}
+	return nil
+}
+
+// jsonValueString formats a decoded JSON value for comparison with an expected string
+func jsonValueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return "null"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+}
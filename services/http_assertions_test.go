@@ -0,0 +1,125 @@
+package services
+
+import (
+	"monitoring/models"
+	"strings"
+	"testing"
+)
+
+func TestStatusCodeAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     int
+		expected []string
+		want     bool
+		wantErr  bool
+	}{
+		{name: "default accepts 2xx", code: 204, want: true},
+		{name: "default accepts 3xx", code: 301, want: true},
+		{name: "default rejects 4xx", code: 404},
+		{name: "exact", code: 201, expected: []string{"201"}, want: true},
+		{name: "exact mismatch", code: 200, expected: []string{"201"}},
+		{name: "class", code: 503, expected: []string{"5xx"}, want: true},
+		{name: "class is case insensitive", code: 418, expected: []string{" 4XX "}, want: true},
+		{name: "range", code: 204, expected: []string{"200-204"}, want: true},
+		{name: "past the range", code: 205, expected: []string{"200-204"}},
+		{name: "any of several", code: 401, expected: []string{"200", "401"}, want: true},
+		{name: "blank patterns ignored", code: 200, expected: []string{"", "200"}, want: true},
+		{name: "invalid code", code: 200, expected: []string{"ok"}, wantErr: true},
+		{name: "invalid class", code: 200, expected: []string{"axx"}, wantErr: true},
+		{name: "invalid range", code: 200, expected: []string{"200-abc"}, wantErr: true},
+		{name: "reversed range", code: 200, expected: []string{"204-200"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := statusCodeAllowed(test.code, test.expected)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("statusCodeAllowed(%d, %q) accepted an invalid pattern", test.code, test.expected)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("statusCodeAllowed(%d, %q): %v", test.code, test.expected, err)
+			}
+			if got != test.want {
+				t.Fatalf("statusCodeAllowed(%d, %q) = %v, want %v", test.code, test.expected, got, test.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateBodyAssertions(t *testing.T) {
+	body := []byte(`{"status":"ok","version":"1.4.2","healthy":true,"latency":12.5,"data":{"items":[{"name":"db"},{"name":"cache"}],"empty":null}}`)
+
+	tests := []struct {
+		name      string
+		assertion models.BodyAssertion
+		wantError string // Empty if the assertion passes
+	}{
+		{name: "contains", assertion: models.BodyAssertion{Type: models.AssertContains, Value: `"status":"ok"`}},
+		{name: "contains fails", assertion: models.BodyAssertion{Type: models.AssertContains, Value: "degraded"}, wantError: "does not contain"},
+		{name: "not contains", assertion: models.BodyAssertion{Type: models.AssertNotContains, Value: "error"}},
+		{name: "not contains fails", assertion: models.BodyAssertion{Type: models.AssertNotContains, Value: "cache"}, wantError: "forbidden keyword"},
+		{name: "regex", assertion: models.BodyAssertion{Type: models.AssertRegex, Value: `"version":"1\.\d+\.\d+"`}},
+		{name: "regex fails", assertion: models.BodyAssertion{Type: models.AssertRegex, Value: `"version":"2\.`}, wantError: "does not match regex"},
+		{name: "invalid regex", assertion: models.BodyAssertion{Type: models.AssertRegex, Value: `(`}, wantError: "invalid regex"},
+		{name: "JSON path string", assertion: models.BodyAssertion{Type: models.AssertJSONPath, Path: "$.status", Value: "ok"}},
+		{name: "JSON path without prefix", assertion: models.BodyAssertion{Type: models.AssertJSONPath, Path: "status", Value: "ok"}},
+		{name: "JSON path array index", assertion: models.BodyAssertion{Type: models.AssertJSONPath, Path: "$.data.items[1].name", Value: "cache"}},
+		{name: "JSON path bool", assertion: models.BodyAssertion{Type: models.AssertJSONPath, Path: "$.healthy", Value: "true"}},
+		{name: "JSON path number", assertion: models.BodyAssertion{Type: models.AssertJSONPath, Path: "$.latency", Value: "12.5"}},
+		{name: "JSON path null", assertion: models.BodyAssertion{Type: models.AssertJSONPath, Path: "$.data.empty", Value: "null"}},
+		{name: "JSON path object", assertion: models.BodyAssertion{Type: models.AssertJSONPath, Path: "$.data.items[0]", Value: `{"name":"db"}`}},
+		{name: "JSON path wrong value", assertion: models.BodyAssertion{Type: models.AssertJSONPath, Path: "$.status", Value: "down"}, wantError: `is "ok", expected "down"`},
+		{name: "JSON path missing key", assertion: models.BodyAssertion{Type: models.AssertJSONPath, Path: "$.data.missing", Value: "x"}, wantError: "not found"},
+		{name: "JSON path index out of range", assertion: models.BodyAssertion{Type: models.AssertJSONPath, Path: "$.data.items[5].name", Value: "x"}, wantError: "not found"},
+		{name: "unknown type", assertion: models.BodyAssertion{Type: "xpath", Value: "x"}, wantError: "unknown assertion type"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			failures := evaluateBodyAssertions(body, []models.BodyAssertion{test.assertion})
+			if test.wantError == "" {
+				if len(failures) != 0 {
+					t.Fatalf("failures = %q, want none", failures)
+				}
+				return
+			}
+			if len(failures) != 1 || !strings.Contains(failures[0], test.wantError) {
+				t.Fatalf("failures = %q, want one containing %q", failures, test.wantError)
+			}
+		})
+	}
+}
+
+func TestEvaluateBodyAssertionsInvalidJSON(t *testing.T) {
+	failures := evaluateBodyAssertions([]byte("<html>"), []models.BodyAssertion{
+		{Type: models.AssertJSONPath, Path: "$.status", Value: "ok"},
+		{Type: models.AssertContains, Value: "html"},
+	})
+	if len(failures) != 1 || !strings.Contains(failures[0], "not valid JSON") {
+		t.Fatalf("failures = %q, want one invalid JSON failure", failures)
+	}
+}
+
+func TestValidateJSONPath(t *testing.T) {
+	tests := map[string]bool{
+		"$":                    true,
+		"$.status":             true,
+		"status":               true,
+		"$.data.items[0].name": true,
+		"$.matrix[1][2]":       true,
+		"$[0]":                 true,
+		"$.data..name":         false,
+		"$.items[":             false,
+		"$.items[a]":           false,
+		"$.items[-1]":          false,
+	}
+	for path, valid := range tests {
+		if err := validateJSONPath(path); (err == nil) != valid {
+			t.Errorf("validateJSONPath(%q) = %v, want valid = %v", path, err, valid)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"monitoring/models"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	defaultPingCount    = 4
+	maxPingCount        = 20
+	pingSpacing         = 200 * time.Millisecond // pause between echo requests
+	minPingReplyWait    = 100 * time.Millisecond // shortest wait for a reply worth sending a request for
+	icmpProtocolV4      = 1
+	icmpProtocolV6      = 58
+	pingPayloadTokenLen = 8
+)
+
+// checkICMP sends a series of echo requests and judges the service on packet loss and latency
+func (m *MonitorService) checkICMP(service *models.MonitoredService, result *models.HealthCheckResult) *models.HealthCheckResult {
+	timeout, count := pingSettings(service)
+
+	// Services saved before ping_count was limited may ask for more requests
+	// than fit in the timeout; send as many as do
+	for count > 1 && pingReplyWait(count, timeout) < minPingReplyWait {
+		count--
+	}
+
+	stats, err := ping(service.Host, count, timeout)
+	if err != nil {
+		result.Status = models.StatusDown
+		result.ErrorMessage = fmt.Sprintf("ICMP check failed: %v", err)
+		return result
+	}
+
+	result.Ping = stats
+	result.ResponseTime = int64(math.Round(stats.AvgRTT))
+	result.Status, result.ErrorMessage = evaluatePingStats(service, stats)
+	return result
+}
+
+// pingSettings returns the check timeout and number of echo requests, with defaults applied
+func pingSettings(service *models.MonitoredService) (time.Duration, int) {
+	timeout := time.Duration(service.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	count := service.PingCount
+	if count <= 0 {
+		count = defaultPingCount
+	}
+	return timeout, min(count, maxPingCount)
+}
+
+// pingReplyWait returns how long to wait for each reply so that count
+// requests, spaced pingSpacing apart, finish within timeout
+func pingReplyWait(count int, timeout time.Duration) time.Duration {
+	return (timeout - time.Duration(count-1)*pingSpacing) / time.Duration(count)
+}
+
+// validatePingCount checks that ping_count is within limits and that the
+// requests fit in the service timeout
+func validatePingCount(service *models.MonitoredService) error {
+	if service.PingCount < 0 || service.PingCount > maxPingCount {
+		return fmt.Errorf("ping_count must be between 1 and %d", maxPingCount)
+	}
+	timeout, count := pingSettings(service)
+	if pingReplyWait(count, timeout) < minPingReplyWait {
+		return fmt.Errorf("%d echo requests %v apart do not fit in a %v timeout", count, pingSpacing, timeout)
+	}
+	return nil
+}
+
+// evaluatePingStats compares ping results with the service's loss and latency limits
+func evaluatePingStats(service *models.MonitoredService, stats *models.PingStats) (models.ServiceStatus, string) {
+	downLoss := service.DownPacketLoss
+	if downLoss <= 0 {
+		downLoss = 100
+	}
+
+	if stats.Received == 0 {
+		return models.StatusDown, fmt.Sprintf("No echo replies received (%d sent)", stats.Sent)
+	}
+	if stats.PacketLoss >= downLoss {
+		return models.StatusDown, fmt.Sprintf("Packet loss %.1f%% is at or above %.1f%%", stats.PacketLoss, downLoss)
+	}
+	if service.DownLatency > 0 && stats.AvgRTT > float64(service.DownLatency) {
+		return models.StatusDown, fmt.Sprintf("Average RTT %.1fms is above %dms", stats.AvgRTT, service.DownLatency)
+	}
+
+	var warnings []string
+	if service.DegradedPacketLoss > 0 && stats.PacketLoss >= service.DegradedPacketLoss {
+		warnings = append(warnings, fmt.Sprintf("packet loss %.1f%% is at or above %.1f%%", stats.PacketLoss, service.DegradedPacketLoss))
+	}
+	if service.DegradedLatency > 0 && stats.AvgRTT > float64(service.DegradedLatency) {
+		warnings = append(warnings, fmt.Sprintf("average RTT %.1fms is above %dms", stats.AvgRTT, service.DegradedLatency))
+	}
+	if len(warnings) > 0 {
+		return models.StatusDegraded, "Degraded: " + strings.Join(warnings, "; ")
+	}
+
+	return models.StatusUp, ""
+}
+
+// pingSocket wraps an ICMP socket together with how to address and parse packets on it
+type pingSocket struct {
+	conn         *icmp.PacketConn
+	protocol     int
+	echoType     icmp.Type
+	replyType    icmp.Type
+	unprivileged bool
+}
+
+// openPingSocket opens an unprivileged datagram ICMP socket where the kernel allows it
+// (net.ipv4.ping_group_range on Linux) and falls back to a raw socket otherwise
+func openPingSocket(ip net.IP) (*pingSocket, error) {
+	socket := &pingSocket{
+		protocol:  icmpProtocolV4,
+		echoType:  ipv4.ICMPTypeEcho,
+		replyType: ipv4.ICMPTypeEchoReply,
+	}
+	datagramNetwork, rawNetwork, listenAddr := "udp4", "ip4:icmp", "0.0.0.0"
+	if ip.To4() == nil {
+		socket.protocol = icmpProtocolV6
+		socket.echoType = ipv6.ICMPTypeEchoRequest
+		socket.replyType = ipv6.ICMPTypeEchoReply
+		datagramNetwork, rawNetwork, listenAddr = "udp6", "ip6:ipv6-icmp", "::"
+	}
+
+	conn, err := icmp.ListenPacket(datagramNetwork, listenAddr)
+	if err == nil {
+		socket.conn = conn
+		socket.unprivileged = true
+		return socket, nil
+	}
+
+	conn, rawErr := icmp.ListenPacket(rawNetwork, listenAddr)
+	if rawErr != nil {
+		return nil, fmt.Errorf("cannot open ICMP socket (datagram: %v; raw: %v)", err, rawErr)
+	}
+	socket.conn = conn
+	return socket, nil
+}
+
+// destination returns the address type expected by the socket
+func (p *pingSocket) destination(ip net.IP) net.Addr {
+	if p.unprivileged {
+		return &net.UDPAddr{IP: ip}
+	}
+	return &net.IPAddr{IP: ip}
+}
+
+// ping sends count echo requests to host and summarises the replies
+func ping(host string, count int, timeout time.Duration) (*models.PingStats, error) {
+	ipAddr, err := net.ResolveIPAddr("ip", host)
+	if err != nil {
+		return nil, err
+	}
+
+	socket, err := openPingSocket(ipAddr.IP)
+	if err != nil {
+		return nil, err
+	}
+	defer socket.conn.Close()
+
+	// A random token in the payload tells our replies apart from other pings on a raw socket
+	token := make([]byte, pingPayloadTokenLen)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	id := os.Getpid() & 0xffff
+
+	perPacket := pingReplyWait(count, timeout)
+
+	dst := socket.destination(ipAddr.IP)
+	rtts := make([]float64, 0, count)
+	for seq := 0; seq < count; seq++ {
+		if seq > 0 {
+			time.Sleep(pingSpacing)
+		}
+		rtt, err := socket.echo(dst, id, seq, token, perPacket)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue // lost packet
+			}
+			return nil, err
+		}
+		rtts = append(rtts, float64(rtt.Microseconds())/1000)
+	}
+
+	return summarizeRTTs(count, rtts), nil
+}
+
+// echo sends one echo request and waits for the matching reply
+func (p *pingSocket) echo(dst net.Addr, id, seq int, token []byte, wait time.Duration) (time.Duration, error) {
+	request := icmp.Message{
+		Type: p.echoType,
+		Body: &icmp.Echo{ID: id, Seq: seq, Data: token},
+	}
+	packet, err := request.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if _, err := p.conn.WriteTo(packet, dst); err != nil {
+		return 0, err
+	}
+
+	p.conn.SetReadDeadline(start.Add(wait))
+	buffer := make([]byte, 1500)
+	for {
+		n, _, err := p.conn.ReadFrom(buffer)
+		if err != nil {
+			return 0, err
+		}
+
+		reply, err := icmp.ParseMessage(p.protocol, buffer[:n])
+		if err != nil || reply.Type != p.replyType {
+			continue
+		}
+		body, ok := reply.Body.(*icmp.Echo)
+		if !ok || body.Seq != seq || !bytes.Equal(body.Data, token) {
+			continue
+		}
+		// Datagram sockets have their ID rewritten by the kernel, so only raw replies can be checked
+		if !p.unprivileged && body.ID != id {
+			continue
+		}
+
+		return time.Since(start), nil
+	}
+}
+
+// summarizeRTTs computes loss, min/avg/max and jitter from the round-trip times received
+func summarizeRTTs(sent int, rtts []float64) *models.PingStats {
+	stats := &models.PingStats{
+		Sent:     sent,
+		Received: len(rtts),
+	}
+	if sent > 0 {
+		stats.PacketLoss = roundTo(100*float64(sent-len(rtts))/float64(sent), 2)
+	}
+	if len(rtts) == 0 {
+		return stats
+	}
+
+	minRTT, maxRTT, total := rtts[0], rtts[0], 0.0
+	var jitterTotal float64
+	for i, rtt := range rtts {
+		minRTT = math.Min(minRTT, rtt)
+		maxRTT = math.Max(maxRTT, rtt)
+		total += rtt
+		if i > 0 {
+			jitterTotal += math.Abs(rtt - rtts[i-1])
+		}
+	}
+
+	stats.MinRTT = roundTo(minRTT, 3)
+	stats.MaxRTT = roundTo(maxRTT, 3)
+	stats.AvgRTT = roundTo(total/float64(len(rtts)), 3)
+	if len(rtts) > 1 {
+		stats.Jitter = roundTo(jitterTotal/float64(len(rtts)-1), 3)
+	}
+	return stats
+}
+
+// roundTo rounds a float to n decimal places
+func roundTo(value float64, precision int) float64 {
+	ratio := math.Pow(10, float64(precision))
+	return math.Round(value*ratio) / ratio
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"monitoring/models"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricsNamespace prefixes every exported metric name
+const metricsNamespace = "monitoring"
+
+// serviceLabels identify a service on every per-service metric. Tags are
+// joined as ",tag1,tag2," so they can be matched with tags=~".*,prod,.*".
+var serviceLabels = []string{"service_id", "name", "check_type", "tags"}
+
+// reportedStatuses are the states of the monitoring_service_status state set
+var reportedStatuses = []models.ServiceStatus{
+	models.StatusUp,
+	models.StatusDegraded,
+	models.StatusDown,
+	models.StatusPending,
+	models.StatusUnknown,
+}
+
+var (
+	serviceUpDesc = prometheus.NewDesc(
+		metricsNamespace+"_service_up",
+		"Whether the service is available (1 when up or degraded, 0 otherwise).",
+		serviceLabels, nil)
+	serviceStatusDesc = prometheus.NewDesc(
+		metricsNamespace+"_service_status",
+		"Current status of the service; 1 for the active status, 0 for the others.",
+		append(serviceLabels, "status"), nil)
+	serviceResponseTimeDesc = prometheus.NewDesc(
+		metricsNamespace+"_service_response_time_seconds",
+		"Response time of the last check.",
+		serviceLabels, nil)
+	serviceLastCheckDesc = prometheus.NewDesc(
+		metricsNamespace+"_service_last_check_timestamp_seconds",
+		"Unix time of the last check.",
+		serviceLabels, nil)
+	serviceSSLDaysLeftDesc = prometheus.NewDesc(
+		metricsNamespace+"_service_ssl_days_left",
+		"Days until the service's TLS certificate expires (HTTPS checks only).",
+		serviceLabels, nil)
+	serviceChecksDesc = prometheus.NewDesc(
+		metricsNamespace+"_service_checks_total",
+		"Checks run since startup, by outcome.",
+		append(serviceLabels, "outcome"), nil)
+
+	schedulerLagDesc = prometheus.NewDesc(
+		metricsNamespace+"_scheduler_lag_seconds",
+		"How long the service's last scheduled check waited for a free worker.",
+		serviceLabels, nil)
+	schedulerRunningDesc = prometheus.NewDesc(
+		metricsNamespace+"_scheduler_running_checks",
+		"Checks currently running.",
+		nil, nil)
+	schedulerMaxConcurrentDesc = prometheus.NewDesc(
+		metricsNamespace+"_scheduler_max_concurrent_checks",
+		"Maximum number of checks run at once (MAX_CONCURRENT_CHECKS).",
+		nil, nil)
+
+	notificationFailuresDesc = prometheus.NewDesc(
+		metricsNamespace+"_notification_failures_total",
+		"Failed alert sends since startup, by notification channel.",
+		[]string{"channel_id", "channel", "channel_type"}, nil)
+
+	systemCPUDesc = prometheus.NewDesc(
+		metricsNamespace+"_system_cpu_usage_percent",
+		"CPU usage of the monitoring host.",
+		nil, nil)
+	systemCPUCoresDesc = prometheus.NewDesc(
+		metricsNamespace+"_system_cpu_cores",
+		"Number of CPU cores of the monitoring host.",
+		nil, nil)
+	systemMemoryUsedDesc = prometheus.NewDesc(
+		metricsNamespace+"_system_memory_used_bytes",
+		"Memory used on the monitoring host.",
+		nil, nil)
+	systemMemoryTotalDesc = prometheus.NewDesc(
+		metricsNamespace+"_system_memory_total_bytes",
+		"Total memory of the monitoring host.",
+		nil, nil)
+	systemMemoryPercentDesc = prometheus.NewDesc(
+		metricsNamespace+"_system_memory_usage_percent",
+		"Memory usage of the monitoring host.",
+		nil, nil)
+	systemDiskUsedDesc = prometheus.NewDesc(
+		metricsNamespace+"_system_disk_used_bytes",
+		"Disk space used on the monitoring host.",
+		[]string{"device", "mountpoint"}, nil)
+	systemDiskTotalDesc = prometheus.NewDesc(
+		metricsNamespace+"_system_disk_total_bytes",
+		"Disk size on the monitoring host.",
+		[]string{"device", "mountpoint"}, nil)
+	systemDiskPercentDesc = prometheus.NewDesc(
+		metricsNamespace+"_system_disk_usage_percent",
+		"Disk usage on the monitoring host.",
+		[]string{"device", "mountpoint"}, nil)
+	systemUptimeDesc = prometheus.NewDesc(
+		metricsNamespace+"_system_uptime_seconds",
+		"Uptime of the monitoring host.",
+		nil, nil)
+)
+
+// MetricsCollector exposes service, scheduler, notification and system
+// metrics to Prometheus. Values are read when scraped, so nothing goes stale.
+type MetricsCollector struct {
+	store         *models.ServiceStore
+	scheduler     *Scheduler
+	notifications *NotificationService
+	system        *SystemService
+	checks        map[string]map[models.ServiceStatus]uint64 // check outcomes per service
+	mu            sync.Mutex
+}
+
+// NewMetricsCollector creates a collector and starts counting check results
+func NewMetricsCollector(store *models.ServiceStore, monitor *MonitorService, scheduler *Scheduler, notifications *NotificationService, system *SystemService) *MetricsCollector {
+	collector := &MetricsCollector{
+		store:         store,
+		scheduler:     scheduler,
+		notifications: notifications,
+		system:        system,
+		checks:        make(map[string]map[models.ServiceStatus]uint64),
+	}
+	monitor.Subscribe(collector.countCheck)
+	store.Subscribe(collector.handleStoreEvent)
+	return collector
+}
+
+// countCheck counts a check result by outcome
+func (m *MetricsCollector) countCheck(result *models.HealthCheckResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts, ok := m.checks[result.ServiceID]
+	if !ok {
+		counts = make(map[models.ServiceStatus]uint64)
+		m.checks[result.ServiceID] = counts
+	}
+	counts[result.Status]++
+}
+
+// handleStoreEvent drops the counters of deleted services
+func (m *MetricsCollector) handleStoreEvent(event models.ServiceEvent) {
+	if event.Type != models.ServiceDeleted {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.checks, event.Service.ID)
+}
+
+// Describe implements prometheus.Collector
+func (m *MetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		serviceUpDesc, serviceStatusDesc, serviceResponseTimeDesc, serviceLastCheckDesc,
+		serviceSSLDaysLeftDesc, serviceChecksDesc,
+		schedulerLagDesc, schedulerRunningDesc, schedulerMaxConcurrentDesc,
+		notificationFailuresDesc,
+		systemCPUDesc, systemCPUCoresDesc, systemMemoryUsedDesc, systemMemoryTotalDesc,
+		systemMemoryPercentDesc, systemDiskUsedDesc, systemDiskTotalDesc, systemDiskPercentDesc,
+		systemUptimeDesc,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector
+func (m *MetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	m.collectServices(ch)
+	m.collectScheduler(ch)
+	m.collectNotifications(ch)
+	m.collectSystem(ch)
+}
+
+func (m *MetricsCollector) collectServices(ch chan<- prometheus.Metric) {
+	m.mu.Lock()
+	checks := make(map[string]map[models.ServiceStatus]uint64, len(m.checks))
+	for id, counts := range m.checks {
+		copied := make(map[models.ServiceStatus]uint64, len(counts))
+		for status, count := range counts {
+			copied[status] = count
+		}
+		checks[id] = copied
+	}
+	m.mu.Unlock()
+
+	lags := m.scheduler.Lags()
+	now := time.Now()
+
+	for _, service := range m.store.GetAll() {
+		labels := serviceLabelValues(service)
+
+		status := service.Status
+		if status == "" {
+			status = models.StatusUnknown
+		}
+		ch <- prometheus.MustNewConstMetric(serviceUpDesc, prometheus.GaugeValue, boolValue(status.IsAvailable()), labels...)
+		for _, reported := range reportedStatuses {
+			ch <- prometheus.MustNewConstMetric(serviceStatusDesc, prometheus.GaugeValue, boolValue(status == reported), append(labels, string(reported))...)
+		}
+
+		if !service.LastCheck.IsZero() {
+			ch <- prometheus.MustNewConstMetric(serviceResponseTimeDesc, prometheus.GaugeValue, float64(service.ResponseTime)/1000, labels...)
+			ch <- prometheus.MustNewConstMetric(serviceLastCheckDesc, prometheus.GaugeValue, float64(service.LastCheck.UnixMilli())/1000, labels...)
+		}
+		if !service.SSLCertExpiry.IsZero() {
+			daysLeft := service.SSLCertExpiry.Sub(now).Hours() / 24
+			ch <- prometheus.MustNewConstMetric(serviceSSLDaysLeftDesc, prometheus.GaugeValue, daysLeft, labels...)
+		}
+
+		// Every outcome is always exported, so rate() works from the first failure
+		for _, outcome := range []models.ServiceStatus{models.StatusUp, models.StatusDegraded, models.StatusDown} {
+			count := checks[service.ID][outcome]
+			ch <- prometheus.MustNewConstMetric(serviceChecksDesc, prometheus.CounterValue, float64(count), append(labels, string(outcome))...)
+		}
+
+		if lag, ok := lags[service.ID]; ok {
+			ch <- prometheus.MustNewConstMetric(schedulerLagDesc, prometheus.GaugeValue, lag.Seconds(), labels...)
+		}
+	}
+}
+
+func (m *MetricsCollector) collectScheduler(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(schedulerRunningDesc, prometheus.GaugeValue, float64(m.scheduler.RunningChecks()))
+	ch <- prometheus.MustNewConstMetric(schedulerMaxConcurrentDesc, prometheus.GaugeValue, float64(m.scheduler.MaxConcurrentChecks()))
+}
+
+func (m *MetricsCollector) collectNotifications(ch chan<- prometheus.Metric) {
+	failures := m.notifications.SendFailures()
+
+	// Channels without failures are exported too, so alerts can use increase()
+	for _, channel := range m.notifications.GetAll() {
+		ch <- prometheus.MustNewConstMetric(notificationFailuresDesc, prometheus.CounterValue,
+			float64(failures[channel.ID]), channel.ID, channel.Name, string(channel.Type))
+	}
+}
+
+// collectSystem reads the host metrics
+func (m *MetricsCollector) collectSystem(ch chan<- prometheus.Metric) {
+	info, err := m.system.GetSystemInfo()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(systemUptimeDesc, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(systemCPUDesc, prometheus.GaugeValue, info.CPUInfo.UsagePercent)
+	ch <- prometheus.MustNewConstMetric(systemCPUCoresDesc, prometheus.GaugeValue, float64(info.CPUInfo.Cores))
+	ch <- prometheus.MustNewConstMetric(systemMemoryUsedDesc, prometheus.GaugeValue, float64(info.MemoryInfo.Used))
+	ch <- prometheus.MustNewConstMetric(systemMemoryTotalDesc, prometheus.GaugeValue, float64(info.MemoryInfo.Total))
+	ch <- prometheus.MustNewConstMetric(systemMemoryPercentDesc, prometheus.GaugeValue, info.MemoryInfo.UsedPercent)
+	ch <- prometheus.MustNewConstMetric(systemUptimeDesc, prometheus.GaugeValue, float64(info.Uptime))
+
+	seen := make(map[string]bool)
+	for _, disk := range info.DiskInfo {
+		// Bind mounts can list the same mountpoint twice
+		if seen[disk.Mountpoint] {
+			continue
+		}
+		seen[disk.Mountpoint] = true
+		ch <- prometheus.MustNewConstMetric(systemDiskUsedDesc, prometheus.GaugeValue, float64(disk.Used), disk.Device, disk.Mountpoint)
+		ch <- prometheus.MustNewConstMetric(systemDiskTotalDesc, prometheus.GaugeValue, float64(disk.Total), disk.Device, disk.Mountpoint)
+		ch <- prometheus.MustNewConstMetric(systemDiskPercentDesc, prometheus.GaugeValue, disk.UsedPercent, disk.Device, disk.Mountpoint)
+	}
+}
+
+// serviceLabelValues returns the values for serviceLabels
+func serviceLabelValues(service *models.MonitoredService) []string {
+	checkType := service.CheckType
+	if checkType == "" {
+		checkType = models.CheckTypeHTTP
+	}
+
+	tags := ""
+	if len(service.Tags) > 0 {
+		tags = "," + strings.Join(service.Tags, ",") + ","
+	}
+	return []string{service.ID, service.Name, string(checkType), tags}
+}
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
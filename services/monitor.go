@@ -3,30 +3,49 @@ package services
 import (
 	"crypto/tls"
 	"fmt"
+	"io"
 	"monitoring/models"
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+// failedCheckRetryDelay is the pause before re-checking a failed service
+const failedCheckRetryDelay = time.Second
+
 // MonitorService handles health checking of services
 type MonitorService struct {
-	store    *models.ServiceStore
-	history  *models.HistoryStore
-	telegram *TelegramService
+	store         *models.ServiceStore
+	history       *models.HistoryStore
+	incidents     *models.IncidentStore
+	notifications *NotificationService
+	listeners     []func(*models.HealthCheckResult)
+	listenersMu   sync.RWMutex
 }
 
 // NewMonitorService creates a new monitor service
-func NewMonitorService(store *models.ServiceStore, history *models.HistoryStore, telegram *TelegramService) *MonitorService {
+func NewMonitorService(store *models.ServiceStore, history *models.HistoryStore, incidents *models.IncidentStore, notifications *NotificationService) *MonitorService {
 	return &MonitorService{
-		store:    store,
-		history:  history,
-		telegram: telegram,
+		store:         store,
+		history:       history,
+		incidents:     incidents,
+		notifications: notifications,
 	}
 }
 
+// Subscribe registers a listener that is called after every check result is recorded
+func (m *MonitorService) Subscribe(listener func(*models.HealthCheckResult)) {
+	m.listenersMu.Lock()
+	defer m.listenersMu.Unlock()
+	m.listeners = append(m.listeners, listener)
+}
+
 // CheckService performs a health check on a single service
 func (m *MonitorService) CheckService(service *models.MonitoredService) *models.HealthCheckResult {
 	result := &models.HealthCheckResult{
@@ -46,6 +65,12 @@ func (m *MonitorService) CheckService(service *models.MonitoredService) *models.
 		return m.checkTCPPort(service, result)
 	case models.CheckTypeUDP:
 		return m.checkUDPPort(service, result)
+	case models.CheckTypeDNS:
+		return m.checkDNS(service, result)
+	case models.CheckTypeICMP:
+		return m.checkICMP(service, result)
+	case models.CheckTypeAgent:
+		return m.checkAgent(service, result)
 	default: // HTTP
 		return m.checkHTTP(service, result)
 	}
@@ -62,24 +87,65 @@ func (m *MonitorService) checkHTTP(service *models.MonitoredService, result *mod
 		Timeout: timeout,
 	}
 
-	start := time.Now()
-	resp, err := client.Get(service.URL)
-	elapsed := time.Since(start)
+	method := strings.ToUpper(service.HTTPMethod)
+	if method == "" {
+		method = http.MethodGet
+	}
 
-	result.ResponseTime = elapsed.Milliseconds()
+	var body io.Reader
+	if service.HTTPBody != "" {
+		body = strings.NewReader(service.HTTPBody)
+	}
 
+	req, err := http.NewRequest(method, service.URL, body)
 	if err != nil {
+		result.Status = models.StatusDown
+		result.ErrorMessage = fmt.Sprintf("Invalid request: %v", err)
+		return result
+	}
+	for name, value := range service.HTTPHeaders {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.ResponseTime = time.Since(start).Milliseconds()
 		result.Status = models.StatusDown
 		result.ErrorMessage = err.Error()
 		return result
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= 200 && resp.StatusCode < 400 {
+	// Read the body before timing so assertions see the full response
+	var respBody []byte
+	if len(service.BodyAssertions) > 0 {
+		respBody, err = io.ReadAll(io.LimitReader(resp.Body, maxAssertionBodySize))
+	}
+	result.ResponseTime = time.Since(start).Milliseconds()
+
+	var failures []string
+	if allowed, patternErr := statusCodeAllowed(resp.StatusCode, service.ExpectedStatusCodes); patternErr != nil {
+		failures = append(failures, patternErr.Error())
+	} else if !allowed {
+		failures = append(failures, fmt.Sprintf("HTTP status code: %d", resp.StatusCode))
+	}
+
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("failed to read response body: %v", err))
+	} else if len(service.BodyAssertions) > 0 {
+		failures = append(failures, evaluateBodyAssertions(respBody, service.BodyAssertions)...)
+	}
+
+	if len(failures) == 0 {
 		result.Status = models.StatusUp
 	} else {
 		result.Status = models.StatusDown
-		result.ErrorMessage = fmt.Sprintf("HTTP status code: %d", resp.StatusCode)
+		result.ErrorMessage = strings.Join(failures, "; ")
 	}
 
 	// Check SSL certificate if it's an HTTPS URL
@@ -97,7 +163,7 @@ func (m *MonitorService) checkTCPPort(service *models.MonitoredService, result *
 		timeout = 10 * time.Second
 	}
 
-	address := fmt.Sprintf("%s:%d", service.Host, service.Port)
+	address := net.JoinHostPort(service.Host, strconv.Itoa(service.Port))
 	start := time.Now()
 
 	conn, err := net.DialTimeout("tcp", address, timeout)
@@ -123,7 +189,7 @@ func (m *MonitorService) checkUDPPort(service *models.MonitoredService, result *
 		timeout = 10 * time.Second
 	}
 
-	address := fmt.Sprintf("%s:%d", service.Host, service.Port)
+	address := net.JoinHostPort(service.Host, strconv.Itoa(service.Port))
 	start := time.Now()
 
 	// Resolve UDP address
@@ -214,39 +280,76 @@ func (m *MonitorService) checkSSLCertificate(serviceURL string, result *models.H
 	}
 }
 
-// UpdateServiceStatus updates the service based on health check result
+// UpdateServiceStatus updates the service based on health check result. Only
+// the status fields are changed, on the current record under the store lock,
+// so an edit saved while the check ran is kept.
 func (m *MonitorService) UpdateServiceStatus(result *models.HealthCheckResult) error {
-	service, err := m.store.Get(result.ServiceID)
-	if err != nil {
-		return err
-	}
+	var sendSSLAlert, sendUpAlert, sendDownAlert bool
+	service, err := m.store.Modify(result.ServiceID, func(service *models.MonitoredService) {
+		// Alerts follow the confirmed status; data saved before confirmation
+		// existed only has Status
+		previousStatus := service.ConfirmedStatus
+		if previousStatus == "" {
+			previousStatus = service.Status
+			if previousStatus == models.StatusPending || previousStatus == "" {
+				previousStatus = models.StatusUnknown
+			}
+		}
 
-	// Track previous status for notification logic
-	previousStatus := service.Status
-
-	service.Status = result.Status
-	service.LastCheck = result.CheckedAt
-	service.ResponseTime = result.ResponseTime
-	service.ErrorMessage = result.ErrorMessage
-
-	// Update SSL certificate info if available
-	if !result.SSLCertExpiry.IsZero() {
-		service.SSLCertExpiry = result.SSLCertExpiry
-		service.SSLCertIssuer = result.SSLCertIssuer
-		service.SSLDaysLeft = result.SSLDaysLeft
-
-		// Send SSL expiry alert if certificate expires in 30 days or less
-		if result.SSLDaysLeft <= 30 && result.SSLDaysLeft > 0 && !service.SSLAlertSent {
-			go func() {
-				if err := m.telegram.SendSSLExpiryAlert(service); err != nil {
-					fmt.Printf("Failed to send SSL expiry alert for %s: %v\n", service.Name, err)
-				}
-			}()
-			service.SSLAlertSent = true
-		} else if result.SSLDaysLeft > 30 {
-			// Reset alert flag when SSL has more than 30 days
-			service.SSLAlertSent = false
+		service.LastCheck = result.CheckedAt
+		service.ResponseTime = result.ResponseTime
+		service.ErrorMessage = result.ErrorMessage
+
+		// Update SSL certificate info if available
+		if !result.SSLCertExpiry.IsZero() {
+			service.SSLCertExpiry = result.SSLCertExpiry
+			service.SSLCertIssuer = result.SSLCertIssuer
+			service.SSLDaysLeft = result.SSLDaysLeft
+
+			// Send SSL expiry alert if certificate expires in 30 days or less
+			if result.SSLDaysLeft <= 30 && result.SSLDaysLeft > 0 && !service.SSLAlertSent {
+				sendSSLAlert = true
+				service.SSLAlertSent = true
+			} else if result.SSLDaysLeft > 30 {
+				// Reset alert flag when SSL has more than 30 days
+				service.SSLAlertSent = false
+			}
 		}
+
+		if result.Status.IsAvailable() {
+			service.LastUptime = result.CheckedAt
+			service.ConsecutiveSuccesses++
+			service.ConsecutiveFailures = 0
+
+			upAfter := max(service.UpAfter, 1)
+			if previousStatus == models.StatusDown && service.ConsecutiveSuccesses < upAfter {
+				service.Status = models.StatusPending
+				service.ErrorMessage = fmt.Sprintf("Recovering: %d of %d successful checks", service.ConsecutiveSuccesses, upAfter)
+			} else {
+				service.Status = result.Status
+				service.ConfirmedStatus = result.Status
+				// Send recovery notification if service was previously down
+				sendUpAlert = previousStatus == models.StatusDown
+			}
+		} else if result.Status == models.StatusDown {
+			service.LastDowntime = result.CheckedAt
+			service.ConsecutiveFailures++
+			service.ConsecutiveSuccesses = 0
+
+			downAfter := max(service.DownAfter, 1)
+			if previousStatus != models.StatusDown && service.ConsecutiveFailures < downAfter {
+				service.Status = models.StatusPending
+				service.ErrorMessage = fmt.Sprintf("Failure %d of %d: %s", service.ConsecutiveFailures, downAfter, result.ErrorMessage)
+			} else {
+				service.Status = models.StatusDown
+				service.ConfirmedStatus = models.StatusDown
+				// Send down notification if service was previously up or unknown
+				sendDownAlert = previousStatus.IsAvailable() || previousStatus == models.StatusUnknown
+			}
+		}
+	})
+	if err != nil {
+		return err
 	}
 
 	// Log the check result to history
@@ -255,41 +358,55 @@ func (m *MonitorService) UpdateServiceStatus(result *models.HealthCheckResult) e
 		Status:       result.Status,
 		ResponseTime: result.ResponseTime,
 		ErrorMessage: result.ErrorMessage,
+		Ping:         result.Ping,
 	})
 
-	if result.Status == models.StatusUp {
-		service.LastUptime = result.CheckedAt
-		// Send recovery notification if service was previously down
-		if previousStatus == models.StatusDown {
-			go func() {
-				if err := m.telegram.SendServiceUpAlert(service); err != nil {
-					fmt.Printf("Failed to send Telegram up alert for %s: %v\n", service.Name, err)
-				}
-			}()
-		}
-	} else if result.Status == models.StatusDown {
-		service.LastDowntime = result.CheckedAt
-		// Send down notification if service was previously up or unknown
-		if previousStatus == models.StatusUp || previousStatus == models.StatusUnknown {
-			go func() {
-				if err := m.telegram.SendServiceDownAlert(service); err != nil {
-					fmt.Printf("Failed to send Telegram down alert for %s: %v\n", service.Name, err)
-				}
-			}()
-		}
+	if sendSSLAlert {
+		go func() {
+			if err := m.notifications.SendSSLExpiryAlert(service); err != nil {
+				fmt.Printf("Failed to send SSL expiry alert for %s: %v\n", service.Name, err)
+			}
+		}()
+	}
+	if sendUpAlert {
+		m.incidents.Resolve(service.ID, result.CheckedAt)
+		go func() {
+			if err := m.notifications.SendServiceUpAlert(service); err != nil {
+				fmt.Printf("Failed to send up alert for %s: %v\n", service.Name, err)
+			}
+		}()
+	}
+	if sendDownAlert {
+		m.incidents.Open(&models.Incident{
+			ID:          uuid.New().String(),
+			ServiceID:   service.ID,
+			ServiceName: service.Name,
+			StartedAt:   result.CheckedAt,
+			Message:     result.ErrorMessage,
+		})
+		go func() {
+			if err := m.notifications.SendServiceDownAlert(service); err != nil {
+				fmt.Printf("Failed to send down alert for %s: %v\n", service.Name, err)
+			}
+		}()
 	}
 
-	return m.store.Update(service)
+	m.listenersMu.RLock()
+	listeners := m.listeners
+	m.listenersMu.RUnlock()
+	for _, listener := range listeners {
+		listener(result)
+	}
+	return nil
 }
 
-// CheckAll performs health checks on all monitored services
-func (m *MonitorService) CheckAll() {
-	services := m.store.GetAll()
-
-	for _, service := range services {
-		result := m.CheckService(service)
-		if err := m.UpdateServiceStatus(result); err != nil {
-			fmt.Printf("Error updating service %s: %v\n", service.ID, err)
-		}
+// RunCheck checks a service and re-checks it up to RetryCount times if it
+// fails, so a single dropped packet does not count as a failure
+func (m *MonitorService) RunCheck(service *models.MonitoredService) *models.HealthCheckResult {
+	result := m.CheckService(service)
+	for retry := 0; retry < service.RetryCount && result.Status == models.StatusDown; retry++ {
+		time.Sleep(failedCheckRetryDelay)
+		result = m.CheckService(service)
 	}
+	return result
 }
@@ -0,0 +1,568 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"monitoring/models"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LegacyTelegramChannelID is the channel backing the /api/telegram endpoints
+const LegacyTelegramChannelID = "telegram"
+
+// maxDeliveryAttempts is how many delivery attempts are kept per channel
+const maxDeliveryAttempts = 100
+
+var (
+	ErrChannelNotFound = errors.New("notification channel not found")
+	ErrChannelExists   = errors.New("notification channel already exists")
+)
+
+// Notifier delivers alerts to a single notification channel
+type Notifier interface {
+	SendServiceDownAlert(service *models.MonitoredService) error
+	SendServiceUpAlert(service *models.MonitoredService) error
+	SendSSLExpiryAlert(service *models.MonitoredService) error
+	SendSystemAlert(resourceType string, device string, currentValue float64, threshold float64) error
+	SendTestMessage() error
+}
+
+// newNotifier builds the Notifier implementation for a channel's type
+func (n *NotificationService) newNotifier(channel *models.NotificationChannel) (Notifier, error) {
+	switch channel.Type {
+	case models.ChannelTelegram:
+		if channel.Telegram == nil {
+			return nil, errors.New("telegram config is required")
+		}
+		return NewTelegramService(channel.Telegram), nil
+	case models.ChannelWebhook:
+		if channel.Webhook == nil {
+			return nil, errors.New("webhook config is required")
+		}
+		return NewWebhookService(channel.ID, channel.Webhook, n.recordDelivery)
+	case models.ChannelEmail:
+		if channel.Email == nil {
+			return nil, errors.New("email config is required")
+		}
+		return NewEmailService(channel.Email)
+	default:
+		return nil, fmt.Errorf("unknown channel type %q", channel.Type)
+	}
+}
+
+// NotificationService is the registry of configured notification channels and
+// routes alerts to the channels each service has selected
+type NotificationService struct {
+	channels   map[string]*models.NotificationChannel
+	notifiers  map[string]Notifier
+	mu         sync.RWMutex
+	onSave     func() // callback when channels change
+	deliveries map[string][]models.DeliveryAttempt
+	failures   map[string]uint64 // failed alert sends per channel, since startup
+	deliveryMu sync.RWMutex
+}
+
+// NewNotificationService creates an empty channel registry
+func NewNotificationService() *NotificationService {
+	return &NotificationService{
+		channels:   make(map[string]*models.NotificationChannel),
+		notifiers:  make(map[string]Notifier),
+		deliveries: make(map[string][]models.DeliveryAttempt),
+		failures:   make(map[string]uint64),
+	}
+}
+
+// SetOnSave sets the callback for when channels change
+func (n *NotificationService) SetOnSave(onSave func()) {
+	n.onSave = onSave
+}
+
+// triggerSave calls the onSave callback if set
+func (n *NotificationService) triggerSave() {
+	if n.onSave != nil {
+		go n.onSave()
+	}
+}
+
+// LoadChannels loads channels from persistence, skipping any that are invalid
+func (n *NotificationService) LoadChannels(channels map[string]*models.NotificationChannel) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for id, channel := range channels {
+		// Channels saved before max_retries was limited would otherwise be dropped
+		if webhook := channel.Webhook; webhook != nil && webhook.MaxRetries != nil && *webhook.MaxRetries > maxWebhookRetries {
+			fmt.Printf("Warning: limiting max_retries of notification channel %s to %d\n", id, maxWebhookRetries)
+			retries := maxWebhookRetries
+			webhook.MaxRetries = &retries
+		}
+
+		notifier, err := n.newNotifier(channel)
+		if err != nil {
+			fmt.Printf("Warning: skipping notification channel %s: %v\n", id, err)
+			continue
+		}
+		n.channels[id] = channel
+		n.notifiers[id] = notifier
+	}
+}
+
+// GetAllAsMap returns all channels as a map (for persistence)
+func (n *NotificationService) GetAllAsMap() map[string]*models.NotificationChannel {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	channelsCopy := make(map[string]*models.NotificationChannel)
+	for k, v := range n.channels {
+		channelsCopy[k] = v
+	}
+	return channelsCopy
+}
+
+// GetAll returns all channels with secrets masked, sorted by creation date
+func (n *NotificationService) GetAll() []*models.NotificationChannel {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	channels := make([]*models.NotificationChannel, 0, len(n.channels))
+	for _, channel := range n.channels {
+		channels = append(channels, maskChannel(channel))
+	}
+
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].CreatedAt.Before(channels[j].CreatedAt)
+	})
+
+	return channels
+}
+
+// Get returns a channel with secrets masked
+func (n *NotificationService) Get(id string) (*models.NotificationChannel, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	channel, exists := n.channels[id]
+	if !exists {
+		return nil, ErrChannelNotFound
+	}
+	return maskChannel(channel), nil
+}
+
+// Add validates and registers a new channel
+func (n *NotificationService) Add(channel *models.NotificationChannel) error {
+	if channel.ID == "" {
+		channel.ID = uuid.New().String()
+	}
+	if channel.CreatedAt.IsZero() {
+		channel.CreatedAt = time.Now()
+	}
+
+	notifier, err := n.newNotifier(channel)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, exists := n.channels[channel.ID]; exists {
+		return ErrChannelExists
+	}
+
+	n.channels[channel.ID] = channel
+	n.notifiers[channel.ID] = notifier
+	n.triggerSave()
+	return nil
+}
+
+// Update replaces an existing channel. Masked secrets sent back by clients are
+// replaced with the stored values.
+func (n *NotificationService) Update(channel *models.NotificationChannel) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	existing, exists := n.channels[channel.ID]
+	if !exists {
+		return ErrChannelNotFound
+	}
+
+	channel.CreatedAt = existing.CreatedAt
+	preserveSecrets(existing, channel)
+
+	notifier, err := n.newNotifier(channel)
+	if err != nil {
+		return err
+	}
+
+	n.channels[channel.ID] = channel
+	n.notifiers[channel.ID] = notifier
+	n.triggerSave()
+	return nil
+}
+
+// Delete removes a channel
+func (n *NotificationService) Delete(id string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, exists := n.channels[id]; !exists {
+		return ErrChannelNotFound
+	}
+
+	delete(n.channels, id)
+	delete(n.notifiers, id)
+	n.triggerSave()
+
+	n.deliveryMu.Lock()
+	delete(n.deliveries, id)
+	delete(n.failures, id)
+	n.deliveryMu.Unlock()
+	return nil
+}
+
+// Test sends a test message through a channel, even if it is disabled
+func (n *NotificationService) Test(id string) error {
+	n.mu.RLock()
+	notifier, exists := n.notifiers[id]
+	n.mu.RUnlock()
+
+	if !exists {
+		return ErrChannelNotFound
+	}
+	return notifier.SendTestMessage()
+}
+
+// TestConfig sends a test message through an unsaved channel configuration
+func (n *NotificationService) TestConfig(channel *models.NotificationChannel) error {
+	notifier, err := n.newNotifier(channel)
+	if err != nil {
+		return err
+	}
+	return notifier.SendTestMessage()
+}
+
+// recordDelivery appends a delivery attempt to the channel's log
+func (n *NotificationService) recordDelivery(attempt models.DeliveryAttempt) {
+	n.deliveryMu.Lock()
+	defer n.deliveryMu.Unlock()
+
+	log := append(n.deliveries[attempt.ChannelID], attempt)
+	if len(log) > maxDeliveryAttempts {
+		log = log[len(log)-maxDeliveryAttempts:]
+	}
+	n.deliveries[attempt.ChannelID] = log
+
+	if !attempt.Success {
+		fmt.Printf("Notification delivery to %s failed (attempt %d): %s\n", attempt.ChannelID, attempt.Attempt, attempt.Error)
+	}
+}
+
+// GetDeliveries returns the recorded delivery attempts for a channel, newest first
+func (n *NotificationService) GetDeliveries(id string) ([]models.DeliveryAttempt, error) {
+	n.mu.RLock()
+	_, exists := n.channels[id]
+	n.mu.RUnlock()
+	if !exists {
+		return nil, ErrChannelNotFound
+	}
+
+	n.deliveryMu.RLock()
+	defer n.deliveryMu.RUnlock()
+
+	log := n.deliveries[id]
+	attempts := make([]models.DeliveryAttempt, len(log))
+	for i, attempt := range log {
+		attempts[len(log)-1-i] = attempt
+	}
+	return attempts, nil
+}
+
+// SendFailures returns the number of failed alert sends per channel ID since startup
+func (n *NotificationService) SendFailures() map[string]uint64 {
+	n.deliveryMu.RLock()
+	defer n.deliveryMu.RUnlock()
+
+	failures := make(map[string]uint64, len(n.failures))
+	for id, count := range n.failures {
+		failures[id] = count
+	}
+	return failures
+}
+
+// HasDefaultChannels reports whether any enabled default channel exists
+func (n *NotificationService) HasDefaultChannels() bool {
+	return len(n.defaultNotifiers()) > 0
+}
+
+// defaultNotifiers returns the enabled channels marked as default
+func (n *NotificationService) defaultNotifiers() map[string]Notifier {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	notifiers := make(map[string]Notifier)
+	for id, channel := range n.channels {
+		if channel.Enabled && channel.Default {
+			notifiers[id] = n.notifiers[id]
+		}
+	}
+	return notifiers
+}
+
+// notifiersForService returns the enabled channels a service alerts
+func (n *NotificationService) notifiersForService(service *models.MonitoredService) map[string]Notifier {
+	if service.AlertsDisabled {
+		return nil
+	}
+	if len(service.NotificationChannels) == 0 {
+		return n.defaultNotifiers()
+	}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	notifiers := make(map[string]Notifier)
+	for _, id := range service.NotificationChannels {
+		if channel, exists := n.channels[id]; exists && channel.Enabled {
+			notifiers[id] = n.notifiers[id]
+		}
+	}
+	return notifiers
+}
+
+// dispatch sends an alert through each notifier concurrently, so a slow or
+// retrying channel doesn't hold up the others, and collects the failures
+func (n *NotificationService) dispatch(notifiers map[string]Notifier, send func(Notifier) error) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for id, notifier := range notifiers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := send(notifier); err != nil {
+				err = fmt.Errorf("channel %s: %w", n.channelName(id), err)
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+
+				n.deliveryMu.Lock()
+				n.failures[id]++
+				n.deliveryMu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// channelName returns a channel's display name for log messages
+func (n *NotificationService) channelName(id string) string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if channel, exists := n.channels[id]; exists && channel.Name != "" {
+		return channel.Name
+	}
+	return id
+}
+
+// SendServiceDownAlert notifies the service's channels that it went down
+func (n *NotificationService) SendServiceDownAlert(service *models.MonitoredService) error {
+	return n.dispatch(n.notifiersForService(service), func(notifier Notifier) error {
+		return notifier.SendServiceDownAlert(service)
+	})
+}
+
+// SendServiceUpAlert notifies the service's channels that it recovered
+func (n *NotificationService) SendServiceUpAlert(service *models.MonitoredService) error {
+	return n.dispatch(n.notifiersForService(service), func(notifier Notifier) error {
+		return notifier.SendServiceUpAlert(service)
+	})
+}
+
+// SendSSLExpiryAlert notifies the service's channels that its certificate expires soon
+func (n *NotificationService) SendSSLExpiryAlert(service *models.MonitoredService) error {
+	return n.dispatch(n.notifiersForService(service), func(notifier Notifier) error {
+		return notifier.SendSSLExpiryAlert(service)
+	})
+}
+
+// SendSystemAlert notifies the default channels about a system resource alert
+func (n *NotificationService) SendSystemAlert(resourceType string, device string, currentValue float64, threshold float64) error {
+	return n.dispatch(n.defaultNotifiers(), func(notifier Notifier) error {
+		return notifier.SendSystemAlert(resourceType, device, currentValue, threshold)
+	})
+}
+
+// MigrateLegacyTelegram converts the old global Telegram config and per-service
+// Telegram overrides into notification channels. It returns true if anything changed.
+func (n *NotificationService) MigrateLegacyTelegram(legacy *models.TelegramConfig, services []*models.MonitoredService) bool {
+	changed := false
+
+	n.mu.RLock()
+	_, hasLegacyChannel := n.channels[LegacyTelegramChannelID]
+	n.mu.RUnlock()
+
+	if legacy != nil && legacy.BotToken != "" && !hasLegacyChannel {
+		n.Add(&models.NotificationChannel{
+			ID:       LegacyTelegramChannelID,
+			Name:     "Telegram",
+			Type:     models.ChannelTelegram,
+			Enabled:  legacy.Enabled,
+			Default:  true,
+			Telegram: &models.TelegramConfig{BotToken: legacy.BotToken, ChatID: legacy.ChatID},
+		})
+		changed = true
+	}
+
+	for _, service := range services {
+		if service.TelegramBotToken == "" && service.TelegramChatID == "" && service.TelegramEnabled == nil {
+			continue
+		}
+
+		if service.TelegramEnabled != nil && !*service.TelegramEnabled {
+			service.AlertsDisabled = true
+		} else if service.TelegramBotToken != "" || service.TelegramChatID != "" ||
+			(service.TelegramEnabled != nil && legacy != nil && !legacy.Enabled) {
+			// Services without their own flag inherited the global one. An explicit
+			// enable overrode a disabled global config, so it needs its own channel.
+			enabled := service.TelegramEnabled != nil || (legacy != nil && legacy.Enabled)
+
+			// Fill the parts that weren't overridden from the global config
+			config := &models.TelegramConfig{BotToken: service.TelegramBotToken, ChatID: service.TelegramChatID}
+			if legacy != nil {
+				if config.BotToken == "" {
+					config.BotToken = legacy.BotToken
+				}
+				if config.ChatID == "" {
+					config.ChatID = legacy.ChatID
+				}
+			}
+
+			sum := sha256.Sum256([]byte(config.BotToken + "|" + config.ChatID + "|" + strconv.FormatBool(enabled)))
+			id := "telegram-" + hex.EncodeToString(sum[:])[:12]
+			if err := n.Add(&models.NotificationChannel{
+				ID:       id,
+				Name:     "Telegram " + config.ChatID,
+				Type:     models.ChannelTelegram,
+				Enabled:  enabled,
+				Telegram: config,
+			}); err != nil && !errors.Is(err, ErrChannelExists) {
+				fmt.Printf("Warning: could not migrate Telegram override for %s: %v\n", service.Name, err)
+				continue
+			}
+			service.NotificationChannels = []string{id}
+		}
+
+		service.TelegramBotToken = ""
+		service.TelegramChatID = ""
+		service.TelegramEnabled = nil
+		changed = true
+	}
+
+	return changed
+}
+
+// maskChannel returns a copy of a channel with secrets masked for API responses
+func maskChannel(channel *models.NotificationChannel) *models.NotificationChannel {
+	masked := *channel
+	if channel.Telegram != nil {
+		telegram := *channel.Telegram
+		telegram.BotToken = maskToken(telegram.BotToken)
+		masked.Telegram = &telegram
+	}
+	if channel.Webhook != nil {
+		webhook := *channel.Webhook
+		webhook.Secret = maskToken(webhook.Secret)
+		masked.Webhook = &webhook
+	}
+	if channel.Email != nil {
+		email := *channel.Email
+		email.Password = maskPassword(email.Password)
+		masked.Email = &email
+	}
+	return &masked
+}
+
+// preserveSecrets keeps stored secrets when an update sends back masked or empty values
+func preserveSecrets(existing, updated *models.NotificationChannel) {
+	if existing.Telegram != nil && updated.Telegram != nil {
+		if isMaskedSecret(updated.Telegram.BotToken, existing.Telegram.BotToken) {
+			updated.Telegram.BotToken = existing.Telegram.BotToken
+		}
+	}
+	if existing.Webhook != nil && updated.Webhook != nil {
+		if updated.Webhook.Secret != "" && updated.Webhook.Secret == maskToken(existing.Webhook.Secret) {
+			updated.Webhook.Secret = existing.Webhook.Secret
+		}
+	}
+	if existing.Email != nil && updated.Email != nil {
+		if updated.Email.Password == maskPassword(existing.Email.Password) && updated.Email.Password != "" {
+			updated.Email.Password = existing.Email.Password
+		}
+	}
+}
+
+// maskPassword hides a password completely; unlike tokens, no part of it is shown
+func maskPassword(password string) string {
+	if password == "" {
+		return ""
+	}
+	return "********"
+}
+
+// isMaskedSecret reports whether value is empty or the masked form of secret
+func isMaskedSecret(value, secret string) bool {
+	return value == "" || value == maskToken(secret)
+}
+
+// newAlertService copies the fields of a service that are safe to include in alerts
+func newAlertService(service *models.MonitoredService) *models.AlertService {
+	checkType := service.CheckType
+	if checkType == "" {
+		checkType = models.CheckTypeHTTP
+	}
+
+	return &models.AlertService{
+		ID:            service.ID,
+		Name:          service.Name,
+		CheckType:     checkType,
+		Target:        serviceTarget(service),
+		Status:        service.Status,
+		ResponseTime:  service.ResponseTime,
+		ErrorMessage:  service.ErrorMessage,
+		LastCheck:     service.LastCheck,
+		SSLCertExpiry: service.SSLCertExpiry,
+		SSLCertIssuer: service.SSLCertIssuer,
+		SSLDaysLeft:   service.SSLDaysLeft,
+	}
+}
+
+// serviceTarget describes what a service checks, for use in alert messages
+func serviceTarget(service *models.MonitoredService) string {
+	switch service.CheckType {
+	case models.CheckTypeTCP, models.CheckTypeUDP:
+		return strings.ToLower(string(service.CheckType)) + "://" + net.JoinHostPort(service.Host, strconv.Itoa(service.Port))
+	case models.CheckTypeDNS:
+		recordType := service.DNSRecordType
+		if recordType == "" {
+			recordType = "A"
+		}
+		return recordType + " " + service.Host
+	case models.CheckTypeICMP:
+		return service.Host
+	default:
+		return service.URL
+	}
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"monitoring/models"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMigrateLegacyTelegramEnabled(t *testing.T) {
+	enabled, disabled := true, false
+
+	tests := []struct {
+		name          string
+		legacyEnabled bool
+		service       models.MonitoredService
+		wantChannel   bool
+		wantEnabled   bool
+		wantDisabled  bool // AlertsDisabled
+	}{
+		{name: "override inherits enabled", legacyEnabled: true, service: models.MonitoredService{TelegramChatID: "-100"}, wantChannel: true, wantEnabled: true},
+		{name: "override inherits disabled", service: models.MonitoredService{TelegramChatID: "-100"}, wantChannel: true},
+		{name: "explicit enable overrides disabled global", service: models.MonitoredService{TelegramChatID: "-100", TelegramEnabled: &enabled}, wantChannel: true, wantEnabled: true},
+		{name: "explicit enable without override", service: models.MonitoredService{TelegramEnabled: &enabled}, wantChannel: true, wantEnabled: true},
+		{name: "explicit enable matching global", legacyEnabled: true, service: models.MonitoredService{TelegramEnabled: &enabled}},
+		{name: "explicit disable", legacyEnabled: true, service: models.MonitoredService{TelegramChatID: "-100", TelegramEnabled: &disabled}, wantDisabled: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notifications := NewNotificationService()
+			legacy := &models.TelegramConfig{BotToken: "123:abc", ChatID: "42", Enabled: test.legacyEnabled}
+			service := test.service
+
+			if !notifications.MigrateLegacyTelegram(legacy, []*models.MonitoredService{&service}) {
+				t.Fatal("nothing was migrated")
+			}
+			if service.TelegramChatID != "" || service.TelegramEnabled != nil {
+				t.Error("legacy fields were not cleared")
+			}
+			if service.AlertsDisabled != test.wantDisabled {
+				t.Errorf("AlertsDisabled = %v, want %v", service.AlertsDisabled, test.wantDisabled)
+			}
+
+			if !test.wantChannel {
+				if len(service.NotificationChannels) != 0 {
+					t.Fatalf("channels = %v, want the defaults", service.NotificationChannels)
+				}
+				return
+			}
+			if len(service.NotificationChannels) != 1 {
+				t.Fatalf("channels = %v, want one override channel", service.NotificationChannels)
+			}
+			channel, err := notifications.Get(service.NotificationChannels[0])
+			if err != nil {
+				t.Fatalf("override channel: %v", err)
+			}
+			if channel.Enabled != test.wantEnabled {
+				t.Errorf("override channel enabled = %v, want %v", channel.Enabled, test.wantEnabled)
+			}
+		})
+	}
+}
+
+func TestDispatchSendsConcurrently(t *testing.T) {
+	notifications := NewNotificationService()
+	notifiers := map[string]Notifier{"a": nil, "b": nil, "c": nil}
+
+	// Every send waits until all of them have started, which only happens if
+	// they run at the same time
+	var started sync.WaitGroup
+	started.Add(len(notifiers))
+	allStarted := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(allStarted)
+	}()
+
+	var mu sync.Mutex
+	calls := 0
+	err := notifications.dispatch(notifiers, func(Notifier) error {
+		mu.Lock()
+		calls++
+		fail := calls == 1
+		mu.Unlock()
+
+		started.Done()
+		select {
+		case <-allStarted:
+		case <-time.After(5 * time.Second):
+			return errors.New("sent one at a time")
+		}
+		if fail {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "boom") || strings.Contains(err.Error(), "one at a time") {
+		t.Fatalf("error = %v, want only the one failure", err)
+	}
+	var failures uint64
+	for _, count := range notifications.failures {
+		failures += count
+	}
+	if failures != 1 {
+		t.Errorf("%d failures counted, want 1", failures)
+	}
+}
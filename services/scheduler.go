@@ -2,44 +2,198 @@ package services
 
 import (
 	"fmt"
+	"math/rand/v2"
+	"monitoring/models"
+	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
 )
 
-// Scheduler manages periodic health checks
+const (
+	defaultCheckInterval = 60 // seconds, matches the default applied in CreateService
+	defaultMaxWorkers    = 10
+	jitterFraction       = 0.1 // each run is shifted by up to ±10% of the interval
+)
+
+// Scheduler runs each service's health check on its own interval
 type Scheduler struct {
 	cron    *cron.Cron
 	monitor *MonitorService
+	store   *models.ServiceStore
+	workers chan struct{} // bounds the number of checks running at once
+	entries map[string]scheduledEntry
+	lags    map[string]time.Duration // how long each service's last check waited for a worker
+	mu      sync.Mutex
+}
+
+// scheduledEntry links a service to its cron entry
+type scheduledEntry struct {
+	entryID  cron.EntryID
+	interval int
 }
 
-// NewScheduler creates a new scheduler
-func NewScheduler(monitor *MonitorService) *Scheduler {
+// NewScheduler creates a new scheduler that runs at most maxWorkers checks concurrently
+func NewScheduler(monitor *MonitorService, store *models.ServiceStore, maxWorkers int) *Scheduler {
+	if maxWorkers <= 0 {
+		maxWorkers = defaultMaxWorkers
+	}
 	return &Scheduler{
 		cron:    cron.New(cron.WithSeconds()),
 		monitor: monitor,
+		store:   store,
+		workers: make(chan struct{}, maxWorkers),
+		entries: make(map[string]scheduledEntry),
+		lags:    make(map[string]time.Duration),
 	}
 }
 
-// Start begins the scheduled health checks
+// Start schedules every known service and begins watching the store for changes
 func (s *Scheduler) Start() {
-	// Run health checks every 30 seconds
-	_, err := s.cron.AddFunc("*/30 * * * * *", func() {
-		fmt.Printf("[%s] Running scheduled health checks\n", time.Now().Format("2006-01-02 15:04:05"))
-		s.monitor.CheckAll()
-	})
-
-	if err != nil {
-		fmt.Printf("Error adding cron job: %v\n", err)
-		return
+	for _, service := range s.store.GetAll() {
+		s.schedule(service)
 	}
 
+	s.store.Subscribe(s.handleStoreEvent)
+
 	s.cron.Start()
-	fmt.Println("Scheduler started - health checks will run every 30 seconds")
+	fmt.Printf("Scheduler started - %d service(s) scheduled, up to %d concurrent checks\n", len(s.entries), cap(s.workers))
 }
 
-// Stop stops the scheduler
+// Stop stops the scheduler and waits for running checks to finish
 func (s *Scheduler) Stop() {
-	s.cron.Stop()
+	<-s.cron.Stop().Done()
 	fmt.Println("Scheduler stopped")
 }
+
+// handleStoreEvent keeps the schedule in sync with created, updated and deleted services
+func (s *Scheduler) handleStoreEvent(event models.ServiceEvent) {
+	switch event.Type {
+	case models.ServiceCreated, models.ServiceUpdated:
+		s.schedule(event.Service)
+	case models.ServiceDeleted:
+		s.unschedule(event.Service.ID)
+	}
+}
+
+// schedule adds a cron entry for the service, replacing an existing one if the interval changed
+func (s *Scheduler) schedule(service *models.MonitoredService) {
+	interval := service.CheckInterval
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Store events are delivered after the store unlocks, so an update can
+	// arrive after the service was deleted (and unscheduled). A delete after
+	// this check unschedules once the lock is released.
+	if _, err := s.store.Get(service.ID); err != nil {
+		return
+	}
+
+	if existing, ok := s.entries[service.ID]; ok {
+		if existing.interval == interval {
+			return
+		}
+		s.cron.Remove(existing.entryID)
+	}
+
+	serviceID := service.ID
+	job := cron.NewChain(cron.SkipIfStillRunning(cron.DiscardLogger)).Then(cron.FuncJob(func() {
+		s.runCheck(serviceID)
+	}))
+
+	entryID := s.cron.Schedule(newJitterSchedule(time.Duration(interval)*time.Second), job)
+	s.entries[service.ID] = scheduledEntry{entryID: entryID, interval: interval}
+}
+
+// unschedule removes the cron entry for a service
+func (s *Scheduler) unschedule(serviceID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.entries[serviceID]; ok {
+		s.cron.Remove(existing.entryID)
+		delete(s.entries, serviceID)
+	}
+	delete(s.lags, serviceID)
+}
+
+// runCheck waits for a free worker and checks a single service
+func (s *Scheduler) runCheck(serviceID string) {
+	queuedAt := time.Now()
+	s.workers <- struct{}{}
+	defer func() { <-s.workers }()
+
+	s.mu.Lock()
+	if _, ok := s.entries[serviceID]; ok {
+		s.lags[serviceID] = time.Since(queuedAt)
+	}
+	s.mu.Unlock()
+
+	// Re-read the service so the check uses its latest configuration
+	service, err := s.store.Get(serviceID)
+	if err != nil {
+		return
+	}
+
+	result := s.monitor.RunCheck(service)
+	if err := s.monitor.UpdateServiceStatus(result); err != nil {
+		fmt.Printf("Error updating service %s: %v\n", serviceID, err)
+	}
+}
+
+// Lags returns, per service, how long its last scheduled check waited for a
+// free worker. Growing lags mean MAX_CONCURRENT_CHECKS is too low.
+func (s *Scheduler) Lags() map[string]time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lags := make(map[string]time.Duration, len(s.lags))
+	for id, lag := range s.lags {
+		lags[id] = lag
+	}
+	return lags
+}
+
+// RunningChecks returns the number of checks currently running
+func (s *Scheduler) RunningChecks() int {
+	return len(s.workers)
+}
+
+// MaxConcurrentChecks returns the maximum number of checks run at once
+func (s *Scheduler) MaxConcurrentChecks() int {
+	return cap(s.workers)
+}
+
+// jitterSchedule fires every interval, shifted by a random offset so checks
+// with the same interval don't all run at the same instant
+type jitterSchedule struct {
+	interval time.Duration
+	jitter   time.Duration
+	started  bool
+}
+
+func newJitterSchedule(interval time.Duration) *jitterSchedule {
+	return &jitterSchedule{
+		interval: interval,
+		jitter:   time.Duration(float64(interval) * jitterFraction),
+	}
+}
+
+// Next implements cron.Schedule
+func (j *jitterSchedule) Next(t time.Time) time.Time {
+	// Spread the first run across the whole interval
+	if !j.started {
+		j.started = true
+		return t.Add(time.Duration(rand.Int64N(int64(j.interval))) + time.Second)
+	}
+
+	offset := time.Duration(0)
+	if j.jitter > 0 {
+		offset = time.Duration(rand.Int64N(int64(2*j.jitter))) - j.jitter
+	}
+	return t.Add(j.interval + offset)
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"monitoring/models"
+	"testing"
+)
+
+func TestSchedulerIgnoresLateUpdateOfDeletedService(t *testing.T) {
+	store := models.NewServiceStore()
+	scheduler := NewScheduler(nil, store, 1)
+	store.Subscribe(scheduler.handleStoreEvent)
+
+	service := &models.MonitoredService{ID: "svc", Name: "API", CheckInterval: 30}
+	if err := store.Add(service); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if _, ok := scheduler.entries["svc"]; !ok {
+		t.Fatal("created service was not scheduled")
+	}
+
+	if err := store.Delete("svc"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	// An update event delivered after the delete, with a changed interval so
+	// it would replace the entry
+	late := *service
+	late.CheckInterval = 60
+	scheduler.handleStoreEvent(models.ServiceEvent{Type: models.ServiceUpdated, Service: &late})
+
+	if len(scheduler.entries) != 0 || len(scheduler.cron.Entries()) != 0 {
+		t.Fatalf("deleted service is scheduled: %d entries, %d cron entries", len(scheduler.entries), len(scheduler.cron.Entries()))
+	}
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"monitoring/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// statusPageDays is the number of daily uptime bars shown per component
+	statusPageDays = 90
+
+	// statusPageIncidentWindow is how far back incidents are listed
+	statusPageIncidentWindow = 30 * 24 * time.Hour
+
+	// statusPageCacheTTL limits how often the public page is rebuilt from history
+	statusPageCacheTTL = 30 * time.Second
+)
+
+// ErrStatusPageDisabled is returned when the public status page is switched off
+var ErrStatusPageDisabled = errors.New("status page is disabled")
+
+// StatusPageService builds the public status page from services, check
+// history and incidents
+type StatusPageService struct {
+	store     *models.ServiceStore
+	history   *models.HistoryStore
+	incidents *models.IncidentStore
+	config    *models.StatusPageConfig
+	mu        sync.RWMutex
+	onSave    func() // callback when the config changes
+
+	cached   *models.StatusPage
+	cachedAt time.Time
+	cacheMu  sync.Mutex
+}
+
+// NewStatusPageService creates a status page service. Deleted services are
+// removed from the page along with their incidents.
+func NewStatusPageService(store *models.ServiceStore, history *models.HistoryStore, incidents *models.IncidentStore) *StatusPageService {
+	s := &StatusPageService{
+		store:     store,
+		history:   history,
+		incidents: incidents,
+		config: &models.StatusPageConfig{
+			Title:  "Service Status",
+			Groups: []models.StatusPageGroup{},
+		},
+	}
+	store.Subscribe(s.handleStoreEvent)
+	return s
+}
+
+// SetOnSave sets the callback for when the config changes
+func (s *StatusPageService) SetOnSave(onSave func()) {
+	s.onSave = onSave
+}
+
+// triggerSave calls the onSave callback if set
+func (s *StatusPageService) triggerSave() {
+	if s.onSave != nil {
+		go s.onSave()
+	}
+}
+
+// LoadConfig loads the status page configuration (for persistence)
+func (s *StatusPageService) LoadConfig(config *models.StatusPageConfig) {
+	if config == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = config
+}
+
+// GetConfig returns the status page configuration
+func (s *StatusPageService) GetConfig() *models.StatusPageConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return copyStatusPageConfig(s.config)
+}
+
+// UpdateConfig validates and replaces the status page configuration. Components
+// without a display name are published under their service name.
+func (s *StatusPageService) UpdateConfig(config *models.StatusPageConfig) error {
+	config = copyStatusPageConfig(config)
+	config.Title = strings.TrimSpace(config.Title)
+	if config.Title == "" {
+		config.Title = "Service Status"
+	}
+
+	seen := make(map[string]bool)
+	for i := range config.Groups {
+		group := &config.Groups[i]
+		group.Name = strings.TrimSpace(group.Name)
+		if group.Name == "" {
+			return fmt.Errorf("group %d: name is required", i+1)
+		}
+
+		for j := range group.Components {
+			component := &group.Components[j]
+			service, err := s.store.Get(component.ServiceID)
+			if err != nil {
+				return fmt.Errorf("group %q: %w: %s", group.Name, err, component.ServiceID)
+			}
+			if seen[service.ID] {
+				return fmt.Errorf("service %q appears more than once", service.Name)
+			}
+			seen[service.ID] = true
+
+			component.DisplayName = strings.TrimSpace(component.DisplayName)
+			if component.DisplayName == "" {
+				component.DisplayName = service.Name
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.config = config
+	s.mu.Unlock()
+
+	s.invalidate()
+	s.triggerSave()
+	return nil
+}
+
+// GetPage returns the public status page, rebuilding it at most every
+// statusPageCacheTTL
+func (s *StatusPageService) GetPage() (*models.StatusPage, error) {
+	config := s.GetConfig()
+	if !config.Enabled {
+		return nil, ErrStatusPageDisabled
+	}
+
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	if s.cached != nil && time.Since(s.cachedAt) < statusPageCacheTTL {
+		return s.cached, nil
+	}
+
+	page, err := s.build(config, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.cached = page
+	s.cachedAt = time.Now()
+	return page, nil
+}
+
+// build assembles the public page for a configuration
+func (s *StatusPageService) build(config *models.StatusPageConfig, now time.Time) (*models.StatusPage, error) {
+	page := &models.StatusPage{
+		Title:       config.Title,
+		Description: config.Description,
+		UpdatedAt:   now,
+		Groups:      []models.StatusPageGroupView{},
+		Incidents:   []models.StatusPageIncident{},
+	}
+
+	names := make(map[string]string)
+	included := make(map[string]bool)
+	var statuses []models.ComponentStatus
+
+	for _, group := range config.Groups {
+		view := models.StatusPageGroupView{
+			Name:       group.Name,
+			Status:     models.ComponentOperational,
+			Components: []models.StatusPageComponentView{},
+		}
+
+		for _, component := range group.Components {
+			service, err := s.store.Get(component.ServiceID)
+			if err != nil {
+				continue
+			}
+
+			componentView, err := s.buildComponent(service, component.DisplayName, now)
+			if err != nil {
+				return nil, err
+			}
+
+			view.Components = append(view.Components, *componentView)
+			view.Status = worseStatus(view.Status, componentView.Status)
+			statuses = append(statuses, componentView.Status)
+			names[service.ID] = component.DisplayName
+			included[service.ID] = true
+		}
+
+		page.Groups = append(page.Groups, view)
+	}
+
+	page.Status = overallStatus(statuses)
+
+	for _, incident := range s.incidents.GetSince(included, now.Add(-statusPageIncidentWindow)) {
+		page.Incidents = append(page.Incidents, models.StatusPageIncident{
+			Component:  names[incident.ServiceID],
+			StartedAt:  incident.StartedAt,
+			ResolvedAt: incident.ResolvedAt,
+		})
+	}
+
+	return page, nil
+}
+
+// buildComponent returns a component's current status and daily uptime bars,
+// computed from its hourly rollups
+func (s *StatusPageService) buildComponent(service *models.MonitoredService, name string, now time.Time) (*models.StatusPageComponentView, error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+	start := today.AddDate(0, 0, -(statusPageDays - 1))
+
+	rollups, err := s.history.GetRollups(service.ID, "1h", start, now)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make([]models.HistoryRollup, statusPageDays)
+	for _, rollup := range rollups {
+		day := int(rollup.Timestamp.Sub(start) / (24 * time.Hour))
+		if day < 0 || day >= statusPageDays {
+			continue
+		}
+		totals[day].Checks += rollup.Checks
+		totals[day].UpCount += rollup.UpCount
+		totals[day].DownCount += rollup.DownCount
+	}
+
+	view := &models.StatusPageComponentView{
+		Name:   name,
+		Status: componentStatus(service),
+		Days:   make([]models.StatusPageDay, statusPageDays),
+	}
+
+	checks, up := 0, 0
+	for i, total := range totals {
+		view.Days[i] = models.StatusPageDay{
+			Date:      start.AddDate(0, 0, i).Format("2006-01-02"),
+			Uptime:    uptimePercent(total.UpCount, total.Checks),
+			Checks:    total.Checks,
+			DownCount: total.DownCount,
+		}
+		checks += total.Checks
+		up += total.UpCount
+	}
+	view.Uptime = uptimePercent(up, checks)
+
+	return view, nil
+}
+
+// handleStoreEvent removes deleted services from the page
+func (s *StatusPageService) handleStoreEvent(event models.ServiceEvent) {
+	if event.Type != models.ServiceDeleted {
+		return
+	}
+
+	s.incidents.DeleteForService(event.Service.ID)
+
+	s.mu.Lock()
+	changed := false
+	for i := range s.config.Groups {
+		group := &s.config.Groups[i]
+		components := group.Components[:0]
+		for _, component := range group.Components {
+			if component.ServiceID == event.Service.ID {
+				changed = true
+				continue
+			}
+			components = append(components, component)
+		}
+		group.Components = components
+	}
+	s.mu.Unlock()
+
+	s.invalidate()
+	if changed {
+		s.triggerSave()
+	}
+}
+
+// invalidate drops the cached page so the next request rebuilds it
+func (s *StatusPageService) invalidate() {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	s.cached = nil
+}
+
+// componentStatus maps a service's confirmed status to its public status
+func componentStatus(service *models.MonitoredService) models.ComponentStatus {
+	status := service.ConfirmedStatus
+	if status == "" {
+		status = service.Status
+	}
+
+	switch status {
+	case models.StatusUp:
+		return models.ComponentOperational
+	case models.StatusDegraded:
+		return models.ComponentDegraded
+	case models.StatusDown:
+		return models.ComponentOutage
+	default:
+		return models.ComponentUnknown
+	}
+}
+
+// statusSeverity orders component statuses from best to worst
+var statusSeverity = map[models.ComponentStatus]int{
+	models.ComponentOperational: 0,
+	models.ComponentUnknown:     1,
+	models.ComponentDegraded:    2,
+	models.ComponentOutage:      3,
+}
+
+// worseStatus returns the more severe of two component statuses
+func worseStatus(a, b models.ComponentStatus) models.ComponentStatus {
+	if statusSeverity[b] > statusSeverity[a] {
+		return b
+	}
+	return a
+}
+
+// overallStatus summarises all components for the page header
+func overallStatus(statuses []models.ComponentStatus) models.ComponentStatus {
+	outages, degraded := 0, 0
+	for _, status := range statuses {
+		switch status {
+		case models.ComponentOutage:
+			outages++
+		case models.ComponentDegraded:
+			degraded++
+		}
+	}
+
+	switch {
+	case outages > 0 && outages == len(statuses):
+		return models.ComponentMajorOutage
+	case outages > 0:
+		return models.ComponentPartialOutage
+	case degraded > 0:
+		return models.ComponentDegraded
+	default:
+		return models.ComponentOperational
+	}
+}
+
+// uptimePercent returns up/checks as a percentage, or nil without checks
+func uptimePercent(up, checks int) *float64 {
+	if checks == 0 {
+		return nil
+	}
+	percent := float64(up) / float64(checks) * 100
+	return &percent
+}
+
+// copyStatusPageConfig deep-copies a configuration so callers cannot modify the stored one
+func copyStatusPageConfig(config *models.StatusPageConfig) *models.StatusPageConfig {
+	copied := *config
+	copied.Groups = make([]models.StatusPageGroup, len(config.Groups))
+	for i, group := range config.Groups {
+		copied.Groups[i] = models.StatusPageGroup{
+			Name:       group.Name,
+			Components: append([]models.StatusPageComponent{}, group.Components...),
+		}
+	}
+	return &copied
+}
@@ -1,8 +1,11 @@
 package services
 
 import (
+	"fmt"
 	"monitoring/models"
 	"runtime"
+	"sync"
+	"time"
 
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
@@ -12,13 +15,17 @@ import (
 
 // SystemService handles system information and metrics
 type SystemService struct {
-	alertConfig *models.SystemAlertConfig
-	alertState  *models.AlertState
-	telegram    *TelegramService
+	alertConfig   *models.SystemAlertConfig
+	alertState    *models.AlertState
+	alertMu       sync.Mutex // Guards alertConfig and alertState
+	notifications *NotificationService
+	listeners     []func(models.SystemAlert)
+	listenersMu   sync.RWMutex
+	onSave        func()
 }
 
 // NewSystemService creates a new system service
-func NewSystemService(telegram *TelegramService) *SystemService {
+func NewSystemService(notifications *NotificationService) *SystemService {
 	return &SystemService{
 		alertConfig: &models.SystemAlertConfig{
 			DiskSpaceThreshold: 80.0, // Default: alert at 80% disk usage
@@ -26,29 +33,105 @@ func NewSystemService(telegram *TelegramService) *SystemService {
 			MemoryThreshold:    90.0, // Default: alert at 90% memory
 			Enabled:            true,
 		},
-		alertState: &models.AlertState{},
-		telegram:   telegram,
+		alertState:    &models.AlertState{},
+		notifications: notifications,
 	}
 }
 
+// SubscribeAlerts registers a listener that is called whenever a resource alert fires
+func (s *SystemService) SubscribeAlerts(listener func(models.SystemAlert)) {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
 // SetAlertConfig updates the alert configuration
 func (s *SystemService) SetAlertConfig(config *models.SystemAlertConfig) {
+	s.alertMu.Lock()
+	defer s.alertMu.Unlock()
 	s.alertConfig = config
 }
 
 // GetAlertConfig returns the current alert configuration
 func (s *SystemService) GetAlertConfig() *models.SystemAlertConfig {
+	s.alertMu.Lock()
+	defer s.alertMu.Unlock()
 	return s.alertConfig
 }
 
+// UpdateAlertConfig validates and replaces the alert configuration
+func (s *SystemService) UpdateAlertConfig(config *models.SystemAlertConfig) error {
+	thresholds := []struct {
+		name  string
+		value float64
+	}{
+		{"disk_space_threshold", config.DiskSpaceThreshold},
+		{"cpu_threshold", config.CPUThreshold},
+		{"memory_threshold", config.MemoryThreshold},
+	}
+	for _, threshold := range thresholds {
+		if threshold.value <= 0 || threshold.value > 100 {
+			return fmt.Errorf("%s must be above 0 and at most 100", threshold.name)
+		}
+	}
+
+	updated := *config
+	s.SetAlertConfig(&updated)
+	s.triggerSave()
+	return nil
+}
+
+// SetOnSave sets the callback for when the alert configuration changes
+func (s *SystemService) SetOnSave(onSave func()) {
+	s.onSave = onSave
+}
+
+// triggerSave calls the onSave callback if set
+func (s *SystemService) triggerSave() {
+	if s.onSave != nil {
+		go s.onSave()
+	}
+}
+
 // LoadAlertConfig loads alert configuration (for persistence)
 func (s *SystemService) LoadAlertConfig(config *models.SystemAlertConfig) {
 	if config != nil {
-		s.alertConfig = config
+		s.SetAlertConfig(config)
+	}
+}
+
+// WatchAlerts evaluates the system resource alerts every interval. It is the
+// only place alerts are evaluated, so reading system info (the dashboard,
+// Prometheus scrapes) never sends alerts.
+func (s *SystemService) WatchAlerts(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.CheckAlerts()
 	}
 }
 
-// GetSystemInfo retrieves current system information and metrics
+// CheckAlerts reads the system info and sends alerts for thresholds crossed
+// since the previous check, if alerts are enabled
+func (s *SystemService) CheckAlerts() {
+	if !s.GetAlertConfig().Enabled {
+		return
+	}
+
+	info, err := s.GetSystemInfo()
+	if err != nil {
+		fmt.Printf("Error reading system info for alerts: %v\n", err)
+		return
+	}
+
+	s.alertMu.Lock()
+	defer s.alertMu.Unlock()
+	s.checkResourceAlerts(info)
+}
+
+// GetSystemInfo retrieves current system information and metrics. It only
+// reads; alerts are evaluated by CheckAlerts.
 func (s *SystemService) GetSystemInfo() (*models.SystemInfo, error) {
 	info := &models.SystemInfo{}
 
@@ -96,15 +179,12 @@ func (s *SystemService) GetSystemInfo() (*models.SystemInfo, error) {
 		}
 	}
 
-	// Check for alerts if enabled
-	if s.alertConfig.Enabled && s.telegram.IsEnabled() {
-		s.checkResourceAlerts(info)
-	}
-
 	return info, nil
 }
 
-// checkResourceAlerts checks system resources and sends alerts if thresholds are exceeded
+// checkResourceAlerts checks system resources and sends alerts if thresholds
+// are exceeded. Alerts go to the default channels and alert listeners. Callers
+// must hold alertMu.
 func (s *SystemService) checkResourceAlerts(info *models.SystemInfo) {
 	// Check disk space
 	for _, diskInfo := range info.DiskInfo {
@@ -137,17 +217,47 @@ func (s *SystemService) checkResourceAlerts(info *models.SystemInfo) {
 	}
 }
 
-// sendDiskAlert sends a disk space alert via Telegram
+// sendDiskAlert sends a disk space alert to the default notification channels
 func (s *SystemService) sendDiskAlert(diskInfo models.DiskInfo) {
-	go s.telegram.SendSystemAlert("disk", diskInfo.Mountpoint, diskInfo.UsedPercent, s.alertConfig.DiskSpaceThreshold)
+	go s.notifications.SendSystemAlert("disk", diskInfo.Mountpoint, diskInfo.UsedPercent, s.alertConfig.DiskSpaceThreshold)
+	s.notifyListeners(models.SystemAlert{
+		Type:         "disk",
+		Message:      fmt.Sprintf("Disk usage on %s is %.1f%% (threshold %.1f%%)", diskInfo.Mountpoint, diskInfo.UsedPercent, s.alertConfig.DiskSpaceThreshold),
+		CurrentValue: diskInfo.UsedPercent,
+		Threshold:    s.alertConfig.DiskSpaceThreshold,
+		Device:       diskInfo.Mountpoint,
+	})
 }
 
-// sendCPUAlert sends a CPU usage alert via Telegram
+// sendCPUAlert sends a CPU usage alert to the default notification channels
 func (s *SystemService) sendCPUAlert(usage float64) {
-	go s.telegram.SendSystemAlert("cpu", "", usage, s.alertConfig.CPUThreshold)
+	go s.notifications.SendSystemAlert("cpu", "", usage, s.alertConfig.CPUThreshold)
+	s.notifyListeners(models.SystemAlert{
+		Type:         "cpu",
+		Message:      fmt.Sprintf("CPU usage is %.1f%% (threshold %.1f%%)", usage, s.alertConfig.CPUThreshold),
+		CurrentValue: usage,
+		Threshold:    s.alertConfig.CPUThreshold,
+	})
 }
 
-// sendMemoryAlert sends a memory usage alert via Telegram
+// sendMemoryAlert sends a memory usage alert to the default notification channels
 func (s *SystemService) sendMemoryAlert(usage float64) {
-	go s.telegram.SendSystemAlert("memory", "", usage, s.alertConfig.MemoryThreshold)
+	go s.notifications.SendSystemAlert("memory", "", usage, s.alertConfig.MemoryThreshold)
+	s.notifyListeners(models.SystemAlert{
+		Type:         "memory",
+		Message:      fmt.Sprintf("Memory usage is %.1f%% (threshold %.1f%%)", usage, s.alertConfig.MemoryThreshold),
+		CurrentValue: usage,
+		Threshold:    s.alertConfig.MemoryThreshold,
+	})
+}
+
+// notifyListeners delivers a fired alert to all alert listeners
+func (s *SystemService) notifyListeners(alert models.SystemAlert) {
+	s.listenersMu.RLock()
+	listeners := s.listeners
+	s.listenersMu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(alert)
+	}
 }
@@ -6,191 +6,81 @@ import (
 	"fmt"
 	"monitoring/models"
 	"net/http"
-	"sync"
 	"time"
 )
 
-// TelegramService handles sending notifications via Telegram
+// TelegramService sends notifications to a Telegram chat. It implements Notifier.
 type TelegramService struct {
 	config *models.TelegramConfig
-	mu     sync.RWMutex
-	onSave func() // callback when config changes
 }
 
-// NewTelegramService creates a new Telegram notification service
-func NewTelegramService() *TelegramService {
+// NewTelegramService creates a Telegram notifier for the given bot and chat
+func NewTelegramService(config *models.TelegramConfig) *TelegramService {
 	return &TelegramService{
-		config: &models.TelegramConfig{
-			Enabled: false,
-		},
+		config: config,
 	}
 }
 
-// SetOnSave sets the callback for when config changes
-func (t *TelegramService) SetOnSave(onSave func()) {
-	t.onSave = onSave
-}
-
-// LoadConfig loads configuration (used during startup)
-func (t *TelegramService) LoadConfig(config *models.TelegramConfig) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-	if config != nil {
-		t.config = config
-	}
-}
-
-// GetRawConfig returns the actual config (for persistence)
-func (t *TelegramService) GetRawConfig() *models.TelegramConfig {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
-	return t.config
-}
-
-// SetConfig updates the Telegram configuration
-func (t *TelegramService) SetConfig(config *models.TelegramConfig) {
-	t.mu.Lock()
-	t.config = config
-	t.mu.Unlock()
-
-	if t.onSave != nil {
-		go t.onSave()
-	}
-}
-
-// GetConfig returns the current Telegram configuration
-func (t *TelegramService) GetConfig() *models.TelegramConfig {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
-
-	// Return a copy without exposing the token fully
-	return &models.TelegramConfig{
-		BotToken: maskToken(t.config.BotToken),
-		ChatID:   t.config.ChatID,
-		Enabled:  t.config.Enabled,
-	}
-}
-
-// IsEnabled checks if Telegram notifications are enabled
-func (t *TelegramService) IsEnabled() bool {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
-	return t.config.Enabled && t.config.BotToken != "" && t.config.ChatID != ""
-}
-
-// getEffectiveConfig returns the effective configuration for a service
-// Uses service-specific overrides if provided, otherwise falls back to default
-func (t *TelegramService) getEffectiveConfig(service *models.MonitoredService) (botToken, chatID string, enabled bool) {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
-
-	// Start with defaults
-	botToken = t.config.BotToken
-	chatID = t.config.ChatID
-	enabled = t.config.Enabled
-
-	// Override with service-specific settings if provided
-	if service.TelegramBotToken != "" {
-		botToken = service.TelegramBotToken
-	}
-	if service.TelegramChatID != "" {
-		chatID = service.TelegramChatID
-	}
-	if service.TelegramEnabled != nil {
-		enabled = *service.TelegramEnabled
-	}
-
-	return botToken, chatID, enabled
-}
-
-// isEnabledForService checks if Telegram notifications are enabled for a specific service
-func (t *TelegramService) isEnabledForService(service *models.MonitoredService) bool {
-	botToken, chatID, enabled := t.getEffectiveConfig(service)
-	return enabled && botToken != "" && chatID != ""
-}
-
 // SendServiceDownAlert sends an alert when a service goes down
 func (t *TelegramService) SendServiceDownAlert(service *models.MonitoredService) error {
-	if !t.isEnabledForService(service) {
-		return nil
-	}
-
-	botToken, chatID, _ := t.getEffectiveConfig(service)
-
 	message := fmt.Sprintf(
 		"🔴 *Service Down Alert*\n\n"+
 			"*Service:* %s\n"+
-			"*URL:* %s\n"+
+			"*Target:* %s\n"+
 			"*Status:* DOWN\n"+
 			"*Error:* %s\n"+
 			"*Time:* %s",
 		escapeMarkdown(service.Name),
-		escapeMarkdown(service.URL),
+		escapeMarkdown(serviceTarget(service)),
 		escapeMarkdown(service.ErrorMessage),
 		service.LastCheck.Format("2006-01-02 15:04:05"),
 	)
 
-	return t.sendMessageWithConfig(message, botToken, chatID)
+	return t.sendMessage(message)
 }
 
 // SendServiceUpAlert sends an alert when a service comes back up
 func (t *TelegramService) SendServiceUpAlert(service *models.MonitoredService) error {
-	if !t.isEnabledForService(service) {
-		return nil
-	}
-
-	botToken, chatID, _ := t.getEffectiveConfig(service)
-
 	message := fmt.Sprintf(
 		"🟢 *Service Recovered*\n\n"+
 			"*Service:* %s\n"+
-			"*URL:* %s\n"+
+			"*Target:* %s\n"+
 			"*Status:* UP\n"+
 			"*Response Time:* %dms\n"+
 			"*Time:* %s",
 		escapeMarkdown(service.Name),
-		escapeMarkdown(service.URL),
+		escapeMarkdown(serviceTarget(service)),
 		service.ResponseTime,
 		service.LastCheck.Format("2006-01-02 15:04:05"),
 	)
 
-	return t.sendMessageWithConfig(message, botToken, chatID)
+	return t.sendMessage(message)
 }
 
 // SendSSLExpiryAlert sends an alert when SSL certificate is expiring soon
 func (t *TelegramService) SendSSLExpiryAlert(service *models.MonitoredService) error {
-	if !t.isEnabledForService(service) {
-		return nil
-	}
-
-	botToken, chatID, _ := t.getEffectiveConfig(service)
-
 	message := fmt.Sprintf(
 		"🔒 *SSL Certificate Expiry Alert*\n\n"+
 			"*Service:* %s\n"+
-			"*URL:* %s\n"+
+			"*Target:* %s\n"+
 			"*Days Until Expiry:* %d\n"+
 			"*Expiry Date:* %s\n"+
 			"*Issuer:* %s\n"+
 			"*Time:* %s\n\n"+
 			"_Please renew your SSL certificate\\\\._",
 		escapeMarkdown(service.Name),
-		escapeMarkdown(service.URL),
+		escapeMarkdown(serviceTarget(service)),
 		service.SSLDaysLeft,
 		service.SSLCertExpiry.Format("2006-01-02 15:04:05"),
 		escapeMarkdown(service.SSLCertIssuer),
 		time.Now().Format("2006-01-02 15:04:05"),
 	)
 
-	return t.sendMessageWithConfig(message, botToken, chatID)
+	return t.sendMessage(message)
 }
 
 // SendSystemAlert sends a system resource alert
 func (t *TelegramService) SendSystemAlert(resourceType string, device string, currentValue float64, threshold float64) error {
-	if !t.IsEnabled() {
-		return nil
-	}
-
 	var emoji, resourceName, deviceInfo string
 
 	switch resourceType {
@@ -230,59 +120,21 @@ func (t *TelegramService) SendSystemAlert(resourceType string, device string, cu
 
 // SendTestMessage sends a test notification
 func (t *TelegramService) SendTestMessage() error {
-	t.mu.RLock()
-	botToken := t.config.BotToken
-	chatID := t.config.ChatID
-	t.mu.RUnlock()
-
-	if botToken == "" || chatID == "" {
+	if t.config.BotToken == "" || t.config.ChatID == "" {
 		return fmt.Errorf("bot token and chat ID are required")
 	}
 
 	message := "✅ *Test Notification*\n\nYour monitoring service is successfully connected to Telegram!"
 
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", botToken)
-
-	payload := map[string]interface{}{
-		"chat_id":    chatID,
-		"text":       message,
-		"parse_mode": "Markdown",
-	}
-
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %v", err)
-	}
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to send message: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("telegram API returned status code: %d", resp.StatusCode)
-	}
-
-	return nil
+	return t.sendMessage(message)
 }
 
-// sendMessage sends a message to Telegram using the default config
+// sendMessage sends a message to the configured Telegram chat
 func (t *TelegramService) sendMessage(message string) error {
-	t.mu.RLock()
-	botToken := t.config.BotToken
-	chatID := t.config.ChatID
-	t.mu.RUnlock()
-
-	return t.sendMessageWithConfig(message, botToken, chatID)
-}
-
-// sendMessageWithConfig sends a message to Telegram with specific bot token and chat ID
-func (t *TelegramService) sendMessageWithConfig(message, botToken, chatID string) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", botToken)
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", t.config.BotToken)
 
 	payload := map[string]interface{}{
-		"chat_id":    chatID,
+		"chat_id":    t.config.ChatID,
 		"text":       message,
 		"parse_mode": "Markdown",
 	}
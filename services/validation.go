@@ -0,0 +1,66 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"monitoring/models"
+	"regexp"
+	"strings"
+)
+
+// ValidateService checks the check configuration of a service before it is
+// stored, so mistakes are reported to the user instead of as check failures.
+// The legacy Telegram overrides are rejected, as nothing reads them anymore.
+func ValidateService(service *models.MonitoredService) error {
+	if service.TelegramBotToken != "" || service.TelegramChatID != "" || service.TelegramEnabled != nil {
+		return errors.New("telegram_bot_token, telegram_chat_id and telegram_enabled are no longer supported; use notification_channels")
+	}
+
+	switch service.CheckType {
+	case "", models.CheckTypeHTTP, models.CheckTypeTCP, models.CheckTypeUDP,
+		models.CheckTypeDNS, models.CheckTypeICMP, models.CheckTypeAgent:
+	default:
+		return fmt.Errorf("unknown check type %q", service.CheckType)
+	}
+
+	for _, pattern := range service.ExpectedStatusCodes {
+		if _, err := matchStatusCode(0, pattern); err != nil {
+			return err
+		}
+	}
+
+	for i, assertion := range service.BodyAssertions {
+		switch assertion.Type {
+		case models.AssertContains, models.AssertNotContains:
+		case models.AssertRegex:
+			if _, err := regexp.Compile(assertion.Value); err != nil {
+				return fmt.Errorf("assertion %d: invalid regex %q: %v", i+1, assertion.Value, err)
+			}
+		case models.AssertJSONPath:
+			if err := validateJSONPath(assertion.Path); err != nil {
+				return fmt.Errorf("assertion %d: %v", i+1, err)
+			}
+		default:
+			return fmt.Errorf("assertion %d: unknown assertion type %q", i+1, assertion.Type)
+		}
+	}
+
+	if service.DNSRecordType != "" {
+		if _, ok := dnsRecordTypes[strings.ToUpper(service.DNSRecordType)]; !ok {
+			return fmt.Errorf("unsupported DNS record type %q", service.DNSRecordType)
+		}
+	}
+	switch service.DNSMatchMode {
+	case "", models.DNSMatchContains, models.DNSMatchExact:
+	default:
+		return fmt.Errorf("unknown DNS match mode %q", service.DNSMatchMode)
+	}
+
+	if service.CheckType == models.CheckTypeICMP {
+		if err := validatePingCount(service); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"monitoring/models"
+	"testing"
+)
+
+func TestValidateService(t *testing.T) {
+	tests := map[string]func(*models.MonitoredService){
+		"unknown check type":     func(s *models.MonitoredService) { s.CheckType = "smtp" },
+		"invalid status code":    func(s *models.MonitoredService) { s.ExpectedStatusCodes = []string{"200", "ok"} },
+		"unknown assertion type": func(s *models.MonitoredService) { s.BodyAssertions = []models.BodyAssertion{{Type: "xpath"}} },
+		"invalid regex": func(s *models.MonitoredService) {
+			s.BodyAssertions = []models.BodyAssertion{{Type: models.AssertRegex, Value: "[a-"}}
+		},
+		"invalid JSON path": func(s *models.MonitoredService) {
+			s.BodyAssertions = []models.BodyAssertion{{Type: models.AssertJSONPath, Path: "$.items[x]"}}
+		},
+		"unknown DNS record type": func(s *models.MonitoredService) { s.DNSRecordType = "PTR" },
+		"unknown DNS match mode":  func(s *models.MonitoredService) { s.DNSMatchMode = "prefix" },
+		"legacy Telegram token":   func(s *models.MonitoredService) { s.TelegramBotToken = "123:abc" },
+		"legacy Telegram chat":    func(s *models.MonitoredService) { s.TelegramChatID = "-100" },
+		"legacy Telegram enabled": func(s *models.MonitoredService) { s.TelegramEnabled = new(bool) },
+	}
+
+	valid := func() *models.MonitoredService {
+		return &models.MonitoredService{
+			CheckType:           models.CheckTypeHTTP,
+			URL:                 "https://example.com/health",
+			ExpectedStatusCodes: []string{"2xx", "301-302"},
+			BodyAssertions: []models.BodyAssertion{
+				{Type: models.AssertContains, Value: "ok"},
+				{Type: models.AssertRegex, Value: `v\d+`},
+				{Type: models.AssertJSONPath, Path: "$.data[0].status", Value: "up"},
+			},
+			DNSRecordType: "aaaa",
+			DNSMatchMode:  models.DNSMatchExact,
+		}
+	}
+
+	for name, modify := range tests {
+		service := valid()
+		modify(service)
+		if err := ValidateService(service); err == nil {
+			t.Errorf("%s: ValidateService accepted the service", name)
+		}
+	}
+
+	if err := ValidateService(valid()); err != nil {
+		t.Errorf("valid service rejected: %v", err)
+	}
+	if err := ValidateService(&models.MonitoredService{Host: "example.com"}); err != nil {
+		t.Errorf("service with defaults rejected: %v", err)
+	}
+}
+
+func TestValidatePingCount(t *testing.T) {
+	tests := []struct {
+		count   int
+		timeout int
+		valid   bool
+	}{
+		{count: 0, timeout: 0, valid: true},
+		{count: 20, timeout: 10, valid: true},
+		{count: 4, timeout: 1, valid: true},
+		{count: 5, timeout: 1, valid: false},
+		{count: 21, timeout: 30, valid: false},
+		{count: -1, timeout: 10, valid: false},
+	}
+	for _, test := range tests {
+		service := &models.MonitoredService{CheckType: models.CheckTypeICMP, Host: "10.0.0.1", PingCount: test.count, Timeout: test.timeout}
+		if err := ValidateService(service); (err == nil) != test.valid {
+			t.Errorf("ping_count %d with a %ds timeout: error = %v, want valid = %v", test.count, test.timeout, err, test.valid)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"monitoring/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	defaultWebhookRetries = 3
+	maxWebhookRetries     = 10
+	defaultWebhookTimeout = 10 * time.Second
+	webhookBaseBackoff    = time.Second
+	webhookMaxBackoff     = time.Minute // longest pause between two attempts
+	webhookResponseLimit  = 512         // bytes of the response body kept in the delivery log
+)
+
+// webhookTemplateFuncs are available inside webhook body templates
+var webhookTemplateFuncs = template.FuncMap{
+	// json encodes a value, so strings can be embedded safely: {"name": {{json .Service.Name}}}
+	"json": func(v interface{}) (string, error) {
+		encoded, err := json.Marshal(v)
+		return string(encoded), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// WebhookService sends alerts to an HTTP endpoint. It implements Notifier.
+type WebhookService struct {
+	channelID string
+	config    *models.WebhookConfig
+	template  *template.Template
+	client    *http.Client
+	record    func(models.DeliveryAttempt)
+	backoff   time.Duration
+}
+
+// NewWebhookService creates a webhook notifier. record is called for every delivery attempt.
+func NewWebhookService(channelID string, config *models.WebhookConfig, record func(models.DeliveryAttempt)) (*WebhookService, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("webhook URL is required")
+	}
+	if config.MaxRetries != nil && (*config.MaxRetries < 0 || *config.MaxRetries > maxWebhookRetries) {
+		return nil, fmt.Errorf("max_retries must be between 0 and %d", maxWebhookRetries)
+	}
+	if config.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must not be negative")
+	}
+
+	var tmpl *template.Template
+	if config.BodyTemplate != "" {
+		parsed, err := template.New("webhook").Funcs(webhookTemplateFuncs).Option("missingkey=zero").Parse(config.BodyTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body template: %v", err)
+		}
+		tmpl = parsed
+	}
+
+	timeout := time.Duration(config.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = defaultWebhookTimeout
+	}
+
+	return &WebhookService{
+		channelID: channelID,
+		config:    config,
+		template:  tmpl,
+		client:    &http.Client{Timeout: timeout},
+		record:    record,
+		backoff:   webhookBaseBackoff,
+	}, nil
+}
+
+// SendServiceDownAlert sends an alert when a service goes down
+func (w *WebhookService) SendServiceDownAlert(service *models.MonitoredService) error {
+	return w.deliver(w.serviceEvent(models.EventServiceDown, service,
+		fmt.Sprintf("Service %s is DOWN: %s", service.Name, service.ErrorMessage)))
+}
+
+// SendServiceUpAlert sends an alert when a service comes back up
+func (w *WebhookService) SendServiceUpAlert(service *models.MonitoredService) error {
+	return w.deliver(w.serviceEvent(models.EventServiceUp, service,
+		fmt.Sprintf("Service %s is UP (%dms)", service.Name, service.ResponseTime)))
+}
+
+// SendSSLExpiryAlert sends an alert when SSL certificate is expiring soon
+func (w *WebhookService) SendSSLExpiryAlert(service *models.MonitoredService) error {
+	return w.deliver(w.serviceEvent(models.EventSSLExpiry, service,
+		fmt.Sprintf("SSL certificate for %s expires in %d days", service.Name, service.SSLDaysLeft)))
+}
+
+// SendSystemAlert sends a system resource alert
+func (w *WebhookService) SendSystemAlert(resourceType string, device string, currentValue float64, threshold float64) error {
+	message := fmt.Sprintf("%s usage is %.1f%% (threshold %.1f%%)", resourceType, currentValue, threshold)
+	if device != "" {
+		message = fmt.Sprintf("%s usage on %s is %.1f%% (threshold %.1f%%)", resourceType, device, currentValue, threshold)
+	}
+
+	return w.deliver(&models.AlertEvent{
+		Event:     models.EventSystem,
+		Timestamp: time.Now(),
+		Message:   message,
+		System: &models.SystemAlert{
+			Type:         resourceType,
+			Message:      message,
+			CurrentValue: currentValue,
+			Threshold:    threshold,
+			Device:       device,
+		},
+	})
+}
+
+// SendTestMessage sends a test notification
+func (w *WebhookService) SendTestMessage() error {
+	return w.deliver(&models.AlertEvent{
+		Event:     models.EventTest,
+		Timestamp: time.Now(),
+		Message:   "Your monitoring service is successfully connected to this webhook!",
+	})
+}
+
+// serviceEvent builds the event for a service alert
+func (w *WebhookService) serviceEvent(event models.NotificationEvent, service *models.MonitoredService, message string) *models.AlertEvent {
+	return &models.AlertEvent{
+		Event:     event,
+		Timestamp: time.Now(),
+		Message:   message,
+		Service:   newAlertService(service),
+	}
+}
+
+// renderBody produces the request body for an event
+func (w *WebhookService) renderBody(event *models.AlertEvent) ([]byte, error) {
+	if w.template == nil {
+		return json.Marshal(event)
+	}
+
+	var buffer bytes.Buffer
+	if err := w.template.Execute(&buffer, event); err != nil {
+		return nil, fmt.Errorf("failed to render body template: %v", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// deliver sends the event, retrying with exponential backoff on network errors,
+// 429 and 5xx responses
+func (w *WebhookService) deliver(event *models.AlertEvent) error {
+	body, err := w.renderBody(event)
+	if err != nil {
+		w.recordAttempt(models.DeliveryAttempt{Event: event.Event, Attempt: 1, Timestamp: time.Now(), Error: err.Error()})
+		return err
+	}
+
+	retries := defaultWebhookRetries
+	if w.config.MaxRetries != nil {
+		retries = *w.config.MaxRetries
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= retries+1; attempt++ {
+		if attempt > 1 {
+			time.Sleep(webhookBackoff(w.backoff, attempt))
+		}
+
+		retryable, err := w.send(event.Event, attempt, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retryable {
+			break
+		}
+	}
+
+	return lastErr
+}
+
+// webhookBackoff returns the pause before an attempt: base before the second,
+// doubling for each one after, and never more than webhookMaxBackoff
+func webhookBackoff(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 2; i < attempt && delay < webhookMaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookMaxBackoff)
+}
+
+// send performs a single delivery attempt and reports whether a failure may be retried
+func (w *WebhookService) send(event models.NotificationEvent, attempt int, body []byte) (bool, error) {
+	method := strings.ToUpper(w.config.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	record := models.DeliveryAttempt{
+		Event:     event,
+		Attempt:   attempt,
+		Timestamp: time.Now(),
+	}
+	defer func() {
+		record.Duration = time.Since(record.Timestamp).Milliseconds()
+		w.recordAttempt(record)
+	}()
+
+	req, err := http.NewRequest(method, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		record.Error = err.Error()
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "monitoring-webhook/1.0")
+	req.Header.Set("X-Webhook-Event", string(event))
+	for name, value := range w.config.Headers {
+		req.Header.Set(name, value)
+	}
+
+	if w.config.Secret != "" {
+		timestamp := strconv.FormatInt(record.Timestamp.Unix(), 10)
+		req.Header.Set("X-Webhook-Timestamp", timestamp)
+		req.Header.Set("X-Webhook-Signature", "sha256="+signWebhook(w.config.Secret, timestamp, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		record.Error = err.Error()
+		return true, fmt.Errorf("webhook request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	record.StatusCode = resp.StatusCode
+	record.Response = string(responseBody)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		record.Success = true
+		return false, nil
+	}
+
+	err = fmt.Errorf("webhook returned status code: %d", resp.StatusCode)
+	record.Error = err.Error()
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retryable, err
+}
+
+// recordAttempt reports a delivery attempt to the registry
+func (w *WebhookService) recordAttempt(attempt models.DeliveryAttempt) {
+	if w.record == nil {
+		return
+	}
+	attempt.ChannelID = w.channelID
+	w.record(attempt)
+}
+
+// signWebhook computes the hex HMAC-SHA256 of "timestamp.body", so receivers can
+// verify both the payload and that it is recent
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"monitoring/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookStub records the requests it receives and answers each with the
+// next status code from its list, repeating the last one
+type webhookStub struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func startWebhookStub(t *testing.T, statuses ...int) *webhookStub {
+	t.Helper()
+
+	stub := &webhookStub{statuses: statuses}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		stub.mu.Lock()
+		status := stub.statuses[min(len(stub.requests), len(stub.statuses)-1)]
+		stub.requests = append(stub.requests, r)
+		stub.bodies = append(stub.bodies, body)
+		stub.mu.Unlock()
+
+		w.WriteHeader(status)
+		io.WriteString(w, http.StatusText(status))
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+// newTestWebhook creates a webhook notifier with a short backoff that records its attempts
+func newTestWebhook(t *testing.T, config *models.WebhookConfig) (*WebhookService, *[]models.DeliveryAttempt) {
+	t.Helper()
+
+	var attempts []models.DeliveryAttempt
+	webhook, err := NewWebhookService("hook", config, func(attempt models.DeliveryAttempt) {
+		attempts = append(attempts, attempt)
+	})
+	if err != nil {
+		t.Fatalf("NewWebhookService: %v", err)
+	}
+	webhook.backoff = time.Millisecond
+	return webhook, &attempts
+}
+
+func TestWebhookSignature(t *testing.T) {
+	stub := startWebhookStub(t, http.StatusOK)
+	webhook, _ := newTestWebhook(t, &models.WebhookConfig{
+		URL:     stub.URL,
+		Secret:  "s3cret",
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	})
+
+	service := &models.MonitoredService{ID: "svc", Name: "API", URL: "https://api.example.com", ErrorMessage: "timeout"}
+	if err := webhook.SendServiceDownAlert(service); err != nil {
+		t.Fatalf("SendServiceDownAlert: %v", err)
+	}
+	if len(stub.requests) != 1 {
+		t.Fatalf("received %d requests, want 1", len(stub.requests))
+	}
+
+	request, body := stub.requests[0], stub.bodies[0]
+	timestamp := request.Header.Get("X-Webhook-Timestamp")
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Fatalf("X-Webhook-Timestamp = %q, want Unix seconds", timestamp)
+	}
+	if got, want := request.Header.Get("X-Webhook-Signature"), "sha256="+signWebhook("s3cret", timestamp, body); got != want {
+		t.Errorf("X-Webhook-Signature = %q, want %q", got, want)
+	}
+	if got := request.Header.Get("X-Webhook-Event"); got != string(models.EventServiceDown) {
+		t.Errorf("X-Webhook-Event = %q, want %s", got, models.EventServiceDown)
+	}
+	if got := request.Header.Get("Authorization"); got != "Bearer token" {
+		t.Errorf("Authorization = %q, want the configured header", got)
+	}
+
+	var event models.AlertEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		t.Fatalf("body is not an alert event: %v", err)
+	}
+	if event.Event != models.EventServiceDown || event.Service == nil || event.Service.Name != "API" {
+		t.Errorf("event = %+v, want a service_down event for API", event)
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	// echo -n '1700000000.{"a":1}' | openssl dgst -sha256 -hmac key
+	got := signWebhook("key", "1700000000", []byte(`{"a":1}`))
+	if want := "a438e398bfafc57e4396bb7fc2304422f0f768e965d073ca313cb52e22e6ad03"; got != want {
+		t.Fatalf("signWebhook = %q, want %q", got, want)
+	}
+	if signWebhook("key", "1700000001", []byte(`{"a":1}`)) == got {
+		t.Error("signature does not cover the timestamp")
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		maxRetries   int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "success", statuses: []int{200}, maxRetries: 3, wantAttempts: 1},
+		{name: "5xx retried until success", statuses: []int{500, 503, 204}, maxRetries: 3, wantAttempts: 3},
+		{name: "429 retried", statuses: []int{429, 200}, maxRetries: 3, wantAttempts: 2},
+		{name: "retries exhausted", statuses: []int{502}, maxRetries: 2, wantAttempts: 3, wantErr: true},
+		{name: "4xx not retried", statuses: []int{404, 200}, maxRetries: 3, wantAttempts: 1, wantErr: true},
+		{name: "retries disabled", statuses: []int{500, 200}, maxRetries: 0, wantAttempts: 1, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stub := startWebhookStub(t, test.statuses...)
+			retries := test.maxRetries
+			webhook, attempts := newTestWebhook(t, &models.WebhookConfig{URL: stub.URL, MaxRetries: &retries})
+
+			err := webhook.SendTestMessage()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("error = %v, want error = %v", err, test.wantErr)
+			}
+			if len(stub.requests) != test.wantAttempts || len(*attempts) != test.wantAttempts {
+				t.Fatalf("%d requests and %d recorded attempts, want %d", len(stub.requests), len(*attempts), test.wantAttempts)
+			}
+			for i, attempt := range *attempts {
+				if attempt.Attempt != i+1 || attempt.ChannelID != "hook" || attempt.StatusCode != test.statuses[min(i, len(test.statuses)-1)] {
+					t.Errorf("attempt %d = %+v", i+1, attempt)
+				}
+			}
+			if last := (*attempts)[len(*attempts)-1]; last.Success == test.wantErr {
+				t.Errorf("last attempt success = %v, want %v", last.Success, !test.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhookRetriesNetworkErrors(t *testing.T) {
+	stub := startWebhookStub(t, http.StatusOK)
+	url := stub.URL
+	stub.Close()
+
+	retries := 2
+	webhook, attempts := newTestWebhook(t, &models.WebhookConfig{URL: url, MaxRetries: &retries, Timeout: 1})
+	if err := webhook.SendTestMessage(); err == nil {
+		t.Fatal("delivery to a closed server succeeded")
+	}
+	if len(*attempts) != 3 {
+		t.Fatalf("%d attempts, want 3", len(*attempts))
+	}
+	for _, attempt := range *attempts {
+		if attempt.Error == "" || attempt.StatusCode != 0 {
+			t.Errorf("attempt = %+v, want a network error", attempt)
+		}
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := map[int]time.Duration{
+		2:  time.Second,
+		3:  2 * time.Second,
+		4:  4 * time.Second,
+		7:  32 * time.Second,
+		8:  time.Minute,
+		11: time.Minute,
+		70: time.Minute,
+	}
+	for attempt, want := range tests {
+		if got := webhookBackoff(time.Second, attempt); got != want {
+			t.Errorf("webhookBackoff(1s, %d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestNewWebhookServiceValidation(t *testing.T) {
+	negative, tooMany, most := -1, maxWebhookRetries+1, maxWebhookRetries
+
+	tests := map[string]*models.WebhookConfig{
+		"missing URL":          {},
+		"negative max_retries": {URL: "http://example.com", MaxRetries: &negative},
+		"too many retries":     {URL: "http://example.com", MaxRetries: &tooMany},
+		"negative timeout":     {URL: "http://example.com", Timeout: -1},
+		"invalid template":     {URL: "http://example.com", BodyTemplate: "{{.Event"},
+	}
+	for name, config := range tests {
+		if _, err := NewWebhookService("hook", config, nil); err == nil {
+			t.Errorf("%s: NewWebhookService accepted the config", name)
+		}
+	}
+
+	if _, err := NewWebhookService("hook", &models.WebhookConfig{URL: "http://example.com", MaxRetries: &most}, nil); err != nil {
+		t.Errorf("max_retries %d rejected: %v", most, err)
+	}
+}